@@ -0,0 +1,281 @@
+package cmd
+
+//spellchecker:words path filepath strings essio shellescape github cobra ggman internal dirs pkglib exit
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/dirs"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/ggman/internal/path"
+	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/fsx"
+)
+
+//spellchecker:words positionals unarchive GGROOT GGARCHIVE rmdir wrapcheck
+
+func NewArchiveCommand() *cobra.Command {
+	impl := new(archive)
+
+	cmd := &cobra.Command{
+		Use:   "archive PATTERN",
+		Short: "Move repositories matching a pattern into the archive",
+		Long: `Archive moves all repositories matching PATTERN from '$GGROOT' into '$GGARCHIVE'.
+PATTERN is interpreted like the '--for' flag, except that fuzzy matching is disabled.
+
+Repositories keep their location relative to the root folder.
+For example, '$GGROOT/github.com/hello/world' is moved to '$GGARCHIVE/github.com/hello/world'.
+Repositories of roots other than the primary root are moved into '$GGARCHIVE/.ggroot' followed by the absolute path of their root.
+For example, '/mnt/work/github.com/hello/world' is moved to '$GGARCHIVE/.ggroot/mnt/work/github.com/hello/world'.
+Parent directories that become empty are removed afterwards.
+
+Archived repositories are not found by other commands.
+Use 'ggman ls --archived' to list them, and 'ggman unarchive' to restore them.
+
+Output consists of unix-like commands performing the move.
+The '--simulate' flag only prints these commands, without moving anything.`,
+		Args: cobra.ExactArgs(1),
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Simulate, "simulate", "s", false, "only print unix-like commands to archive repositories")
+
+	return cmd
+}
+
+func NewUnarchiveCommand() *cobra.Command {
+	impl := &archive{Unarchive: true}
+
+	cmd := &cobra.Command{
+		Use:   "unarchive PATTERN",
+		Short: "Move repositories matching a pattern out of the archive",
+		Long: `Unarchive moves all repositories matching PATTERN from '$GGARCHIVE' back into '$GGROOT'.
+It is the inverse of 'ggman archive'.
+Each repository is restored into the root it was archived from, which must still be part of '$GGROOT'.
+
+Output consists of unix-like commands performing the move.
+The '--simulate' flag only prints these commands, without moving anything.`,
+		Args: cobra.ExactArgs(1),
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Simulate, "simulate", "s", false, "only print unix-like commands to unarchive repositories")
+
+	return cmd
+}
+
+type archive struct {
+	Positionals struct {
+		Pattern string
+	}
+	Simulate  bool
+	Unarchive bool
+}
+
+var (
+	errArchiveNoRepos      = exit.NewErrorWithCode("failed to find any matching repositories", env.ExitInvalidRepo)
+	errArchiveScan         = exit.NewErrorWithCode("failed to scan for repositories", env.ExitGeneric)
+	errArchiveOutside      = exit.NewErrorWithCode("failed to move repository: repository is outside of source folder", env.ExitGeneric)
+	errArchiveExists       = exit.NewErrorWithCode("failed to move repository: path already exists", env.ExitGeneric)
+	errArchiveUnknownRoot  = exit.NewErrorWithCode("failed to move repository: archived from a root that is not in '$GGROOT'", env.ExitGeneric)
+	errArchiveCreateParent = exit.NewErrorWithCode("failed to create parent directory for destination", env.ExitGeneric)
+	errArchiveMove         = exit.NewErrorWithCode("failed to move repository", env.ExitGeneric)
+	errArchiveSweep        = exit.NewErrorWithCode("failed to remove empty directory", env.ExitGeneric)
+)
+
+func (a *archive) ParseArgs(cmd *cobra.Command, args []string) error {
+	a.Positionals.Pattern = args[0]
+	return nil
+}
+
+func (a *archive) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		NeedsRoot:    true,
+		NeedsArchive: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+	environment.Filter = environment.NewForFilter(cmd.Context(), a.Positionals.Pattern, false)
+
+	// determine the folders to move from and to
+	archiveDir, err := filepath.Abs(environment.Archive)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+	roots := make([]string, len(environment.Roots))
+	for i, root := range environment.Roots {
+		if roots[i], err = filepath.Abs(root); err != nil {
+			return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+		}
+	}
+
+	// find the repositories to move
+	var moves []archiveMove
+	if a.Unarchive {
+		moves, err = unarchiveMoves(cmd, environment, roots, archiveDir)
+	} else {
+		moves, err = archiveMoves(cmd, environment, roots, archiveDir)
+	}
+	if err != nil {
+		return err
+	}
+	if len(moves) == 0 {
+		return errArchiveNoRepos
+	}
+
+	// check all targets before moving anything
+	for _, move := range moves {
+		if !path.HasChild(move.Folder, move.Repo) {
+			return fmt.Errorf("%q: %w", move.Repo, errArchiveOutside)
+		}
+		if exists, err := fsx.Exists(move.Target); err != nil || exists {
+			return fmt.Errorf("%q: %w", move.Target, errArchiveExists)
+		}
+	}
+
+	var folders []string
+	byFolder := make(map[string][]string)
+	for _, move := range moves {
+		parent := filepath.Dir(move.Target)
+
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "mkdir -p %s\n", shellescape.Quote(parent)); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "mv %s %s\n", shellescape.Quote(move.Repo), shellescape.Quote(move.Target)); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+
+		if _, ok := byFolder[move.Folder]; !ok {
+			folders = append(folders, move.Folder)
+		}
+		byFolder[move.Folder] = append(byFolder[move.Folder], move.Repo)
+
+		if a.Simulate {
+			continue
+		}
+
+		if err := os.MkdirAll(parent, dirs.NewModBits); err != nil {
+			return fmt.Errorf("%q: %w: %w", parent, errArchiveCreateParent, err)
+		}
+		if err := os.Rename(move.Repo, move.Target); err != nil {
+			return fmt.Errorf("%w: %w", errArchiveMove, err)
+		}
+	}
+
+	// remove parents that are now empty
+	for _, folder := range folders {
+		parents, err := sweepParents(cmd.Context(), environment, folder, byFolder[folder]...)
+		if err != nil {
+			return err
+		}
+		for _, dir := range parents {
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "rmdir %s\n", shellescape.Quote(dir)); err != nil {
+				return fmt.Errorf("%w: %w", errGenericOutput, err)
+			}
+			if a.Simulate {
+				continue
+			}
+			if err := removeEmptyDir(environment, dir); err != nil {
+				return fmt.Errorf("%q: %w: %w", dir, errArchiveSweep, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// archiveMove is a single repository moved by archive or unarchive.
+type archiveMove struct {
+	Repo   string // path of the repository to move
+	Target string // path to move the repository to
+	Folder string // folder containing the repository, empty parents within it are removed after moving
+}
+
+// archiveRootsDir is the directory within the archive holding repositories of roots other than the primary root.
+// It starts with a '.', so that it never clashes with a hostname.
+const archiveRootsDir = ".ggroot"
+
+// archiveRootDir returns the directory within archiveDir holding the repositories of the given non-primary root.
+// It mirrors the absolute path of root, e.g. '/mnt/work' is archived into '$GGARCHIVE/.ggroot/mnt/work'.
+func archiveRootDir(archiveDir, root string) string {
+	volume := filepath.VolumeName(root)
+	return filepath.Join(archiveDir, archiveRootsDir, strings.TrimSuffix(volume, ":"), strings.TrimPrefix(root, volume))
+}
+
+// archiveMoves finds the repositories in the given roots to move into archiveDir.
+// Repositories of the primary root, the first of roots, are moved directly into archiveDir.
+// Repositories of other roots are moved into their archiveRootDir.
+func archiveMoves(cmd *cobra.Command, environment *env.Env, roots []string, archiveDir string) ([]archiveMove, error) {
+	var moves []archiveMove
+	for i, root := range roots {
+		if exists, err := fsx.IsDirectory(root, true); err != nil || !exists {
+			continue
+		}
+		repos, err := environment.ScanRepos(cmd.Context(), root, false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errArchiveScan, err)
+		}
+
+		base := archiveDir
+		if i > 0 {
+			base = archiveRootDir(archiveDir, root)
+		}
+		for _, repo := range repos {
+			// repositories in a nested root belong to that root only
+			if repoRoot, scanOnly, ok := environment.RootOf(repo); !ok || scanOnly || repoRoot != root {
+				continue
+			}
+
+			rel, err := filepath.Rel(root, repo)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", repo, errArchiveOutside)
+			}
+			moves = append(moves, archiveMove{Repo: repo, Target: filepath.Join(base, rel), Folder: root})
+		}
+	}
+	return moves, nil
+}
+
+// unarchiveMoves finds the repositories in archiveDir to move back into the given roots.
+// Each repository is moved back into the root it was archived from.
+func unarchiveMoves(cmd *cobra.Command, environment *env.Env, roots []string, archiveDir string) ([]archiveMove, error) {
+	if exists, err := fsx.IsDirectory(archiveDir, true); err != nil || !exists {
+		return nil, nil
+	}
+	repos, err := environment.ScanRepos(cmd.Context(), archiveDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errArchiveScan, err)
+	}
+
+	moves := make([]archiveMove, 0, len(repos))
+	for _, repo := range repos {
+		// find the root the repository was archived from
+		root, base := roots[0], archiveDir
+		for _, candidate := range roots[1:] {
+			if dir := archiveRootDir(archiveDir, candidate); path.HasChild(dir, repo) && len(dir) > len(base) {
+				root, base = candidate, dir
+			}
+		}
+
+		rel, err := filepath.Rel(base, repo)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", repo, errArchiveOutside)
+		}
+		if base == archiveDir && path.HasChild(archiveRootsDir, rel) {
+			return nil, fmt.Errorf("%q: %w", repo, errArchiveUnknownRoot)
+		}
+		moves = append(moves, archiveMove{Repo: repo, Target: filepath.Join(root, rel), Folder: archiveDir})
+	}
+	return moves, nil
+}
@@ -0,0 +1,205 @@
+package cmd_test
+
+//spellchecker:words path filepath strconv strings testing essio shellescape ggman internal mockenv pkglib testlib
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"al.essio.dev/pkg/shellescape"
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+	"go.tkw01536.de/pkglib/fsx"
+	"go.tkw01536.de/pkglib/testlib"
+)
+
+//spellchecker:words GGROOT GGARCHIVE workdir unarchive rmdir
+
+func TestCommandArchive(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	archive := testlib.TempDirAbs(t)
+	mock.SetArchive(archive)
+
+	repo := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	mock.Clone(t.Context(), "https://github.com/other/world.git", "github.com", "other", "world")
+	archived := filepath.Join(archive, "github.com", "hello", "world")
+
+	// check that the repository is in the right place
+	assertLocation := func(t *testing.T, wantArchived bool) {
+		t.Helper()
+
+		if exists, _ := fsx.Exists(repo); exists == wantArchived {
+			t.Errorf("repository exists = %v, want %v", exists, !wantArchived)
+		}
+		if exists, _ := fsx.Exists(archived); exists != wantArchived {
+			t.Errorf("archived repository exists = %v, want %v", exists, wantArchived)
+		}
+	}
+
+	steps := []struct {
+		name string
+		args []string
+
+		wantCode     uint8
+		wantStdout   string
+		wantStderr   string
+		wantArchived bool
+	}{
+		{
+			"simulate archive",
+			[]string{"archive", "--simulate", "github.com/hello/world"},
+
+			0,
+			"mkdir -p " + shellescape.Quote(filepath.Join(archive, "github.com", "hello")) + "\nmv `${GGROOT github.com hello world}` " + shellescape.Quote(archived) + "\nrmdir `${GGROOT github.com hello}`\n",
+			"",
+			false,
+		},
+		{
+			"archive",
+			[]string{"archive", "github.com/hello/world"},
+
+			0,
+			"mkdir -p " + shellescape.Quote(filepath.Join(archive, "github.com", "hello")) + "\nmv `${GGROOT github.com hello world}` " + shellescape.Quote(archived) + "\nrmdir `${GGROOT github.com hello}`\n",
+			"",
+			true,
+		},
+		{
+			"list active repositories",
+			[]string{"ls"},
+
+			0,
+			"${GGROOT github.com other world}\n",
+			"",
+			true,
+		},
+		{
+			"list archived repositories",
+			[]string{"ls", "--archived"},
+
+			0,
+			archived + "\n",
+			"",
+			true,
+		},
+		{
+			"archive non-existing repository",
+			[]string{"archive", "github.com/hello/world"},
+
+			6,
+			"",
+			"failed to find any matching repositories\n",
+			true,
+		},
+		{
+			"unarchive",
+			[]string{"unarchive", "github.com/hello/world"},
+
+			0,
+			"mkdir -p `${GGROOT github.com hello}`\nmv " + shellescape.Quote(archived) + " `${GGROOT github.com hello world}`\nrmdir " + shellescape.Quote(filepath.Join(archive, "github.com", "hello")) + "\nrmdir " + shellescape.Quote(filepath.Join(archive, "github.com")) + "\n",
+			"",
+			false,
+		},
+	}
+
+	// steps depend on each other, so they are not run in parallel
+	for _, tt := range steps {
+		code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+		if code != tt.wantCode {
+			t.Errorf("%s: Code = %d, wantCode = %d", tt.name, code, tt.wantCode)
+		}
+		mock.AssertOutput(t, tt.name+": Stdout", stdout, tt.wantStdout)
+		mock.AssertOutput(t, tt.name+": Stderr", stderr, tt.wantStderr)
+		assertLocation(t, tt.wantArchived)
+	}
+}
+
+func TestCommandArchive_exists(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	archive := testlib.TempDirAbs(t)
+	mock.SetArchive(archive)
+
+	hello := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	other := mock.Clone(t.Context(), "https://github.com/other/world.git", "github.com", "other", "world")
+
+	// the target of the last repository to be archived already exists
+	target := filepath.Join(archive, "github.com", "other", "world")
+	if err := os.MkdirAll(target, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "archive", "github.com")
+	if code != 1 {
+		t.Errorf("Code = %d, wantCode = 1", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "")
+	mock.AssertOutput(t, "Stderr", stderr, strconv.Quote(target)+": failed to move repository: path already exists\n")
+
+	// no repository should have been moved
+	for _, repo := range []string{hello, other} {
+		if exists, _ := fsx.Exists(repo); !exists {
+			t.Errorf("repository %q was moved", repo)
+		}
+	}
+}
+
+func TestCommandArchive_roots(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	mock.SetRoots(mock.Resolve("..", "work"))
+
+	archive := testlib.TempDirAbs(t)
+	mock.SetArchive(archive)
+
+	hello := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	work := mock.Clone(t.Context(), "https://gitlab.work.com/hello/world.git", "..", "work", "gitlab.work.com", "hello", "world")
+
+	archivedHello := filepath.Join(archive, "github.com", "hello", "world")
+	workRoot := mock.Resolve("..", "work")
+	volume := filepath.VolumeName(workRoot)
+	archivedWork := filepath.Join(archive, ".ggroot", strings.TrimSuffix(volume, ":"), strings.TrimPrefix(workRoot, volume), "gitlab.work.com", "hello", "world")
+
+	// check that each path exists as expected
+	assertExists := func(t *testing.T, want bool, paths ...string) {
+		t.Helper()
+
+		for _, path := range paths {
+			if exists, _ := fsx.Exists(path); exists != want {
+				t.Errorf("%q exists = %v, want %v", path, exists, want)
+			}
+		}
+	}
+
+	code, _, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "archive", "hello/world")
+	if code != 0 {
+		t.Fatalf("archive: Code = %d, wantCode = 0, stderr = %q", code, stderr)
+	}
+	assertExists(t, false, hello, work)
+	assertExists(t, true, archivedHello, archivedWork)
+
+	// a root that is no longer configured is not restored into another root
+	mock.SetRoots()
+	code, _, stderr = mock.Run(t, nil, cmd.NewCommand, "", "", "unarchive", "gitlab.work.com")
+	if code != 1 {
+		t.Errorf("unarchive without root: Code = %d, wantCode = 1", code)
+	}
+	mock.AssertOutput(t, "Stderr", stderr, strconv.Quote(archivedWork)+": failed to move repository: archived from a root that is not in '$GGROOT'\n")
+	assertExists(t, true, archivedWork)
+
+	// repositories are restored into the root they were archived from
+	mock.SetRoots(workRoot)
+	code, _, stderr = mock.Run(t, nil, cmd.NewCommand, "", "", "unarchive", "hello/world")
+	if code != 0 {
+		t.Fatalf("unarchive: Code = %d, wantCode = 0, stderr = %q", code, stderr)
+	}
+	assertExists(t, true, hello, work)
+	assertExists(t, false, archivedHello, archivedWork)
+}
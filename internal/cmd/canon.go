@@ -1,7 +1,8 @@
 package cmd
 
-//spellchecker:words github cobra ggman internal pkglib exit
+//spellchecker:words errors github cobra ggman internal pkglib exit
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -44,22 +45,41 @@ CANSPEC characters are copied literally except:
 - '^' is replaced by the first component (hostname)
 - '%' is replaced by the second unused component (commonly username)
 - '!' consumes a component without using it in the output
-- '$' is replaced by remaining components joined with '/'; stops special processing
+- '$' is replaced by remaining components joined with '/'; stops processing of '^', '%', '!' and '$'
 
 If '$' is absent, it is assumed at the end.
 The CANSPEC '$$' leaves URLs unchanged.
 
+The extended CANSPEC syntax additionally supports references and escapes.
+It is used for rules of a structured CANFILE, and for the CANSPEC argument when the '--extended' flag is given.
+Other CANSPECs copy '{' and '\' literally, so that existing CANFILEs keep working.
+
+References of the form '{reference}' or '{modifier:reference}' allow more control:
+
+- '{1}', '{2}', ... => the respective component; negative numbers like '{-1}' count from the end
+- '{^}', '{%}', '{$}' => as the respective special characters
+- '{scheme}', '{user}', '{password}', '{host}', '{port}' => the respective part of the URL
+- the modifiers 'lower' and 'upper' => convert to lower or upper case, e.g. '{lower:^}'
+
+Components used by index are not used by other special characters.
+When a reference by index is used, '$' is not assumed at the end.
+A '\' makes the next character literal.
+Invalid extended CANSPECs result in an error explaining the problem.
+
 Examples for components 'server.com', 'user', 'repository':
 
 - 'git@^:$.git' => 'git@server.com:user/repository.git'
 - 'ssh://%@^/$.git' => 'ssh://user@server.com/repository.git'
 - 'git@!ssh.server.com:$.git' => 'git@ssh.server.com:user/repository.git'
 - empty CANSPEC => 'server.com/user/repository'
+- 'https://^/{-1}/{2}' => 'https://server.com/repository/user' (extended)
+- 'git@{upper:^}:$.git' => 'git@SERVER.COM:user/repository.git' (extended)
 
 A CANFILE provides pattern-based CANSPEC rules.
 It is loaded from '.ggman' in the home directory or from '$GGMAN_CANFILE'.
 
-Each CANFILE line contains one or two space-separated strings: a pattern and an optional CANSPEC.
+Each CANFILE line contains one, two or three space-separated strings: a pattern, a CANSPEC and an optional push CANSPEC.
+The push CANSPEC is used by 'ggman fix' and 'ggman clone' to set push URLs.
 Lines starting with '#' or '\' are comments.
 
 Example CANFILE:
@@ -76,29 +96,50 @@ Example CANFILE:
     # by default, clone via ssh
     git@^:$.git
 
-Omitting the CANSPEC argument uses the CANFILE for resolution.`,
+A CANFILE with a '.toml' extension is read in a structured format instead.
+It contains one '[[rule]]' table per line, with keys 'pattern', 'canonical' and 'push'.
+Rules may also contain the keys 'clone-args', 'branch', 'web' and 'config', see 'ggman clone' and 'ggman web'.
+The key 'root' selects one of the directories in '$GGROOT' to clone matching repositories into, see 'ggman where'.
+The key 'layout' sets the layout template of matching repositories, see 'ggman where'.
+
+Omitting the CANSPEC argument uses the CANFILE for resolution.
+
+A CANFILE in the structured format can also define aliases in an '[alias]' table.
+These work like git's 'url.<base>.insteadOf' setting:
+
+    [alias]
+    "gh:" = "git@github.com:"
+
+expands 'gh:hello/world' to 'git@github.com:hello/world'.
+Aliases are expanded by 'ggman canon', 'ggman comps', 'ggman clone', 'ggman where' and in '--for' patterns.`,
 		Args: cobra.RangeArgs(1, 2),
 
 		PreRunE: impl.ParseArgs,
 		RunE:    impl.Exec,
 	}
 
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Extended, "extended", "x", false, "use the extended syntax for the CANSPEC argument")
+
 	return cmd
 }
 
 type canon struct {
 	Positional struct {
-		URL     env.URL
+		URL     string
 		CANSPEC string
 	}
+	Extended bool
 }
 
 var (
-	errCanonUnableCanFile = exit.NewErrorWithCode("failed to load default CANFILE", env.ExitContext)
+	errCanonUnableCanFile  = exit.NewErrorWithCode("failed to load default CANFILE", env.ExitContext)
+	errCanonInvalidSpec    = exit.NewErrorWithCode("failed to parse CANSPEC", env.ExitCommandArguments)
+	errCanonInvalidCanFile = exit.NewErrorWithCode("failed to parse CANSPEC from CANFILE", env.ExitInvalidEnvironment)
 )
 
 func (c *canon) ParseArgs(cmd *cobra.Command, args []string) error {
-	c.Positional.URL = env.ParseURL(args[0])
+	c.Positional.URL = args[0]
 	if len(args) == 2 {
 		c.Positional.CANSPEC = args[1]
 	}
@@ -106,24 +147,55 @@ func (c *canon) ParseArgs(cmd *cobra.Command, args []string) error {
 }
 
 func (c *canon) Exec(cmd *cobra.Command, args []string) error {
-	var file env.CanFile
+	environment, err := env.GetEnv(cmd, env.Requirement{})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
 
-	if c.Positional.CANSPEC == "" {
-		env, err := env.GetEnv(cmd, env.Requirement{})
-		if err != nil {
-			return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	// the CANFILE is needed for aliases, even when a CANSPEC is given.
+	// but in that case, a CANFILE that cannot be loaded only means there are no aliases.
+	file, err := environment.LoadDefaultCANFILE()
+	if err != nil {
+		if c.Positional.CANSPEC == "" {
+			return fmt.Errorf("%w: %w", errCanonUnableCanFile, err)
 		}
+		environment.Aliases = nil
+	}
 
-		if file, err = env.LoadDefaultCANFILE(); err != nil {
-			return fmt.Errorf("%w: %w", errCanonUnableCanFile, err)
+	url := environment.ParseURL(c.Positional.URL)
+
+	// find the spec to use
+	cSpec, extended, errInvalid := c.Positional.CANSPEC, c.Extended, errCanonInvalidSpec
+	if cSpec == "" {
+		errInvalid = errCanonInvalidCanFile
+
+		line, ok := url.CanLineWith(file)
+		if ok {
+			cSpec, extended = line.Canonical, line.Extended
+		} else {
+			cSpec = "$$" // no line matches, so leave the url unchanged
 		}
-	} else {
-		file = []env.CanLine{{Pattern: "", Canonical: c.Positional.CANSPEC}}
 	}
 
-	// print out the canonical version of the file
-	canonical := c.Positional.URL.CanonicalWith(file)
+	if !extended {
+		return c.print(cmd, url.Canonical(cSpec))
+	}
+
+	// parse the spec, and explain any errors
+	spec, err := env.ParseCanSpec(cSpec)
+	var specErr *env.CanSpecError
+	if errors.As(err, &specErr) {
+		return fmt.Errorf("%w: %s", errInvalid, specErr.Explain())
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalid, err)
+	}
+
+	return c.print(cmd, spec.Apply(url))
+}
 
+// print prints the canonical version of the url.
+func (c *canon) print(cmd *cobra.Command, canonical string) error {
 	if _, err := fmt.Fprintln(cmd.OutOrStdout(), canonical); err != nil {
 		return fmt.Errorf("%w: %w", errGenericOutput, err)
 	}
@@ -113,6 +113,36 @@ func TestCommandCanon(t *testing.T) {
 			"ssh://user@server.com:1234/repo.git\n",
 			"",
 		},
+
+		{
+			"ssh://user@Server.com:1234/repo.git ssh://{lower:host}:{port}/{-1}/{2}",
+			"",
+			[]string{"canon", "--extended", "ssh://user@Server.com:1234/repo.git", "ssh://{lower:host}:{port}/{-1}/{2}"},
+
+			0,
+			"ssh://server.com:1234/repo/user\n",
+			"",
+		},
+
+		{
+			"git@github.com/user/repo with invalid CANSPEC",
+			"",
+			[]string{"canon", "--extended", "git@github.com/user/repo", "git@^:{repo}.git"},
+
+			4,
+			"",
+			"failed to parse CANSPEC: unknown reference \"repo\" (expected a component index, '^', '%', '$', 'scheme', 'user', 'password', 'host' or 'port'):\n\n    git@^:{repo}.git\n          ^\n",
+		},
+
+		{
+			"git@github.com/user/repo with legacy CANSPEC",
+			"",
+			[]string{"canon", "git@github.com/user/repo", "git@^:\\{repo}$.git"},
+
+			0,
+			"git@github.com:\\{repo}user/repo.git\n",
+			"",
+		},
 	}
 
 	for _, tt := range tests {
@@ -142,6 +172,9 @@ func TestCommandCanon_ReplaceDomain(t *testing.T) {
 # anything under example.com/namespace should be rewritten to a custom domain
 ^example.com/namespace git@!custom.com:$.git
 
+# a legacy pattern, '\' and '{' are copied literally
+^legacy.com git@^:\{$}.git
+
 # default to a normal pattern
 git@^:$.git
 	`), 0600); err != nil {
@@ -194,6 +227,15 @@ git@^:$.git
 			"git@example.com:namespace_2/repo.git\n",
 			"",
 		},
+		{
+			"copies escapes and braces in CANFILE literally",
+			"",
+			[]string{"canon", "git@legacy.com/user/repo"},
+
+			0,
+			"git@legacy.com:\\{user/repo}.git\n",
+			"",
+		},
 	}
 
 	for _, tt := range tests {
@@ -209,3 +251,141 @@ git@^:$.git
 		})
 	}
 }
+
+func TestCommandCanon_alias(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[alias]
+"gh:" = "git@github.com:"
+"work:" = "https://git.work.example.com/"
+
+[[rule]]
+pattern = "^broken.com"
+canonical = "git@^:{2.git"
+
+[[rule]]
+pattern = "^upper.com"
+canonical = "git@{upper:^}:$.git"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"expands alias",
+			"",
+			[]string{"canon", "work:payments/ledger"},
+
+			0,
+			"git@git.work.example.com:payments/ledger.git\n",
+			"",
+		},
+		{
+			"expands alias with CANSPEC",
+			"",
+			[]string{"canon", "gh:hello/world", "https://^/$"},
+
+			0,
+			"https://github.com/hello/world\n",
+			"",
+		},
+		{
+			"uses extended syntax in structured CANFILE",
+			"",
+			[]string{"canon", "git@upper.com/user/repo"},
+
+			0,
+			"git@UPPER.COM:user/repo.git\n",
+			"",
+		},
+		{
+			"explains invalid CANSPEC in structured CANFILE",
+			"",
+			[]string{"canon", "git@broken.com/user/repo"},
+
+			5,
+			"",
+			"failed to parse CANSPEC from CANFILE: unclosed '{':\n\n    git@^:{2.git\n          ^\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandCanon_invalidCanfile(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte("not valid toml"), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"CANSPEC does not need the CANFILE",
+			[]string{"canon", "git@github.com/hello/world", "https://^/$"},
+
+			0,
+			"https://github.com/hello/world\n",
+			"",
+		},
+		{
+			"no CANSPEC needs the CANFILE",
+			[]string{"canon", "git@github.com/hello/world"},
+
+			254,
+			"",
+			"failed to load default CANFILE: unable to parse CANFILE: toml: line 1: expected '.' or '=', but got 'v' instead\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
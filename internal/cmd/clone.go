@@ -1,10 +1,13 @@
 package cmd
 
-//spellchecker:words errors essio shellescape github cobra ggman internal pkglib exit
+//spellchecker:words errors maps slices strings essio shellescape github cobra ggman internal pkglib exit
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
 	"al.essio.dev/pkg/shellescape"
 	"github.com/spf13/cobra"
@@ -46,7 +49,16 @@ Additional arguments can be passed to git after '--':
     ggman clone --exact-url https://github.com/hello/world.git -- --branch dev --depth 2
 
 This executes 'git clone git@github.com:hello/world.git --branch dev --depth 2'.
-The '--' separator distinguishes ggman flags from git flags.`,
+The '--' separator distinguishes ggman flags from git flags.
+
+When the matching CANFILE line contains a push CANSPEC, the push URL of the 'origin' remote is set accordingly after cloning.
+This does not happen when '--exact-url' is given.
+
+When the CANFILE uses the structured format, the matching rule may furthermore specify:
+
+- 'clone-args' => arguments passed to git before any additional arguments
+- 'branch' => a branch to check out, unless '--branch' is part of the additional arguments
+- 'config' => git config values to set after cloning`,
 		Args: cobra.MinimumNArgs(1),
 
 		PreRunE: impl.ParseArgs,
@@ -105,10 +117,15 @@ var (
 	errCloneAlreadyExists     = exit.NewErrorWithCode("failed to clone repository: another git repository already exists in target location", env.ExitGeneric)
 	errCloneNoArguments       = exit.NewErrorWithCode(`failed to pass arguments: external "git" not found`, env.ExitGeneric)
 	errCloneOther             = exit.NewErrorWithCode("", env.ExitGeneric)
+	errClonePushURL           = exit.NewErrorWithCode("failed to set push url", env.ExitGeneric)
+	errCloneConfig            = exit.NewErrorWithCode("failed to set git config", env.ExitGeneric)
 
 	errCloneNoComps = errors.New("failed to find components of URI")
 )
 
+// cloneRemoteName is the name of the remote created by cloning.
+const cloneRemoteName = "origin"
+
 func (c *clone) Exec(cmd *cobra.Command, args []string) error {
 	// get the environment
 	environment, err := env.GetEnv(cmd, env.Requirement{
@@ -120,13 +137,13 @@ func (c *clone) Exec(cmd *cobra.Command, args []string) error {
 	}
 
 	// grab the url to clone and make sure it is not local
-	url := env.ParseURL(c.Positional.URL)
+	url := environment.ParseURL(c.Positional.URL)
 	if url.IsLocal() {
 		return fmt.Errorf("%q: %w", c.Positional.URL, errCloneLocalURI)
 	}
 
 	// find the remote and local paths to clone to / from
-	remote := c.Positional.URL
+	remote := environment.Aliases.Expand(c.Positional.URL)
 	if !c.Exact {
 		remote = environment.Canonical(url)
 	}
@@ -135,6 +152,10 @@ func (c *clone) Exec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%q: %w: %w", c.Positional.URL, errCloneInvalidDest, err)
 	}
 
+	// find the settings of the matching rule
+	line, _ := environment.CanLine(url)
+	cloneArgs := c.args(line)
+
 	if c.Overwrite {
 		isDir, err := fsx.IsDirectory(local, false)
 		if err != nil {
@@ -155,9 +176,12 @@ func (c *clone) Exec(cmd *cobra.Command, args []string) error {
 	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Cloning %q into %q ...\n", remote, local); err != nil {
 		return fmt.Errorf("%w: %w", errGenericOutput, err)
 	}
-	switch err := environment.Git.Clone(cmd.Context(), streamFromCommand(cmd), remote, local, c.Positional.Args...); {
+	switch err := environment.Git.Clone(cmd.Context(), streamFromCommand(cmd), remote, local, cloneArgs...); {
 	case err == nil:
-		return nil
+		if err := c.setPushURL(cmd, environment, url, local); err != nil {
+			return err
+		}
+		return c.setConfig(cmd, environment, line, local)
 	case errors.Is(err, git.ErrCloneAlreadyExists):
 		if c.Force {
 			_, err := fmt.Fprintln(cmd.OutOrStdout(), "Clone already exists in target location, done.")
@@ -168,12 +192,65 @@ func (c *clone) Exec(cmd *cobra.Command, args []string) error {
 		}
 		return errCloneAlreadyExists
 	case errors.Is(err, git.ErrArgumentsUnsupported):
-		return fmt.Errorf("%w: %v", errCloneNoArguments, shellescape.QuoteCommand(c.Positional.Args))
+		return fmt.Errorf("%w: %v", errCloneNoArguments, shellescape.QuoteCommand(cloneArgs))
 	default:
 		return fmt.Errorf("%w%w", errCloneOther, err)
 	}
 }
 
+// args returns the arguments to pass to git clone.
+// These consist of the clone arguments and branch of line, followed by the positional arguments.
+func (c *clone) args(line env.CanLine) []string {
+	args := make([]string, 0, len(line.CloneArgs)+len(c.Positional.Args)+2)
+	args = append(args, line.CloneArgs...)
+
+	if line.Branch != "" && !slices.ContainsFunc(c.Positional.Args, isBranchArg) {
+		args = append(args, "--branch", line.Branch)
+	}
+
+	return append(args, c.Positional.Args...)
+}
+
+// isBranchArg checks if arg is a 'git clone' argument specifying the branch.
+func isBranchArg(arg string) bool {
+	return arg == "-b" || arg == "--branch" || strings.HasPrefix(arg, "--branch=")
+}
+
+// setConfig sets the git config values of line in the freshly cloned repository at local.
+func (c *clone) setConfig(cmd *cobra.Command, environment *env.Env, line env.CanLine, local string) error {
+	keys := slices.Sorted(maps.Keys(line.Config))
+	for _, key := range keys {
+		value := line.Config[key]
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Setting config %q to %q\n", key, value); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+		if err := environment.Git.SetConfig(cmd.Context(), local, key, value); err != nil {
+			return fmt.Errorf("%w: %w", errCloneConfig, err)
+		}
+	}
+	return nil
+}
+
+// setPushURL sets the push url of the freshly cloned repository at local, if the CANFILE provides one.
+func (c *clone) setPushURL(cmd *cobra.Command, environment *env.Env, url env.URL, local string) error {
+	if c.Exact {
+		return nil
+	}
+
+	push, ok := environment.CanonicalPush(url)
+	if !ok {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Setting push url to %q\n", push); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	if err := environment.Git.SetPushURLs(cmd.Context(), local, cloneRemoteName, []string{push}); err != nil {
+		return fmt.Errorf("%w: %w", errClonePushURL, err)
+	}
+	return nil
+}
+
 // dest returns the destination path to clone the repository into.
 func (c *clone) dest(environment *env.Env, url env.URL) (path string, err error) {
 	switch {
@@ -1,14 +1,18 @@
 package cmd_test
 
-//spellchecker:words testing ggman internal mockenv
+//spellchecker:words path filepath slices testing github ggman internal mockenv
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/go-git/go-git/v5"
 	"go.tkw01536.de/ggman/internal/cmd"
 	"go.tkw01536.de/ggman/internal/mockenv"
 )
 
-//spellchecker:words GGROOT tparallel paralleltest
+//spellchecker:words GGROOT tparallel paralleltest CANFILE pushurl
 
 //nolint:tparallel,paralleltest
 func TestCommandClone(t *testing.T) {
@@ -194,3 +198,169 @@ func TestCommandClone(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandClone_push(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	// fetch via https, but push via ssh
+	CANFILE := filepath.Join(t.TempDir(), "canfile")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte("^github.com https://^/$.git git@^:$.git\n"), 0600); err != nil {
+		panic(err)
+	}
+	mock.Register("https://github.com/hello/world.git")
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "clone", "git@github.com:hello/world.git")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "Cloning \"https://github.com/hello/world.git\" into \"${GGROOT github.com hello world}\" ...\nSetting push url to \"git@github.com:hello/world.git\"\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+
+	repo, err := git.PlainOpen(mock.Resolve("github.com", "hello", "world"))
+	if err != nil {
+		panic(err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		panic(err)
+	}
+	got := cfg.Raw.Section("remote").Subsection("origin").Options.GetAll("pushurl")
+	if !slices.Equal(got, []string{"git@github.com:hello/world.git"}) {
+		t.Errorf("pushurl = %v, want %v", got, []string{"git@github.com:hello/world.git"})
+	}
+}
+
+func TestCommandClone_structured(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[[rule]]
+pattern = "^github.com/hello"
+canonical = "https://^/$.git"
+push = "git@^:$.git"
+
+[rule.config]
+"user.email" = "hello@example.com"
+"branch.main.rebase" = "true"
+
+[[rule]]
+pattern = "^github.com"
+canonical = "https://^/$.git"
+clone-args = ["--depth", "1"]
+branch = "develop"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+	mock.Register("https://github.com/hello/world.git")
+	mock.Register("https://github.com/other/world.git")
+
+	t.Run("clone with config", func(t *testing.T) {
+		t.Parallel()
+
+		code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "clone", "git@github.com:hello/world.git")
+		if code != 0 {
+			t.Errorf("Code = %d, wantCode = 0", code)
+		}
+		mock.AssertOutput(t, "Stdout", stdout, "Cloning \"https://github.com/hello/world.git\" into \"${GGROOT github.com hello world}\" ...\nSetting push url to \"git@github.com:hello/world.git\"\nSetting config \"branch.main.rebase\" to \"true\"\nSetting config \"user.email\" to \"hello@example.com\"\n")
+		mock.AssertOutput(t, "Stderr", stderr, "")
+
+		repo, err := git.PlainOpen(mock.Resolve("github.com", "hello", "world"))
+		if err != nil {
+			panic(err)
+		}
+		cfg, err := repo.Config()
+		if err != nil {
+			panic(err)
+		}
+		if cfg.User.Email != "hello@example.com" {
+			t.Errorf("user.email = %q, want %q", cfg.User.Email, "hello@example.com")
+		}
+		if got := cfg.Raw.Section("branch").Subsection("main").Option("rebase"); got != "true" {
+			t.Errorf("branch.main.rebase = %q, want %q", got, "true")
+		}
+		if got := cfg.Raw.Section("remote").Subsection("origin").Options.GetAll("pushurl"); !slices.Equal(got, []string{"git@github.com:hello/world.git"}) {
+			t.Errorf("pushurl = %v, want %v", got, []string{"git@github.com:hello/world.git"})
+		}
+		if got := cfg.Raw.Section("remote").Subsection("origin").Options.GetAll("url"); len(got) != 1 {
+			t.Errorf("url = %v, want exactly one url", got)
+		}
+	})
+
+	t.Run("clone with arguments", func(t *testing.T) {
+		t.Parallel()
+
+		code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "clone", "git@github.com:other/world.git")
+		if code != 1 {
+			t.Errorf("Code = %d, wantCode = 1", code)
+		}
+		mock.AssertOutput(t, "Stdout", stdout, "Cloning \"https://github.com/other/world.git\" into \"${GGROOT github.com other world}\" ...\n")
+		mock.AssertOutput(t, "Stderr", stderr, "failed to pass arguments: external \"git\" not found: --depth 1 --branch develop\n")
+	})
+}
+
+func TestCommandClone_alias(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[alias]
+"gh:" = "git@github.com:"
+
+[[rule]]
+canonical = "https://^/$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+	mock.Register("https://github.com/hello/world.git")
+	mock.Register("git@github.com:other/world.git")
+
+	t.Run("clone alias", func(t *testing.T) {
+		t.Parallel()
+
+		code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "clone", "gh:hello/world")
+		if code != 0 {
+			t.Errorf("Code = %d, wantCode = 0", code)
+		}
+		mock.AssertOutput(t, "Stdout", stdout, "Cloning \"https://github.com/hello/world.git\" into \"${GGROOT github.com hello world}\" ...\n")
+		mock.AssertOutput(t, "Stderr", stderr, "")
+	})
+
+	t.Run("clone alias with exact url", func(t *testing.T) {
+		t.Parallel()
+
+		code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "clone", "--exact-url", "gh:other/world.git")
+		if code != 0 {
+			t.Errorf("Code = %d, wantCode = 0", code)
+		}
+		mock.AssertOutput(t, "Stdout", stdout, "Cloning \"git@github.com:other/world.git\" into \"${GGROOT github.com other world}\" ...\n")
+		mock.AssertOutput(t, "Stderr", stderr, "")
+	})
+}
+
+func TestCommandClone_layout(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	mock.SetLayout("{owner}/{name}")
+	mock.Register("git@github.com:hello/world.git")
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "clone", "https://github.com/hello/world.git")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "Cloning \"git@github.com:hello/world.git\" into \"${GGROOT hello world}\" ...\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+}
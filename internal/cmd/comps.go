@@ -1,11 +1,12 @@
 package cmd
 
-//spellchecker:words github cobra ggman internal
+//spellchecker:words github cobra ggman internal pkglib exit
 import (
 	"fmt"
 
 	"github.com/spf13/cobra"
 	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/pkglib/exit"
 )
 
 //spellchecker:words wrapcheck
@@ -18,6 +19,11 @@ func NewCompsCommand() *cobra.Command {
 		Short: "Print the components of a URL",
 		Long: `Comps prints the URL components of the first argument.
 Each component is printed on a separate line.
+Aliases defined in the CANFILE are expanded first.
+
+The '--strict' flag parses the URL strictly.
+Invalid or ambiguous URLs then result in an error instead of a best guess.
+Strict parsing furthermore supports IPv6 addresses in brackets, decodes percent-escapes and removes query strings and fragments.
 
 See 'ggman canon' for details on URL components.`,
 		Args: cobra.ExactArgs(1),
@@ -26,22 +32,43 @@ See 'ggman canon' for details on URL components.`,
 		RunE:    impl.Exec,
 	}
 
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Strict, "strict", "s", false, "parse the URL strictly and fail on invalid or ambiguous input")
+
 	return cmd
 }
 
 type comps struct {
 	Positional struct {
-		URL env.URL
+		URL string
 	}
+	Strict bool
 }
 
+var errCompsInvalidURL = exit.NewErrorWithCode("failed to parse URL", env.ExitInvalidRepo)
+
 func (c *comps) ParseArgs(cmd *cobra.Command, args []string) error {
-	c.Positional.URL = env.ParseURL(args[0])
+	c.Positional.URL = args[0]
 	return nil
 }
 
 func (c *comps) Exec(cmd *cobra.Command, args []string) error {
-	for _, comp := range c.Positional.URL.Components() {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		NeedsCanFile: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	url := environment.ParseURL(c.Positional.URL)
+	if c.Strict {
+		url, err = environment.ParseURLStrict(c.Positional.URL)
+		if err != nil {
+			return fmt.Errorf("%w %q: %w", errCompsInvalidURL, c.Positional.URL, err)
+		}
+	}
+
+	for _, comp := range url.Components() {
 		if _, err := fmt.Fprintln(cmd.OutOrStdout(), comp); err != nil {
 			return fmt.Errorf("%w: %w", errGenericOutput, err)
 		}
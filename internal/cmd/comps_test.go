@@ -1,7 +1,9 @@
 package cmd_test
 
-//spellchecker:words testing ggman internal mockenv
+//spellchecker:words path filepath testing ggman internal mockenv
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"go.tkw01536.de/ggman/internal/cmd"
@@ -63,6 +65,87 @@ func TestCommandComps(t *testing.T) {
 			"server.com\nuser\nrepo\n",
 			"",
 		},
+
+		{
+			"strict ipv6 url",
+			"",
+			[]string{"comps", "--strict", "ssh://git@[fe80::1]:2222/hello/world.git?ref=x"},
+
+			0,
+			"fe80::1\nhello\nworld\n",
+			"",
+		},
+
+		{
+			"strict ambiguous url",
+			"",
+			[]string{"comps", "--strict", "server.com:1234/repo.git"},
+
+			6,
+			"",
+			"failed to parse URL \"server.com:1234/repo.git\": ambiguous port or path: use a scheme like 'ssh://' to specify a port\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandComps_alias(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[alias]
+"gh:" = "git@github.com:"
+"work:" = "https://git.work.example.com/"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"expands alias",
+			"",
+			[]string{"comps", "gh:hello/world"},
+
+			0,
+			"github.com\nhello\nworld\n",
+			"",
+		},
+		{
+			"does not expand unknown alias",
+			"",
+			[]string{"comps", "gl:hello/world"},
+
+			0,
+			"gl\nhello\nworld\n",
+			"",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,327 @@
+package cmd
+
+//spellchecker:words errors strings github cobra ggman internal pkglib exit
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/fsx"
+)
+
+//spellchecker:words GGROOT GGNORM GGLAYOUT CANFILE wrapcheck
+
+func NewDoctorCommand() *cobra.Command {
+	impl := new(doctor)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the ggman configuration and local repositories for problems",
+		Long: `Doctor checks the ggman configuration and the repositories in '$GGROOT' for common problems.
+
+The following checks are performed:
+
+- '$GGROOT' => each root directory exists and is a writable directory
+- CANFILE => can be parsed, which file it was read from, and that roots of rules are listed in '$GGROOT'
+- git => which plumbing is used, and the version of the native git executable
+- GGNORM => contains a recognized value
+- GGLAYOUT => contains a valid layout template, see 'ggman where'
+- locations => each repository is at a location returned by 'ggman where'
+- duplicates => no two repositories share the same canonical remote, see 'ggman dupes'
+- links => no symlinks created by 'ggman link' are dangling
+- sweep => no empty directories as found by 'ggman sweep' exist
+
+Each check is printed on a separate line, prefixed by its severity.
+Warnings indicate something that might need attention.
+Errors indicate a broken configuration and cause a non-zero exit code.`,
+		Args: cobra.NoArgs,
+
+		RunE: impl.Exec,
+	}
+
+	return cmd
+}
+
+type doctor struct{}
+
+// doctorSeverity is the severity of a single doctor check.
+type doctorSeverity int
+
+const (
+	doctorOK doctorSeverity = iota
+	doctorWarn
+	doctorError
+)
+
+func (s doctorSeverity) String() string {
+	switch s {
+	case doctorOK:
+		return "ok"
+	case doctorWarn:
+		return "warn"
+	case doctorError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+var errDoctorFailed = exit.NewErrorWithCode("failed to pass all checks", env.ExitGeneric)
+
+func (doctor) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		NeedsRoot:    true,
+		AllowsFilter: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	var errorCount, warningCount int
+	report := func(severity doctorSeverity, check string, format string, args ...any) error {
+		switch severity {
+		case doctorOK:
+		case doctorWarn:
+			warningCount++
+		case doctorError:
+			errorCount++
+		}
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%-7s %s: %s\n", "["+severity.String()+"]", check, fmt.Sprintf(format, args...)); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+		return nil
+	}
+
+	// check the root directories
+	rootOK := true
+	for _, root := range environment.Roots {
+		severity, format, args := doctorCheckRoot(root)
+		if severity != doctorOK {
+			rootOK = false
+		}
+		if err := report(severity, "GGROOT", format, args...); err != nil {
+			return err
+		}
+	}
+
+	// check the CANFILE
+	{
+		cf, err := environment.LoadDefaultCANFILE()
+		switch {
+		case err != nil:
+			// fallback to the default, so that later checks can continue
+			environment.CanFile = nil
+			environment.CanFile.ReadDefault()
+			err = report(doctorError, "CANFILE", "failed to load: %s", err)
+		case environment.CanFileSource == "":
+			err = report(doctorOK, "CANFILE", "using built-in default")
+		default:
+			err = report(doctorOK, "CANFILE", "using %q with %d pattern(s)", environment.CanFileSource, len(cf))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// check that rules only clone into known root directories
+	for _, line := range environment.CanFile {
+		if line.Root == "" {
+			continue
+		}
+		root, err := environment.ExpandRoot(line.Root)
+		if err != nil {
+			err = report(doctorError, "CANFILE", "failed to expand root %q: %s", line.Root, err)
+		} else if containing, scanOnly, ok := environment.RootOf(root); !ok || scanOnly || containing != root {
+			err = report(doctorWarn, "CANFILE", "root %q of pattern %q is not listed in '$GGROOT'", line.Root, line.Pattern)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// check the git plumbing
+	{
+		var err error
+		version, vErr := environment.Git.GitVersion(cmd.Context())
+		switch {
+		case vErr != nil:
+			err = report(doctorError, "git", "failed to run %q: %s", environment.Git.GitPath(), vErr)
+		case version == "":
+			err = report(doctorOK, "git", "using built-in plumbing, no native git found")
+		default:
+			err = report(doctorOK, "git", "using native git %q (%s)", environment.Git.GitPath(), version)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// check the normalization
+	{
+		var err error
+		norm := environment.Vars.GGNORM
+		_, ok := env.ParseNormalization(norm)
+		switch {
+		case !ok:
+			err = report(doctorError, "GGNORM", "unrecognized value %q", norm)
+		case norm == "":
+			err = report(doctorOK, "GGNORM", "using default normalization")
+		default:
+			err = report(doctorOK, "GGNORM", "using %q", norm)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// check the layout
+	{
+		var err error
+		layout := environment.Vars.GGLAYOUT
+		_, lErr := env.ParseLayout(layout)
+		var layoutErr *env.LayoutError
+		switch {
+		case errors.As(lErr, &layoutErr):
+			err = report(doctorError, "GGLAYOUT", "%s", layoutErr.Explain())
+		case lErr != nil:
+			err = report(doctorError, "GGLAYOUT", "invalid layout %q: %s", layout, lErr)
+		case layout == "":
+			err = report(doctorOK, "GGLAYOUT", "using default layout")
+		default:
+			err = report(doctorOK, "GGLAYOUT", "using %q", layout)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if rootOK {
+		if err := doctorCheckRepos(cmd, environment, report); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%d error(s), %d warning(s)\n", errorCount, warningCount); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+
+	if errorCount > 0 {
+		return errDoctorFailed
+	}
+	return nil
+}
+
+// doctorCheckRoot checks that root exists and is writable.
+func doctorCheckRoot(root string) (severity doctorSeverity, format string, args []any) {
+	isDir, err := fsx.IsDirectory(root, true)
+	if err != nil {
+		return doctorError, "failed to check %q: %s", []any{root, err}
+	}
+	if !isDir {
+		return doctorError, "%q does not exist or is not a directory", []any{root}
+	}
+
+	f, err := os.CreateTemp(root, ".ggman-doctor-*")
+	if err != nil {
+		return doctorError, "%q is not writable: %s", []any{root, err}
+	}
+	name := f.Name()
+	_ = f.Close()
+	if err := os.Remove(name); err != nil {
+		return doctorWarn, "failed to remove temporary file %q: %s", []any{name, err}
+	}
+
+	return doctorOK, "%q exists and is writable", []any{root}
+}
+
+// doctorCheckRepos runs all checks on the repositories and directories contained in the root.
+func doctorCheckRepos(cmd *cobra.Command, environment *env.Env, report func(severity doctorSeverity, check string, format string, args ...any) error) error {
+	repos := environment.Repos(cmd.Context(), false)
+
+	// check that repositories are in their expected locations
+	misplaced := 0
+	for _, repo := range repos {
+		valid, err := isValidLocation(repo, false, cmd, environment)
+		switch {
+		case err != nil:
+			misplaced++
+			if err := report(doctorWarn, "locations", "failed to check %q: %s", repo, err); err != nil {
+				return err
+			}
+		case !valid:
+			misplaced++
+			if err := report(doctorWarn, "locations", "%q is not in its expected location", repo); err != nil {
+				return err
+			}
+		}
+	}
+	if misplaced == 0 {
+		if err := report(doctorOK, "locations", "all %d repositories in their expected location", len(repos)); err != nil {
+			return err
+		}
+	}
+
+	// check for duplicates
+	groups := findDupes(cmd, environment)
+	for _, group := range groups {
+		quoted := make([]string, len(group.Paths))
+		for i, path := range group.Paths {
+			quoted[i] = fmt.Sprintf("%q", path)
+		}
+
+		if err := report(doctorWarn, "duplicates", "%q is cloned to %s", group.Canonical, strings.Join(quoted, ", ")); err != nil {
+			return err
+		}
+	}
+	if len(groups) == 0 {
+		if err := report(doctorOK, "duplicates", "no duplicate repositories found"); err != nil {
+			return err
+		}
+	}
+
+	// check for dangling links
+	links, err := findLinks(cmd.Context(), environment)
+	if err != nil {
+		if err := report(doctorWarn, "links", "%s", err); err != nil {
+			return err
+		}
+	}
+	dangling := 0
+	for _, link := range links {
+		if !link.Dangling {
+			continue
+		}
+		dangling++
+		if err := report(doctorWarn, "links", "%q points to non-existent %q", link.Path, link.Target); err != nil {
+			return err
+		}
+	}
+	if err == nil && dangling == 0 {
+		if err := report(doctorOK, "links", "no dangling links found"); err != nil {
+			return err
+		}
+	}
+
+	// check for empty directories
+	empty, err := sweepRoot(cmd.Context(), environment)
+	switch {
+	case err != nil:
+		if err := report(doctorWarn, "sweep", "%s", err); err != nil {
+			return err
+		}
+	case len(empty) == 0:
+		if err := report(doctorOK, "sweep", "no empty directories found"); err != nil {
+			return err
+		}
+	default:
+		if err := report(doctorWarn, "sweep", "found %d empty directories, see 'ggman sweep'", len(empty)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
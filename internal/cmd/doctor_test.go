@@ -0,0 +1,99 @@
+package cmd_test
+
+//spellchecker:words testing ggman internal mockenv
+import (
+	"os"
+	"testing"
+
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+)
+
+//spellchecker:words GGROOT GGNORM CANFILE workdir
+
+func TestCommandDoctor(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	mock.Install(t.Context(), "https://github.com/hello/world.git", "misplaced", "world")
+
+	if err := os.MkdirAll(mock.Resolve("github.com", "empty"), 0750); err != nil {
+		panic(err)
+	}
+	if err := os.Symlink(mock.Resolve("nowhere"), mock.Resolve("github.com", "dangling")); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"report problems",
+			"",
+			[]string{"doctor"},
+
+			0,
+			"[ok]    GGROOT: \"${GGROOT}\" exists and is writable\n" +
+				"[ok]    CANFILE: using built-in default\n" +
+				"[ok]    git: using built-in plumbing, no native git found\n" +
+				"[ok]    GGNORM: using default normalization\n" +
+				"[ok]    GGLAYOUT: using default layout\n" +
+				"[warn]  locations: \"${GGROOT misplaced world}\" is not in its expected location\n" +
+				"[warn]  duplicates: \"git@github.com:hello/world.git\" is cloned to \"${GGROOT github.com hello world}\", \"${GGROOT misplaced world}\"\n" +
+				"[warn]  links: \"${GGROOT github.com dangling}\" points to non-existent \"${GGROOT nowhere}\"\n" +
+				"[warn]  sweep: found 1 empty directories, see 'ggman sweep'\n" +
+				"0 error(s), 4 warning(s)\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandDoctor_canfile(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	// a directory can be opened, but not read as a CANFILE
+	canfile := mock.Resolve("canfile")
+	if err := os.Mkdir(canfile, 0750); err != nil {
+		panic(err)
+	}
+	mock.SetCanfile(canfile)
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "doctor")
+	if code != 1 {
+		t.Errorf("Code = %d, wantCode = %d", code, 1)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "[ok]    GGROOT: \"${GGROOT}\" exists and is writable\n"+
+		"[error] CANFILE: failed to load: unable to read CANFILE: read ${GGROOT canfile}: is a directory\n"+
+		"[ok]    git: using built-in plumbing, no native git found\n"+
+		"[ok]    GGNORM: using default normalization\n"+
+		"[ok]    GGLAYOUT: using default layout\n"+
+		"[ok]    locations: all 0 repositories in their expected location\n"+
+		"[ok]    duplicates: no duplicate repositories found\n"+
+		"[ok]    links: no dangling links found\n"+
+		"[warn]  sweep: found 2 empty directories, see 'ggman sweep'\n"+
+		"1 error(s), 1 warning(s)\n")
+	mock.AssertOutput(t, "Stderr", stderr, "failed to pass all checks\n")
+}
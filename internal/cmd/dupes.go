@@ -0,0 +1,132 @@
+package cmd
+
+//spellchecker:words slices strings github cobra ggman internal pkglib
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/pkglib/fsx"
+)
+
+//spellchecker:words dupes canonicalize CANFILE wrapcheck
+
+func NewDupesCommand() *cobra.Command {
+	impl := new(dupes)
+
+	cmd := &cobra.Command{
+		Use:   "dupes",
+		Short: "Find multiple local clones of the same repository",
+		Long: `Dupes finds repositories whose remotes canonicalize to the same URL using the CANFILE.
+
+Each group of duplicates is printed starting with the shared canonical URL.
+It is followed by one line per clone containing the path, the current HEAD, and if the clone is dirty or unsynced.
+A clone is marked with '*' when it is at the location returned by 'ggman where' for its own remote.
+
+For example:
+
+    git@github.com:hello/world.git
+    * /home/user/Projects/github.com/hello/world (main, clean, synced)
+      /home/user/Projects/old/world (main, dirty, unsynced)
+
+Repositories without a remote are ignored.`,
+		Args: cobra.NoArgs,
+
+		RunE: impl.Exec,
+	}
+
+	return cmd
+}
+
+type dupes struct{}
+
+func (dupes) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		NeedsRoot:    true,
+		NeedsCanFile: true,
+		AllowsFilter: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	for _, group := range findDupes(cmd, environment) {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), group.Canonical); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+
+		for _, path := range group.Paths {
+			// the expected location depends on the remote of each clone, as the CANFILE may rewrite the host
+			marker := " "
+			if local, _, err := getCanonicalLocation(path, cmd, environment); err == nil && fsx.Same(path, local) {
+				marker = "*"
+			}
+
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s %s (%s)\n", marker, path, describeClone(cmd, environment, path)); err != nil {
+				return fmt.Errorf("%w: %w", errGenericOutput, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// dupeGroup is a group of repositories sharing the same canonical remote.
+type dupeGroup struct {
+	Canonical string
+	Paths     []string
+}
+
+// findDupes finds all groups of repositories in environment that share the same canonical remote.
+// Groups are sorted by canonical remote, and paths within each group are sorted.
+func findDupes(cmd *cobra.Command, environment *env.Env) []dupeGroup {
+	canonicals := make(map[string][]string)
+	for _, repo := range environment.Repos(cmd.Context(), false) {
+		remote, err := environment.Git.GetRemote(cmd.Context(), repo, "")
+		if err != nil || remote == "" {
+			continue
+		}
+		canonical := environment.Canonical(env.ParseURL(remote))
+		canonicals[canonical] = append(canonicals[canonical], repo)
+	}
+
+	groups := make([]dupeGroup, 0, len(canonicals))
+	for canonical, paths := range canonicals {
+		if len(paths) < 2 {
+			continue
+		}
+		slices.Sort(paths)
+		groups = append(groups, dupeGroup{Canonical: canonical, Paths: paths})
+	}
+	slices.SortFunc(groups, func(a, b dupeGroup) int {
+		return strings.Compare(a.Canonical, b.Canonical)
+	})
+	return groups
+}
+
+// describeClone returns a short description of the state of the repository at path.
+// It consists of the current HEAD, and if the repository is dirty or synced.
+func describeClone(cmd *cobra.Command, environment *env.Env, path string) string {
+	head, err := environment.Git.GetHeadRef(cmd.Context(), path)
+	if err != nil {
+		head = "unknown HEAD"
+	}
+
+	dirty := "clean"
+	if isDirty, err := environment.Git.IsDirty(cmd.Context(), path); err != nil {
+		dirty = "unknown"
+	} else if isDirty {
+		dirty = "dirty"
+	}
+
+	sync := "synced"
+	if isSync, err := environment.Git.IsSync(cmd.Context(), path); err != nil {
+		sync = "unknown"
+	} else if !isSync {
+		sync = "unsynced"
+	}
+
+	return head + ", " + dirty + ", " + sync
+}
@@ -0,0 +1,92 @@
+package cmd_test
+
+//spellchecker:words path filepath testing ggman internal mockenv
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+)
+
+//spellchecker:words GGROOT workdir dupes unsynced CANFILE nosec
+
+func TestCommandDupes(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	old := mock.Install(t.Context(), "https://github.com/hello/world.git", "old", "world")
+	mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world")
+
+	if err := os.WriteFile(filepath.Join(old, "README"), []byte("dirty"), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"list duplicates",
+			"",
+			[]string{"dupes"},
+
+			0,
+			"git@github.com:hello/world.git\n* ${GGROOT github.com hello world} (master, clean, synced)\n  ${GGROOT old world} (master, dirty, synced)\n",
+			"",
+		},
+		{
+			"list duplicates with filter",
+			"",
+			[]string{"--for", "gitlab.com", "dupes"},
+
+			0,
+			"",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandDupes_rewriteHost(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	// the CANFILE rewrites the host, so the canonical url no longer corresponds to the location of any clone
+	CANFILE := filepath.Join(t.TempDir(), "canfile")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte("git@!ssh.example.com:$.git\n"), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.Clone(t.Context(), "https://example.com/hello/world.git", "example.com", "hello", "world")
+	mock.Install(t.Context(), "https://example.com/hello/world.git", "old", "world")
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "dupes")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "git@ssh.example.com:hello/world.git\n* ${GGROOT example.com hello world} (master, clean, synced)\n  ${GGROOT old world} (master, clean, synced)\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+}
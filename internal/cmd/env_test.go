@@ -8,7 +8,7 @@ import (
 	"go.tkw01536.de/ggman/internal/mockenv"
 )
 
-//spellchecker:words workdir GGROOT
+//spellchecker:words workdir GGROOT GGARCHIVE GGJUNK
 
 func TestCommandEnv(t *testing.T) {
 	t.Parallel()
@@ -30,7 +30,7 @@ func TestCommandEnv(t *testing.T) {
 			[]string{"env", "--list"},
 
 			0,
-			"GGROOT\nGIT\nPWD\n",
+			"GGARCHIVE\nGGJUNK\nGGLAYOUT\nGGROOT\nGGROOTS\nGGSCAN\nGIT\nPWD\n",
 			"",
 		},
 		{
@@ -39,7 +39,7 @@ func TestCommandEnv(t *testing.T) {
 			[]string{"env", "--describe"},
 
 			0,
-			"GGROOT: root folder all ggman repositories will be cloned to\nGIT: path to the native git\nPWD: current working directory\n",
+			"GGARCHIVE: folder archived repositories are moved to\nGGJUNK: list of junk file names that do not prevent a directory from being empty\nGGLAYOUT: layout template of repositories within their root folder\nGGROOT: root folder all ggman repositories will be cloned to\nGGROOTS: list of all root folders repositories may be cloned to\nGGSCAN: list of additional folders searched for repositories\nGIT: path to the native git\nPWD: current working directory\n",
 			"",
 		},
 
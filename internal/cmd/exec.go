@@ -1,14 +1,25 @@
 package cmd
 
-//spellchecker:words context errors exec essio shellescape github cobra ggman internal pkglib exit sema status stream
+//spellchecker:words bytes context encoding json jsontext errors exec path filepath strconv strings tabwriter time essio shellescape github cobra ggman internal dirs pkglib exit sema status stream
 import (
+	"bytes"
 	"context"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"al.essio.dev/pkg/shellescape"
 	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/dirs"
 	"go.tkw01536.de/ggman/internal/env"
 	"go.tkw01536.de/pkglib/exit"
 	"go.tkw01536.de/pkglib/sema"
@@ -31,7 +42,58 @@ Standard input, output, and error streams are inherited from ggman.
 
 The repository path is printed to standard error before each invocation.
 Exec exits with the first non-zero exit code encountered.
-Exit code 0 indicates all invocations succeeded.`,
+Exit code 0 indicates all invocations succeeded.
+
+The command and its arguments may contain placeholders, which are replaced for each repository:
+
+- '{path}' => the absolute path to the repository
+- '{relative}' => the path to the repository relative to its root directory
+- '{remote}' => the url of the remote of the repository
+- '{canonical}' => the canonical url of the remote, according to the CANFILE
+- '{branch}' => the currently checked out branch, or the commit hash when not on a branch
+- '{name}' => the last component of the remote url, typically the name of the repository
+
+For example, 'ggman exec -- tar czf /backup/{name}.tgz .' creates an archive of each repository.
+Placeholders are also replaced when using '--simulate'.
+
+The '--simulate' flag prints a bash script that runs the command instead of running it.
+Together with '--parallel', the script runs each command as a background job, with at most the given number of jobs at a time.
+Without '--force', the script stops starting new jobs once it notices that a job has failed.
+Once all jobs have finished, it exits with the exit code of the first failed job.
+The parallel script requires bash 4.3 or newer.
+
+The '--shell' flag runs a single argument as a shell snippet using '$SHELL -c', falling back to '/bin/sh' when '$SHELL' is not set.
+For example, 'ggman exec --shell "if [ -f go.mod ]; then go vet ./...; fi"' runs 'go vet' in each go repository.
+Placeholders in a snippet are replaced by shell-quoted values, and should not be quoted again.
+Snippets should prefer the environment variables below, e.g. '"$GGMAN_REPO_PATH"' instead of '{path}'.
+
+Each invocation furthermore receives the following environment variables:
+
+- '$GGMAN_REPO_PATH' => the absolute path to the repository
+- '$GGMAN_REPO_RELATIVE' => the path to the repository relative to its root directory
+- '$GGMAN_REPO_REMOTE' => the url of the remote of the repository
+- '$GGMAN_REPO_BRANCH' => the currently checked out branch, or the commit hash when not on a branch
+- '$GGMAN_REPO_INDEX' => the index of the repository, starting at 1
+- '$GGMAN_REPO_COUNT' => the total number of repositories
+
+These variables are not set in scripts generated by '--simulate'.
+
+The '--timeout' flag limits the time a single invocation may take, e.g. '--timeout 5m'.
+When it is exceeded, the invocation and all processes it started are killed, and the repository is reported as timed out.
+The '--retries' flag re-runs invocations that exit with a non-zero exit code or time out up to the given number of times.
+Between attempts exec waits for '--retry-delay', doubling the delay after each attempt.
+
+The '--output-dir' flag additionally writes the standard output and error of each invocation into files within the given directory.
+Files are named after the path of the repository relative to its root directory, e.g. 'github.com/hello/world.stdout' and 'github.com/hello/world.stderr'.
+Once all invocations have finished, a table of exit codes is printed to standard error.
+For invocations that failed, it also contains the paths to their output files.
+Repositories that were not run, e.g. because an earlier invocation failed, have exit code '-'.
+
+The '--report json' flag captures the standard output and error of each invocation instead of forwarding them.
+Once all invocations have finished, a JSON report is printed to standard output.
+For each repository it contains the path, whether the command was run, the exit code, the duration in seconds and the captured output.
+It furthermore contains the number of repositories the command succeeded, failed and was not run in.
+The '--report-limit' flag truncates captured output to the given number of bytes, keeping the end of the output.`,
 		Args: cobra.MinimumNArgs(1),
 
 		PreRunE: impl.ParseArgs,
@@ -44,6 +106,13 @@ Exit code 0 indicates all invocations succeeded.`,
 	flags.BoolVarP(&impl.NoRepo, "no-repo", "n", false, "do not print name of repos command is being run in")
 	flags.BoolVarP(&impl.Quiet, "quiet", "q", false, "do not provide input or output streams to the command being run")
 	flags.BoolVarP(&impl.Force, "force", "f", false, "continue execution even if an executable returns a non-zero exit code")
+	flags.BoolVar(&impl.Shell, "shell", false, "run the single argument as a snippet using '$SHELL -c'")
+	flags.DurationVar(&impl.Timeout, "timeout", 0, "kill commands that take longer than the given duration, 0 for no timeout")
+	flags.IntVar(&impl.Retries, "retries", 0, "number of times to retry commands that fail or time out")
+	flags.DurationVar(&impl.RetryDelay, "retry-delay", time.Second, "delay before the first retry, doubled after each retry")
+	flags.StringVar(&impl.OutputDir, "output-dir", "", "write standard output and error of each repository into files within the given directory")
+	flags.StringVar(&impl.Report, "report", "", "capture output and print a report once all commands have finished, must be 'json'")
+	flags.IntVar(&impl.ReportLimit, "report-limit", 0, "maximum number of bytes of output captured per stream in the report, 0 for no limit")
 
 	return cmd
 }
@@ -59,18 +128,66 @@ type exe struct {
 	NoRepo   bool
 	Quiet    bool
 	Force    bool
+	Shell    bool
+
+	Timeout    time.Duration
+	Retries    int
+	RetryDelay time.Duration
+
+	OutputDir string
+
+	Report      string
+	ReportLimit int
 }
 
+const execReportJSON = "json"
+
+// execWaitDelay is the time to wait for output of a command after it has been killed.
+const execWaitDelay = time.Second
+
 var (
-	errExecFatal              = exit.NewErrorWithCode("", env.ExitGeneric)
-	errExecParallelNegative   = exit.NewErrorWithCode(`argument for "--parallel" must be non-negative`, env.ExitCommandArguments)
-	errExecNoParallelSimulate = exit.NewErrorWithCode(`"--simulate" expects "--parallel" to be 1`, env.ExitCommandArguments)
+	errExecFatal             = exit.NewErrorWithCode("", env.ExitGeneric)
+	errExecParallelNegative  = exit.NewErrorWithCode(`argument for "--parallel" must be non-negative`, env.ExitCommandArguments)
+	errExecOutputDirSimulate = exit.NewErrorWithCode(`"--simulate" and "--output-dir" cannot be used together`, env.ExitCommandArguments)
+	errExecOutputDir         = exit.NewErrorWithCode("failed to write output files", env.ExitGeneric)
+	errExecInvalidReport     = exit.NewErrorWithCode(`"--report" must be "json"`, env.ExitCommandArguments)
+	errExecReportLimit       = exit.NewErrorWithCode(`argument for "--report-limit" must be non-negative`, env.ExitCommandArguments)
+	errExecReportSimulate    = exit.NewErrorWithCode(`"--simulate" and "--report" cannot be used together`, env.ExitCommandArguments)
+	errExecShellArgs         = exit.NewErrorWithCode(`"--shell" expects exactly one argument`, env.ExitCommandArguments)
+	errExecTimeoutNegative   = exit.NewErrorWithCode(`argument for "--timeout" must be non-negative`, env.ExitCommandArguments)
+	errExecRetriesNegative   = exit.NewErrorWithCode(`arguments for "--retries" and "--retry-delay" must be non-negative`, env.ExitCommandArguments)
+	errExecRetriesSimulate   = exit.NewErrorWithCode(`"--simulate" cannot be used with "--timeout" or "--retries"`, env.ExitCommandArguments)
+	errExecTimeout           = exit.NewErrorWithCode("command timed out", env.ExitGeneric)
 )
 
 func (e *exe) ParseArgs(cmd *cobra.Command, args []string) error {
 	if e.Parallel < 0 {
 		return errExecParallelNegative
 	}
+	if e.Simulate && e.OutputDir != "" {
+		return errExecOutputDirSimulate
+	}
+	if e.Report != "" && e.Report != execReportJSON {
+		return errExecInvalidReport
+	}
+	if e.ReportLimit < 0 {
+		return errExecReportLimit
+	}
+	if e.Simulate && e.Report != "" {
+		return errExecReportSimulate
+	}
+	if e.Shell && len(args) != 1 {
+		return errExecShellArgs
+	}
+	if e.Timeout < 0 {
+		return errExecTimeoutNegative
+	}
+	if e.Retries < 0 || e.RetryDelay < 0 {
+		return errExecRetriesNegative
+	}
+	if e.Simulate && (e.Timeout != 0 || e.Retries != 0) {
+		return errExecRetriesSimulate
+	}
 
 	e.Positionals.Exe = args[0]
 	e.Positionals.Args = args[1:]
@@ -82,6 +199,7 @@ func (e *exe) Exec(cmd *cobra.Command, args []string) error {
 	environment, err := env.GetEnv(cmd, env.Requirement{
 		AllowsFilter: true,
 		NeedsRoot:    true,
+		NeedsCanFile: e.hasPlaceholder("{canonical}"),
 	})
 	if err != nil {
 		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
@@ -93,11 +211,36 @@ func (e *exe) Exec(cmd *cobra.Command, args []string) error {
 	return e.execReal(cmd, environment)
 }
 
+// execResult is the result of running the command in a single repository.
+type execResult struct {
+	Ran      bool          // was the command run?
+	Code     exit.ExitCode // exit code of the command
+	TimedOut bool          // did the last attempt time out?
+	Attempts int           // number of times the command was run
+
+	Stdout string // path to the file holding standard output, if any
+	Stderr string // path to the file holding standard error, if any
+
+	Duration time.Duration // time it took to run the command
+
+	CapturedStdout bytes.Buffer // captured standard output, when reporting
+	CapturedStderr bytes.Buffer // captured standard error, when reporting
+}
+
 // execReal implements ggman exec for simulate = False.
 func (e *exe) execReal(cmd *cobra.Command, environment *env.Env) (err error) {
 	repos := environment.Repos(cmd.Context(), true)
 
-	statusIO := e.Parallel != 1 && !e.Quiet
+	outputDir := e.OutputDir
+	if outputDir != "" {
+		outputDir, err = environment.Abs(outputDir)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errExecOutputDir, err)
+		}
+	}
+	results := make([]execResult, len(repos))
+
+	statusIO := e.Parallel != 1 && !e.Quiet && e.Report == ""
 
 	var st *status.Status
 	if statusIO {
@@ -107,7 +250,7 @@ func (e *exe) execReal(cmd *cobra.Command, environment *env.Env) (err error) {
 	}
 
 	// schedule each command to be run in parallel by using a semaphore!
-	err = sema.Schedule(func(i uint64) error {
+	err = sema.Schedule(func(i uint64) (err error) {
 		repo := repos[i]
 
 		io := streamFromCommand(cmd)
@@ -131,28 +274,387 @@ func (e *exe) execReal(cmd *cobra.Command, environment *env.Env) (err error) {
 			}
 		}
 
-		return e.execRepo(cmd.Context(), io, repo)
+		if e.Quiet {
+			io = stream.IOStream{}
+		}
+		if e.Report != "" {
+			io.Stdout = &results[i].CapturedStdout
+			io.Stderr = &results[i].CapturedStderr
+		}
+
+		if outputDir != "" {
+			rel := relativeToRoot(environment, repo)
+			results[i].Stdout = filepath.Join(outputDir, rel+".stdout")
+			results[i].Stderr = filepath.Join(outputDir, rel+".stderr")
+
+			stdout, stderr, oErr := openExecOutputs(results[i].Stdout, results[i].Stderr)
+			if oErr != nil {
+				return oErr
+			}
+			defer func() {
+				errClose := errors.Join(stdout.Close(), stderr.Close())
+				if errClose != nil && err == nil {
+					err = fmt.Errorf("%w: %w", errExecOutputDir, errClose)
+				}
+			}()
+			io.Stdout = teeWriter(stdout, io.Stdout)
+			io.Stderr = teeWriter(stderr, io.Stderr)
+		}
+
+		info := getExecRepoInfo(cmd.Context(), environment, repo)
+
+		start := time.Now()
+		results[i].Attempts, err = e.execRepo(cmd.Context(), io, repo, e.argv(environment, info), info.Environ(int(i), len(repos)))
+		results[i].Duration = time.Since(start)
+		results[i].Ran = true
+		results[i].Code, _ = exit.CodeFromError(err, env.ExitGeneric)
+		results[i].TimedOut = errors.Is(err, errExecTimeout)
+		return err
 	}, uint64(len(repos)), sema.Concurrency{
 		Limit: e.Parallel,
 		Force: e.Force,
 	})
+
+	if outputDir != "" {
+		if tErr := e.printResults(cmd, environment, repos, results); tErr != nil && err == nil {
+			return tErr
+		}
+	}
+	if e.Report != "" {
+		if rErr := e.printReport(cmd, environment, repos, results); rErr != nil && err == nil {
+			return rErr
+		}
+	}
+
 	if err != nil {
 		return fmt.Errorf("process reported error: %w", err)
 	}
 	return nil
 }
 
-func (e *exe) execRepo(ctx context.Context, io stream.IOStream, repo string) error {
-	exe := exec.CommandContext(ctx, e.Positionals.Exe, e.Positionals.Args...) /* #nosec G204 -- by design */
-	exe.Dir = repo
+// relativeToRoot returns the path to repo relative to the root directory containing it.
+// If repo is not contained in any root directory, returns the name of the repository folder.
+func relativeToRoot(environment *env.Env, repo string) string {
+	if root, _, ok := environment.RootOf(repo); ok {
+		if rel, err := filepath.Rel(root, repo); err == nil && rel != "." {
+			return rel
+		}
+	}
+	return filepath.Base(repo)
+}
+
+// openExecOutputs creates the files holding standard output and error of a single repository.
+func openExecOutputs(stdoutPath, stderrPath string) (stdout, stderr *os.File, err error) {
+	if err := os.MkdirAll(filepath.Dir(stdoutPath), dirs.NewModBits); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errExecOutputDir, err)
+	}
+
+	stdout, err = os.Create(stdoutPath) /* #nosec G304 -- explicitly passed as a parameter */
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errExecOutputDir, err)
+	}
+	stderr, err = os.Create(stderrPath) /* #nosec G304 -- explicitly passed as a parameter */
+	if err != nil {
+		_ = stdout.Close()
+		return nil, nil, fmt.Errorf("%w: %w", errExecOutputDir, err)
+	}
+	return stdout, stderr, nil
+}
+
+// teeWriter returns a writer that writes to file, and to other if it is not nil.
+func teeWriter(file *os.File, other io.Writer) io.Writer {
+	if other == nil {
+		return file
+	}
+	return io.MultiWriter(file, other)
+}
+
+// printResults prints a table of exit codes for all repositories to standard error.
+func (e *exe) printResults(cmd *cobra.Command, environment *env.Env, repos []string, results []execResult) error {
+	table := tabwriter.NewWriter(cmd.ErrOrStderr(), 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(table, "CODE\tREPOSITORY\tLOGS"); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	for i, result := range results {
+		code := "-"
+		switch {
+		case result.TimedOut:
+			code = "timeout"
+		case result.Ran:
+			code = strconv.Itoa(int(result.Code))
+		}
+
+		var logs string
+		if result.Ran && result.Code != 0 {
+			logs = result.Stdout + " " + result.Stderr
+		}
+
+		if _, err := fmt.Fprintf(table, "%s\t%s\t%s\n", code, relativeToRoot(environment, repos[i]), logs); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+	}
+
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	return nil
+}
+
+// execReport is the report printed by '--report json'.
+type execReport struct {
+	Repos []execReportRepo
+
+	Total     int // total number of repositories
+	Succeeded int // number of repositories the command succeeded in
+	Failed    int // number of repositories the command failed in
+	TimedOut  int // number of repositories the command timed out in
+	Skipped   int // number of repositories the command was not run in
+}
+
+// execReportRepo is the part of an execReport describing a single repository.
+type execReportRepo struct {
+	Path     string
+	Relative string
+
+	Ran      bool
+	Code     int
+	TimedOut bool `json:",omitzero"`
+	Attempts int
+	Duration float64 // in seconds
+
+	Stdout    string `json:",omitempty"`
+	Stderr    string `json:",omitempty"`
+	Truncated bool   `json:",omitzero"` // was any output truncated?
+}
+
+// printReport prints a report of all results to standard output.
+func (e *exe) printReport(cmd *cobra.Command, environment *env.Env, repos []string, results []execResult) error {
+	report := execReport{
+		Repos: make([]execReportRepo, len(results)),
+		Total: len(results),
+	}
+	for i := range results {
+		result := &results[i]
+
+		stdout, stdoutTruncated := truncateOutput(result.CapturedStdout.String(), e.ReportLimit)
+		stderr, stderrTruncated := truncateOutput(result.CapturedStderr.String(), e.ReportLimit)
+
+		report.Repos[i] = execReportRepo{
+			Path:     repos[i],
+			Relative: relativeToRoot(environment, repos[i]),
+
+			Ran:      result.Ran,
+			Code:     int(result.Code),
+			TimedOut: result.TimedOut,
+			Attempts: result.Attempts,
+			Duration: result.Duration.Seconds(),
+
+			Stdout:    stdout,
+			Stderr:    stderr,
+			Truncated: stdoutTruncated || stderrTruncated,
+		}
+
+		switch {
+		case !result.Ran:
+			report.Skipped++
+		case result.TimedOut:
+			report.TimedOut++
+		case result.Code != 0:
+			report.Failed++
+		default:
+			report.Succeeded++
+		}
+	}
+
+	if err := json.MarshalWrite(cmd.OutOrStdout(), report, jsontext.WithIndent("  ")); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	if _, err := fmt.Fprintln(cmd.OutOrStdout()); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	return nil
+}
+
+// truncateOutput truncates output to at most limit bytes, keeping the end of the output.
+// A limit of 0 means no limit.
+func truncateOutput(output string, limit int) (string, bool) {
+	if limit == 0 || len(output) <= limit {
+		return output, false
+	}
+	return output[len(output)-limit:], true
+}
+
+// execPlaceholders are the placeholders replaced in the command and arguments.
+var execPlaceholders = []string{"{path}", "{relative}", "{remote}", "{canonical}", "{branch}", "{name}"}
+
+// hasPlaceholder checks if the command or any of its arguments contain the given placeholder.
+func (e *exe) hasPlaceholder(placeholder string) bool {
+	if strings.Contains(e.Positionals.Exe, placeholder) {
+		return true
+	}
+	for _, arg := range e.Positionals.Args {
+		if strings.Contains(arg, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// argv returns the command and arguments to run in a repository, with all placeholders replaced.
+// When running with '--shell', placeholders are replaced by shell-quoted values, and the snippet is wrapped into a shell invocation.
+func (e *exe) argv(environment *env.Env, info execRepoInfo) []string {
+	argv := append([]string{e.Positionals.Exe}, e.Positionals.Args...)
+
+	var replacements []string
+	for _, placeholder := range execPlaceholders {
+		if !e.hasPlaceholder(placeholder) {
+			continue
+		}
+		value := info.Get(placeholder)
+		if e.Shell {
+			value = shellescape.Quote(value)
+		}
+		replacements = append(replacements, placeholder, value)
+	}
+	if len(replacements) > 0 {
+		replacer := strings.NewReplacer(replacements...)
+		for i, arg := range argv {
+			argv[i] = replacer.Replace(arg)
+		}
+	}
 
-	// setup standard output / input, using either the environment
-	// or be quiet
-	if !e.Quiet {
-		exe.Stdin = io.Stdin
-		exe.Stdout = io.Stdout
-		exe.Stderr = io.Stderr
+	if e.Shell {
+		return []string{execShell(environment), "-c", argv[0]}
 	}
+	return argv
+}
+
+// execShell returns the shell used to run snippets with '--shell'.
+func execShell(environment *env.Env) string {
+	if shell := environment.Vars.SHELL; shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// execRepoInfo holds information about a single repository.
+type execRepoInfo struct {
+	Path      string
+	Relative  string
+	Remote    string
+	Canonical string
+	Branch    string
+	Name      string
+}
+
+// getExecRepoInfo gathers information about the repository at repo.
+// Information that cannot be determined is left empty.
+// The canonical url is only determined if the CANFILE of environment has been loaded.
+func getExecRepoInfo(ctx context.Context, environment *env.Env, repo string) (info execRepoInfo) {
+	info.Path = repo
+	info.Relative = relativeToRoot(environment, repo)
+	info.Name = filepath.Base(repo)
+
+	if branch, err := environment.Git.GetHeadRef(ctx, repo); err == nil {
+		info.Branch = branch
+	}
+
+	remote, err := environment.Git.GetRemote(ctx, repo, "")
+	if err != nil || remote == "" {
+		return info
+	}
+	info.Remote = remote
+
+	url := environment.ParseURL(remote)
+	if components := url.Components(); len(components) > 0 {
+		info.Name = components[len(components)-1]
+	}
+	if environment.CanFile != nil {
+		info.Canonical = environment.Canonical(url)
+	}
+	return info
+}
+
+// Environ returns the environment variables describing the repository.
+// index is the 0-based index of the repository, and count the total number of repositories.
+func (info execRepoInfo) Environ(index, count int) []string {
+	return []string{
+		"GGMAN_REPO_PATH=" + info.Path,
+		"GGMAN_REPO_RELATIVE=" + info.Relative,
+		"GGMAN_REPO_REMOTE=" + info.Remote,
+		"GGMAN_REPO_BRANCH=" + info.Branch,
+		"GGMAN_REPO_INDEX=" + strconv.Itoa(index+1),
+		"GGMAN_REPO_COUNT=" + strconv.Itoa(count),
+	}
+}
+
+// Get returns the value of the given placeholder.
+func (info execRepoInfo) Get(placeholder string) string {
+	switch placeholder {
+	case "{path}":
+		return info.Path
+	case "{relative}":
+		return info.Relative
+	case "{remote}":
+		return info.Remote
+	case "{canonical}":
+		return info.Canonical
+	case "{branch}":
+		return info.Branch
+	case "{name}":
+		return info.Name
+	default:
+		return placeholder
+	}
+}
+
+// execRepo runs the command in repo, retrying it according to the retry flags.
+// It returns the number of attempts made, along with the error of the last attempt.
+func (e *exe) execRepo(ctx context.Context, io stream.IOStream, repo string, argv []string, environ []string) (attempts int, err error) {
+	delay := e.RetryDelay
+	for attempts = 1; ; attempts++ {
+		err = e.execAttempt(ctx, io, repo, argv, environ)
+		if err == nil || attempts > e.Retries || errors.Is(err, errExecFatal) {
+			return attempts, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// execAttempt runs the command in repo once.
+func (e *exe) execAttempt(ctx context.Context, io stream.IOStream, repo string, argv []string, environ []string) error {
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	exe := exec.CommandContext(ctx, argv[0], argv[1:]...) /* #nosec G204 -- by design */
+	exe.Dir = repo
+	exe.Env = append(exe.Environ(), environ...)
+
+	// when timing out, kill the entire process group, not just the command itself
+	// and don't wait for output forever when some child keeps it open.
+	// Without a timeout, the command stays in the foreground process group to keep using the terminal.
+	if e.Timeout > 0 {
+		setProcessGroup(exe)
+		exe.WaitDelay = execWaitDelay
+	}
+
+	// setup standard output / input
+	// these are empty when being quiet
+	exe.Stdin = io.Stdin
+	exe.Stdout = io.Stdout
+	exe.Stderr = io.Stderr
 
 	// run the actual command, and return if the command was oK!
 	err := exe.Run()
@@ -160,6 +662,11 @@ func (e *exe) execRepo(ctx context.Context, io stream.IOStream, repo string) err
 		return nil
 	}
 
+	// the command timed out
+	if e.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", errExecTimeout, e.Timeout)
+	}
+
 	// when something went wrong intercept ExitErrors
 	// but actually return other error properly!
 	var exitError *exec.ExitError
@@ -173,7 +680,7 @@ func (e *exe) execRepo(ctx context.Context, io stream.IOStream, repo string) err
 // execSimulate runs the --simulate flag.
 func (e *exe) execSimulate(cmd *cobra.Command, environment *env.Env) (err error) {
 	if e.Parallel != 1 {
-		return fmt.Errorf("%w, but got %d", errExecNoParallelSimulate, e.Parallel)
+		return e.execSimulateParallel(cmd, environment)
 	}
 
 	// print header of the bash script
@@ -189,8 +696,6 @@ func (e *exe) execSimulate(cmd *cobra.Command, environment *env.Env) (err error)
 		return fmt.Errorf("%w: %w", errGenericOutput, err)
 	}
 
-	exec := shellescape.QuoteCommand(append([]string{e.Positionals.Exe}, e.Positionals.Args...))
-
 	// iterate over each repository
 	// then print each of the commands to be run!
 	for _, repo := range environment.Repos(cmd.Context(), true) {
@@ -203,7 +708,7 @@ func (e *exe) execSimulate(cmd *cobra.Command, environment *env.Env) (err error)
 			}
 		}
 
-		if _, err := fmt.Fprintln(cmd.OutOrStdout(), exec); err != nil {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), shellescape.QuoteCommand(e.argv(environment, getExecRepoInfo(cmd.Context(), environment, repo)))); err != nil {
 			return fmt.Errorf("%w: %w", errGenericOutput, err)
 		}
 		if _, err := fmt.Fprintln(cmd.OutOrStdout(), ""); err != nil {
@@ -214,4 +719,114 @@ func (e *exe) execSimulate(cmd *cobra.Command, environment *env.Env) (err error)
 	return err
 }
 
+// execSimulateParallel runs the --simulate flag when running in parallel.
+//
+// Each repository is run as a background job, and the script waits for jobs in the order they were started.
+// This guarantees that the exit code of every job is checked.
+func (e *exe) execSimulateParallel(cmd *cobra.Command, environment *env.Env) error {
+	w := cmd.OutOrStdout()
+
+	// the ggman_wait function records the exit codes of all jobs that have finished.
+	// if no job has finished yet, it waits for whichever job finishes first instead, to be recorded by the next call.
+	// exit codes are collected using 'wait PID', as 'wait -n' does not report jobs that have finished before it was called.
+	//
+	// the ggman_next function is called before starting each job.
+	// it waits for a free slot, and fails when a previous job failed (unless forced).
+	var next []string
+	if e.Parallel > 0 {
+		next = append(next,
+			fmt.Sprintf(`  while [ "${#ggman_pids[@]}" -ge %d ]; do`, e.Parallel),
+			"    ggman_wait",
+			"  done",
+		)
+	}
+	if !e.Force {
+		next = append(next, `  [ "$ggman_code" -eq 0 ]`)
+	}
+	if len(next) == 0 {
+		next = append(next, "  :")
+	}
+
+	limit := "all at once"
+	if e.Parallel > 0 {
+		limit = fmt.Sprintf("at most %d at a time", e.Parallel)
+	}
+
+	header := []string{
+		"#!/bin/bash",
+		"",
+		"# run commands in parallel, " + limit,
+		"ggman_pids=()",
+		"ggman_code=0",
+		"ggman_wait() {",
+		"  local pid code running=()",
+		`  for pid in "${ggman_pids[@]}"; do`,
+		`    if kill -0 "$pid" 2>/dev/null; then`,
+		`      running+=("$pid")`,
+		"      continue",
+		"    fi",
+		`    wait "$pid"`,
+		"    code=$?",
+		`    if [ "$code" -ne 0 ] && [ "$ggman_code" -eq 0 ]; then`,
+		"      ggman_code=$code",
+		"    fi",
+		"  done",
+		`  if [ "${#running[@]}" -eq "${#ggman_pids[@]}" ]; then`,
+		"    wait -n",
+		"  fi",
+		`  ggman_pids=("${running[@]}")`,
+		"}",
+		"ggman_next() {",
+	}
+	header = append(header, next...)
+	header = append(header,
+		"}",
+		"ggman_finish() {",
+		`  while [ "${#ggman_pids[@]}" -gt 0 ]; do`,
+		"    ggman_wait",
+		"  done",
+		`  exit "$ggman_code"`,
+		"}",
+		"",
+	)
+	for _, line := range header {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+	}
+
+	nextCall := "ggman_next"
+	if !e.Force {
+		nextCall = "ggman_next || ggman_finish"
+	}
+
+	for _, repo := range environment.Repos(cmd.Context(), true) {
+		lines := []string{
+			nextCall,
+			"(",
+			fmt.Sprintf("  cd %s || exit", shellescape.Quote(repo)),
+		}
+		if !e.NoRepo {
+			lines = append(lines, fmt.Sprintf("  echo %s", shellescape.Quote(repo)))
+		}
+		lines = append(lines,
+			"  "+shellescape.QuoteCommand(e.argv(environment, getExecRepoInfo(cmd.Context(), environment, repo))),
+			") &",
+			"ggman_pids+=($!)",
+			"",
+		)
+
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return fmt.Errorf("%w: %w", errGenericOutput, err)
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "ggman_finish"); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	return nil
+}
+
 //spellchecker:words nosec
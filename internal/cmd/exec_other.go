@@ -0,0 +1,12 @@
+//go:build !unix
+
+package cmd
+
+//spellchecker:words exec
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing on this platform.
+// When the command is cancelled, only the process itself is killed.
+func setProcessGroup(_ *exec.Cmd) {}
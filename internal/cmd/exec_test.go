@@ -1,13 +1,20 @@
 package cmd_test
 
-//spellchecker:words exec runtime testing ggman internal mockenv
+//spellchecker:words encoding json exec path filepath reflect runtime strings testing time ggman internal mockenv pkglib
 import (
+	"encoding/json/v2"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"go.tkw01536.de/ggman/internal/cmd"
 	"go.tkw01536.de/ggman/internal/mockenv"
+	"go.tkw01536.de/pkglib/fsx"
 )
 
 //spellchecker:words workdir GGROOT
@@ -246,6 +253,443 @@ func TestCommandExec_simulate(t *testing.T) {
 			"#!/bin/bash\n\ncd `${GGROOT github.com hello world}`\npwd\n\ncd `${GGROOT gitlab.com hello world}`\npwd\n\ncd `${GGROOT server.com user repo}`\npwd\n\n",
 			"",
 		},
+
+		{
+			"simulate exec with --parallel",
+			"",
+			[]string{"exec", "--simulate", "--parallel", "2", "pwd"},
+
+			0,
+			"#!/bin/bash\n\n# run commands in parallel, at most 2 at a time\nggman_pids=()\nggman_code=0\nggman_wait() {\n  local pid code running=()\n  for pid in \"${ggman_pids[@]}\"; do\n    if kill -0 \"$pid\" 2>/dev/null; then\n      running+=(\"$pid\")\n      continue\n    fi\n    wait \"$pid\"\n    code=$?\n    if [ \"$code\" -ne 0 ] && [ \"$ggman_code\" -eq 0 ]; then\n      ggman_code=$code\n    fi\n  done\n  if [ \"${#running[@]}\" -eq \"${#ggman_pids[@]}\" ]; then\n    wait -n\n  fi\n  ggman_pids=(\"${running[@]}\")\n}\nggman_next() {\n  while [ \"${#ggman_pids[@]}\" -ge 2 ]; do\n    ggman_wait\n  done\n  [ \"$ggman_code\" -eq 0 ]\n}\nggman_finish() {\n  while [ \"${#ggman_pids[@]}\" -gt 0 ]; do\n    ggman_wait\n  done\n  exit \"$ggman_code\"\n}\n\n" +
+				"ggman_next || ggman_finish\n(\n  cd `${GGROOT github.com hello world}` || exit\n  echo `${GGROOT github.com hello world}`\n  pwd\n) &\nggman_pids+=($!)\n\n" +
+				"ggman_next || ggman_finish\n(\n  cd `${GGROOT gitlab.com hello world}` || exit\n  echo `${GGROOT gitlab.com hello world}`\n  pwd\n) &\nggman_pids+=($!)\n\n" +
+				"ggman_next || ggman_finish\n(\n  cd `${GGROOT server.com user repo}` || exit\n  echo `${GGROOT server.com user repo}`\n  pwd\n) &\nggman_pids+=($!)\n\n" +
+				"ggman_finish\n",
+			"",
+		},
+
+		{
+			"simulate exec with unlimited --parallel and --force",
+			"",
+			[]string{"exec", "--simulate", "--parallel", "0", "--no-repo", "--force", "pwd"},
+
+			0,
+			"#!/bin/bash\n\n# run commands in parallel, all at once\nggman_pids=()\nggman_code=0\nggman_wait() {\n  local pid code running=()\n  for pid in \"${ggman_pids[@]}\"; do\n    if kill -0 \"$pid\" 2>/dev/null; then\n      running+=(\"$pid\")\n      continue\n    fi\n    wait \"$pid\"\n    code=$?\n    if [ \"$code\" -ne 0 ] && [ \"$ggman_code\" -eq 0 ]; then\n      ggman_code=$code\n    fi\n  done\n  if [ \"${#running[@]}\" -eq \"${#ggman_pids[@]}\" ]; then\n    wait -n\n  fi\n  ggman_pids=(\"${running[@]}\")\n}\nggman_next() {\n  :\n}\nggman_finish() {\n  while [ \"${#ggman_pids[@]}\" -gt 0 ]; do\n    ggman_wait\n  done\n  exit \"$ggman_code\"\n}\n\n" +
+				"ggman_next\n(\n  cd `${GGROOT github.com hello world}` || exit\n  pwd\n) &\nggman_pids+=($!)\n\n" +
+				"ggman_next\n(\n  cd `${GGROOT gitlab.com hello world}` || exit\n  pwd\n) &\nggman_pids+=($!)\n\n" +
+				"ggman_next\n(\n  cd `${GGROOT server.com user repo}` || exit\n  pwd\n) &\nggman_pids+=($!)\n\n" +
+				"ggman_finish\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandExec_simulateParallel(t *testing.T) {
+	t.Parallel()
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found in path")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not found")
+	}
+
+	mock := setupExecTest(t)
+
+	// the first job is slow, the second one fails immediately.
+	// the failure should be noticed before the first job finishes, so the third job is never started.
+	snippet := "case {relative} in github.com/*) sleep 2 ;; gitlab.com/*) exit 3 ;; *) echo started ;; esac"
+
+	code, script, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "exec", "--simulate", "--parallel", "2", "--no-repo", "--shell", snippet)
+	if code != 0 {
+		t.Fatalf("Code = %d, wantCode = 0, stderr = %q", code, stderr)
+	}
+
+	var errOutput strings.Builder
+	run := exec.CommandContext(t.Context(), bash, "-c", script) // #nosec G204 -- script generated by test
+	run.Stderr = &errOutput
+	output, err := run.Output()
+	if got := run.ProcessState.ExitCode(); got != 3 {
+		t.Errorf("script exit code = %d, want 3 (error %v, stderr %q)", got, err, errOutput.String())
+	}
+	if string(output) != "" {
+		t.Errorf("script output = %q, want no output", output)
+	}
+}
+
+func TestCommandExec_outputDir(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("pwd"); err != nil {
+		t.Skip("pwd not found in path")
+	}
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false not found in path")
+	}
+
+	mock := setupExecTest(t)
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+
+		wantFiles map[string]string
+	}{
+		{
+			"pwd with output dir",
+			"",
+			[]string{"exec", "--output-dir", mock.Resolve("..", "pwd"), "pwd"},
+
+			0,
+			"${GGROOT github.com hello world}\n${GGROOT gitlab.com hello world}\n${GGROOT server.com user repo}\n",
+			"${GGROOT github.com hello world}\n${GGROOT gitlab.com hello world}\n${GGROOT server.com user repo}\nCODE  REPOSITORY              LOGS\n0     github.com/hello/world  \n0     gitlab.com/hello/world  \n0     server.com/user/repo    \n",
+
+			map[string]string{
+				mock.Resolve("..", "pwd", "github.com", "hello", "world.stdout"): mock.Resolve("github.com", "hello", "world") + "\n",
+				mock.Resolve("..", "pwd", "github.com", "hello", "world.stderr"): "",
+				mock.Resolve("..", "pwd", "server.com", "user", "repo.stdout"):   mock.Resolve("server.com", "user", "repo") + "\n",
+			},
+		},
+		{
+			"quiet pwd with output dir",
+			"",
+			[]string{"exec", "--quiet", "--no-repo", "--output-dir", mock.Resolve("..", "quiet"), "pwd"},
+
+			0,
+			"",
+			"CODE  REPOSITORY              LOGS\n0     github.com/hello/world  \n0     gitlab.com/hello/world  \n0     server.com/user/repo    \n",
+
+			map[string]string{
+				mock.Resolve("..", "quiet", "gitlab.com", "hello", "world.stdout"): mock.Resolve("gitlab.com", "hello", "world") + "\n",
+			},
+		},
+		{
+			"false with output dir",
+			"",
+			[]string{"exec", "--no-repo", "--output-dir", mock.Resolve("..", "false"), "false"},
+
+			1,
+			"",
+			"CODE  REPOSITORY              LOGS\n1     github.com/hello/world  ${GGROOT .. false github.com hello world.stdout} ${GGROOT .. false github.com hello world.stderr}\n-     gitlab.com/hello/world  \n-     server.com/user/repo    \nprocess reported error: exit status 1\n",
+
+			map[string]string{
+				mock.Resolve("..", "false", "github.com", "hello", "world.stdout"): "",
+			},
+		},
+		{
+			"false with output dir and force",
+			"",
+			[]string{"exec", "--no-repo", "--force", "--output-dir", mock.Resolve("..", "force"), "false"},
+
+			1,
+			"",
+			"CODE  REPOSITORY              LOGS\n1     github.com/hello/world  ${GGROOT .. force github.com hello world.stdout} ${GGROOT .. force github.com hello world.stderr}\n1     gitlab.com/hello/world  ${GGROOT .. force gitlab.com hello world.stdout} ${GGROOT .. force gitlab.com hello world.stderr}\n1     server.com/user/repo    ${GGROOT .. force server.com user repo.stdout} ${GGROOT .. force server.com user repo.stderr}\nprocess reported error: exit status 1\n",
+
+			nil,
+		},
+		{
+			"output dir with simulate",
+			"",
+			[]string{"exec", "--simulate", "--output-dir", mock.Resolve("..", "simulate"), "pwd"},
+
+			4,
+			"",
+			"\"--simulate\" and \"--output-dir\" cannot be used together\n",
+
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+
+			for path, want := range tt.wantFiles {
+				got, err := os.ReadFile(path) // #nosec G304 -- test file
+				if err != nil {
+					t.Errorf("ReadFile(%q) error = %v", path, err)
+					continue
+				}
+				if string(got) != want {
+					t.Errorf("ReadFile(%q) = %q, want = %q", path, string(got), want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandExec_report(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not found in path")
+	}
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false not found in path")
+	}
+
+	mock := setupExecTest(t)
+
+	type reportRepo struct {
+		Path      string
+		Relative  string
+		Ran       bool
+		Code      int
+		Duration  float64
+		Stdout    string
+		Stderr    string
+		Truncated bool
+	}
+	type report struct {
+		Repos                             []reportRepo
+		Total, Succeeded, Failed, Skipped int
+	}
+
+	ghHelloWorld := mock.Resolve("github.com", "hello", "world")
+	glHelloWorld := mock.Resolve("gitlab.com", "hello", "world")
+	serverRepo := mock.Resolve("server.com", "user", "repo")
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantCode   uint8
+		wantStderr string
+		want       report
+	}{
+		{
+			"echo",
+			[]string{"exec", "--report", "json", "echo", "hello world"},
+
+			0,
+			"${GGROOT github.com hello world}\n${GGROOT gitlab.com hello world}\n${GGROOT server.com user repo}\n",
+			report{
+				Repos: []reportRepo{
+					{Path: ghHelloWorld, Relative: filepath.Join("github.com", "hello", "world"), Ran: true, Stdout: "hello world\n"},
+					{Path: glHelloWorld, Relative: filepath.Join("gitlab.com", "hello", "world"), Ran: true, Stdout: "hello world\n"},
+					{Path: serverRepo, Relative: filepath.Join("server.com", "user", "repo"), Ran: true, Stdout: "hello world\n"},
+				},
+				Total:     3,
+				Succeeded: 3,
+			},
+		},
+		{
+			"echo with limit",
+			[]string{"exec", "--no-repo", "--report", "json", "--report-limit", "6", "echo", "hello world"},
+
+			0,
+			"",
+			report{
+				Repos: []reportRepo{
+					{Path: ghHelloWorld, Relative: filepath.Join("github.com", "hello", "world"), Ran: true, Stdout: "world\n", Truncated: true},
+					{Path: glHelloWorld, Relative: filepath.Join("gitlab.com", "hello", "world"), Ran: true, Stdout: "world\n", Truncated: true},
+					{Path: serverRepo, Relative: filepath.Join("server.com", "user", "repo"), Ran: true, Stdout: "world\n", Truncated: true},
+				},
+				Total:     3,
+				Succeeded: 3,
+			},
+		},
+		{
+			"false",
+			[]string{"exec", "--no-repo", "--report", "json", "false"},
+
+			1,
+			"process reported error: exit status 1\n",
+			report{
+				Repos: []reportRepo{
+					{Path: ghHelloWorld, Relative: filepath.Join("github.com", "hello", "world"), Ran: true, Code: 1},
+					{Path: glHelloWorld, Relative: filepath.Join("gitlab.com", "hello", "world")},
+					{Path: serverRepo, Relative: filepath.Join("server.com", "user", "repo")},
+				},
+				Total:   3,
+				Failed:  1,
+				Skipped: 2,
+			},
+		},
+		{
+			"false with force",
+			[]string{"exec", "--no-repo", "--force", "--report", "json", "false"},
+
+			1,
+			"process reported error: exit status 1\n",
+			report{
+				Repos: []reportRepo{
+					{Path: ghHelloWorld, Relative: filepath.Join("github.com", "hello", "world"), Ran: true, Code: 1},
+					{Path: glHelloWorld, Relative: filepath.Join("gitlab.com", "hello", "world"), Ran: true, Code: 1},
+					{Path: serverRepo, Relative: filepath.Join("server.com", "user", "repo"), Ran: true, Code: 1},
+				},
+				Total:  3,
+				Failed: 3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+
+			var got report
+			if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+				t.Fatalf("failed to unmarshal JSON output: %v", err)
+			}
+			for i, repo := range got.Repos {
+				if repo.Duration < 0 {
+					t.Errorf("Repos[%d].Duration = %v, want non-negative", i, repo.Duration)
+				}
+				got.Repos[i].Duration = 0
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandExec_reportFlags(t *testing.T) {
+	t.Parallel()
+
+	mock := setupExecTest(t)
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantCode   uint8
+		wantStderr string
+	}{
+		{
+			"unknown report format",
+			[]string{"exec", "--report", "yaml", "true"},
+
+			4,
+			"\"--report\" must be \"json\"\n",
+		},
+		{
+			"negative report limit",
+			[]string{"exec", "--report", "json", "--report-limit", "-1", "true"},
+
+			4,
+			"argument for \"--report-limit\" must be non-negative\n",
+		},
+		{
+			"report with simulate",
+			[]string{"exec", "--report", "json", "--simulate", "true"},
+
+			4,
+			"\"--simulate\" and \"--report\" cannot be used together\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, "")
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandExec_placeholders(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not found in path")
+	}
+
+	mock := setupExecTest(t)
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"path and relative",
+			"",
+			[]string{"exec", "--no-repo", "--", "echo", "{path}", "{relative}"},
+
+			0,
+			"${GGROOT github.com hello world} github.com/hello/world\n${GGROOT gitlab.com hello world} gitlab.com/hello/world\n${GGROOT server.com user repo} server.com/user/repo\n",
+			"",
+		},
+		{
+			"remote, canonical and name",
+			"",
+			[]string{"exec", "--no-repo", "--", "echo", "{remote}", "{canonical}", "/backup/{name}.tgz"},
+
+			0,
+			"https://github.com/hello/world.git git@github.com:hello/world.git /backup/world.tgz\nhttps://gitlab.com/hello/world.git git@gitlab.com:hello/world.git /backup/world.tgz\nuser@server.com/repo git@server.com:user/repo.git /backup/repo.tgz\n",
+			"",
+		},
+		{
+			"branch",
+			"",
+			[]string{"exec", "--no-repo", "--", "echo", "{branch}"},
+
+			0,
+			"master\nmaster\nmaster\n",
+			"",
+		},
+		{
+			"unknown placeholders are kept",
+			"",
+			[]string{"exec", "--no-repo", "--", "echo", "{}", "{unknown}"},
+
+			0,
+			"{} {unknown}\n{} {unknown}\n{} {unknown}\n",
+			"",
+		},
+		{
+			"simulate",
+			"",
+			[]string{"exec", "--simulate", "--no-repo", "--", "echo", "{name}", "{path}"},
+
+			0,
+			"#!/bin/bash\nset -e\n\ncd `${GGROOT github.com hello world}`\necho world `${GGROOT github.com hello world}`\n\ncd `${GGROOT gitlab.com hello world}`\necho world `${GGROOT gitlab.com hello world}`\n\ncd `${GGROOT server.com user repo}`\necho repo `${GGROOT server.com user repo}`\n\n",
+			"",
+		},
 	}
 
 	for _, tt := range tests {
@@ -261,3 +705,335 @@ func TestCommandExec_simulate(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandExec_shell(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows because there is no /bin/sh")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not found")
+	}
+
+	mock := setupExecTest(t)
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"shell snippet",
+			"",
+			[]string{"exec", "--no-repo", "--shell", "if [ -d .git ]; then echo {name}; fi"},
+
+			0,
+			"world\nworld\nrepo\n",
+			"",
+		},
+		{
+			"environment variables",
+			"",
+			[]string{"exec", "--no-repo", "--shell", `echo "$GGMAN_REPO_INDEX/$GGMAN_REPO_COUNT $GGMAN_REPO_RELATIVE $GGMAN_REPO_REMOTE $GGMAN_REPO_BRANCH"`},
+
+			0,
+			"1/3 github.com/hello/world https://github.com/hello/world.git master\n2/3 gitlab.com/hello/world https://gitlab.com/hello/world.git master\n3/3 server.com/user/repo user@server.com/repo master\n",
+			"",
+		},
+		{
+			"path variable",
+			"",
+			[]string{"exec", "--no-repo", "--", "sh", "-c", `test "$GGMAN_REPO_PATH" = "$(pwd)" && echo ok`},
+
+			0,
+			"ok\nok\nok\n",
+			"",
+		},
+		{
+			"shell with simulate",
+			"",
+			[]string{"exec", "--simulate", "--no-repo", "--shell", "echo {name}"},
+
+			0,
+			"#!/bin/bash\nset -e\n\ncd `${GGROOT github.com hello world}`\n/bin/sh -c 'echo world'\n\ncd `${GGROOT gitlab.com hello world}`\n/bin/sh -c 'echo world'\n\ncd `${GGROOT server.com user repo}`\n/bin/sh -c 'echo repo'\n\n",
+			"",
+		},
+		{
+			"shell with several arguments",
+			"",
+			[]string{"exec", "--shell", "echo", "hello"},
+
+			4,
+			"",
+			"\"--shell\" expects exactly one argument\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandExec_shellQuoting(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows because there is no /bin/sh")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not found")
+	}
+
+	mock := mockenv.NewMockEnv(t)
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "hello world; echo injected")
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantStdout string
+	}{
+		{
+			"placeholder",
+			[]string{"exec", "--no-repo", "--shell", `test {path} = "$(pwd)" && echo {relative}`},
+
+			"hello world; echo injected\n",
+		},
+		{
+			"environment variable",
+			[]string{"exec", "--no-repo", "--shell", `test "$GGMAN_REPO_PATH" = "$(pwd)" && echo "$GGMAN_REPO_RELATIVE"`},
+
+			"hello world; echo injected\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != 0 {
+				t.Errorf("Code = %d, wantCode = 0", code)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, "")
+		})
+	}
+}
+
+func TestCommandExec_timeout(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows because there is no /bin/sh")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not found")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not found in path")
+	}
+
+	mock := setupExecTest(t)
+
+	// marker is created by a background process of the snippet, unless it is killed along with the snippet
+	marker := mock.Resolve("github.com", "hello", "world", "marker")
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+		wantAbsent string // path that must not exist once background processes would have finished
+	}{
+		{
+			"timeout kills the process group",
+			[]string{"exec", "--no-repo", "--timeout", "100ms", "--output-dir", mock.Resolve("..", "timeout"), "--shell", "(sleep 1; touch marker) & sleep 10"},
+
+			1,
+			"",
+			"CODE     REPOSITORY              LOGS\ntimeout  github.com/hello/world  ${GGROOT .. timeout github.com hello world.stdout} ${GGROOT .. timeout github.com hello world.stderr}\n-        gitlab.com/hello/world  \n-        server.com/user/repo    \nprocess reported error: command timed out after 100ms\n",
+			marker,
+		},
+		{
+			"no timeout",
+			[]string{"exec", "--no-repo", "--timeout", "10s", "--shell", "echo {name}"},
+
+			0,
+			"world\nworld\nrepo\n",
+			"",
+			"",
+		},
+		{
+			"negative timeout",
+			[]string{"exec", "--timeout", "-1s", "true"},
+
+			4,
+			"",
+			"argument for \"--timeout\" must be non-negative\n",
+			"",
+		},
+		{
+			"negative retries",
+			[]string{"exec", "--retries", "-1", "true"},
+
+			4,
+			"",
+			"arguments for \"--retries\" and \"--retry-delay\" must be non-negative\n",
+			"",
+		},
+		{
+			"timeout with simulate",
+			[]string{"exec", "--simulate", "--timeout", "1s", "true"},
+
+			4,
+			"",
+			"\"--simulate\" cannot be used with \"--timeout\" or \"--retries\"\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			start := time.Now()
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if took := time.Since(start); took > 5*time.Second {
+				t.Errorf("command took %s, want less than 5s", took)
+			}
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+
+			if tt.wantAbsent == "" {
+				return
+			}
+			time.Sleep(2 * time.Second)
+			if exists, err := fsx.Exists(tt.wantAbsent); err != nil || exists {
+				t.Errorf("%q exists = %v, want false (error %v)", tt.wantAbsent, exists, err)
+			}
+		})
+	}
+}
+
+func TestCommandExec_retries(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows because there is no /bin/sh")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not found")
+	}
+
+	mock := setupExecTest(t)
+
+	type reportRepo struct {
+		Relative string
+		Code     int
+		TimedOut bool
+		Attempts int
+	}
+	type report struct {
+		Repos                                       []reportRepo
+		Total, Succeeded, Failed, TimedOut, Skipped int
+	}
+
+	// snippet returns a snippet that succeeds on the third attempt in each repository
+	snippet := func(name string) string {
+		counter := mock.Resolve("..", name) + "-$GGMAN_REPO_INDEX"
+		return `echo x >> "` + counter + `" && test "$(wc -l < "` + counter + `")" -ge 3`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantCode uint8
+		want     report
+	}{
+		{
+			"not enough retries",
+			[]string{"exec", "--no-repo", "--force", "--report", "json", "--retries", "1", "--retry-delay", "0s", "--shell", snippet("not-enough")},
+
+			1,
+			report{
+				Repos: []reportRepo{
+					{Relative: filepath.Join("github.com", "hello", "world"), Code: 1, Attempts: 2},
+					{Relative: filepath.Join("gitlab.com", "hello", "world"), Code: 1, Attempts: 2},
+					{Relative: filepath.Join("server.com", "user", "repo"), Code: 1, Attempts: 2},
+				},
+				Total:  3,
+				Failed: 3,
+			},
+		},
+		{
+			"enough retries",
+			[]string{"exec", "--no-repo", "--report", "json", "--retries", "5", "--retry-delay", "1ms", "--shell", snippet("enough")},
+
+			0,
+			report{
+				Repos: []reportRepo{
+					{Relative: filepath.Join("github.com", "hello", "world"), Attempts: 3},
+					{Relative: filepath.Join("gitlab.com", "hello", "world"), Attempts: 3},
+					{Relative: filepath.Join("server.com", "user", "repo"), Attempts: 3},
+				},
+				Total:     3,
+				Succeeded: 3,
+			},
+		},
+		{
+			"timeouts are retried",
+			[]string{"exec", "--no-repo", "--force", "--report", "json", "--timeout", "50ms", "--retries", "1", "--retry-delay", "0s", "--shell", "sleep 10"},
+
+			1,
+			report{
+				Repos: []reportRepo{
+					{Relative: filepath.Join("github.com", "hello", "world"), Code: 1, TimedOut: true, Attempts: 2},
+					{Relative: filepath.Join("gitlab.com", "hello", "world"), Code: 1, TimedOut: true, Attempts: 2},
+					{Relative: filepath.Join("server.com", "user", "repo"), Code: 1, TimedOut: true, Attempts: 2},
+				},
+				Total:    3,
+				TimedOut: 3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, _ := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+
+			var got report
+			if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+				t.Fatalf("failed to unmarshal JSON output: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
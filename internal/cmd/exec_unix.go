@@ -0,0 +1,20 @@
+//go:build unix
+
+package cmd
+
+//spellchecker:words exec syscall
+import (
+	"os/exec"
+	"syscall"
+)
+
+//spellchecker:words setpgid
+
+// setProcessGroup places the command into its own process group.
+// When the command is cancelled, the entire group is killed.
+func setProcessGroup(exe *exec.Cmd) {
+	exe.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	exe.Cancel = func() error {
+		return syscall.Kill(-exe.Process.Pid, syscall.SIGKILL)
+	}
+}
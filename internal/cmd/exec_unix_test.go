@@ -0,0 +1,62 @@
+//go:build unix
+
+package cmd_test
+
+//spellchecker:words exec strconv strings syscall testing ggman internal
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"go.tkw01536.de/ggman/internal/cmd"
+)
+
+//spellchecker:words pgid getpgrp
+
+func TestCommandExec_processGroup(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("ps"); err != nil {
+		t.Skip("ps not found in path")
+	}
+
+	mock := setupExecTest(t)
+
+	// the process group of ggman, which runs within the test process
+	group := strconv.Itoa(syscall.Getpgrp())
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantGroup bool // should the command be in the process group of ggman?
+	}{
+		{
+			"without timeout",
+			[]string{"exec", "--for", "github.com/hello/world", "--shell", "ps -o pgid= -p $$"},
+			true,
+		},
+		{
+			"with timeout",
+			[]string{"exec", "--for", "github.com/hello/world", "--timeout", "10s", "--shell", "ps -o pgid= -p $$"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != 0 {
+				t.Fatalf("Code = %d, wantCode = 0, stderr = %q", code, stderr)
+			}
+
+			got := strings.TrimSpace(stdout)
+			if (got == group) != tt.wantGroup {
+				t.Errorf("process group = %q, ggman process group = %q, want same = %v", got, group, tt.wantGroup)
+			}
+		})
+	}
+}
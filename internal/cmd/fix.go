@@ -1,8 +1,11 @@
 package cmd
 
-//spellchecker:words sync github cobra ggman internal pkglib exit
+//spellchecker:words maps slices strings sync github cobra ggman internal pkglib exit
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/spf13/cobra"
@@ -10,7 +13,7 @@ import (
 	"go.tkw01536.de/pkglib/exit"
 )
 
-//spellchecker:words canonicalize
+//spellchecker:words canonicalize pushurl CANSPEC
 
 func NewFixCommand() *cobra.Command {
 	impl := new(fix)
@@ -20,6 +23,9 @@ func NewFixCommand() *cobra.Command {
 		Short: "Canonicalize remote URLs for cloned repositories",
 		Long: `Fix canonicalizes the URLs of all remotes in matching repositories using the CANFILE.
 
+When the matching CANFILE line contains a push CANSPEC, the push URL of each remote ('remote.<name>.pushurl') is set accordingly.
+Otherwise push URLs are left untouched.
+
 The '--simulate' flag prints actions without modifying any URLs.`,
 		Args: cobra.NoArgs,
 
@@ -126,6 +132,11 @@ func (f *fix) Exec(cmd *cobra.Command, args []string) error {
 			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), e.Error()) // no way to report error
 			hasError = true
 		}
+
+		if e := f.fixPushURLs(cmd, environment, repo, &initialMessage); e != nil {
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), e.Error()) // no way to report error
+			hasError = true
+		}
 	}
 
 	// if we had an error, indicate that to the user
@@ -136,3 +147,58 @@ func (f *fix) Exec(cmd *cobra.Command, args []string) error {
 	// and finish
 	return nil
 }
+
+// fixPushURLs sets the push urls of all remotes of repo according to the push CANSPEC of the CANFILE.
+// initialMessage is used to print the initial message for the repository.
+func (f *fix) fixPushURLs(cmd *cobra.Command, environment *env.Env, repo string, initialMessage *sync.Once) error {
+	remotes, err := environment.Git.GetAllRemotes(cmd.Context(), repo)
+	if err != nil {
+		return fmt.Errorf("failed to get remotes: %w", err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(remotes)) {
+		push, ok := environment.CanonicalPush(env.ParseURL(remotes[name]))
+		if !ok {
+			continue
+		}
+
+		current, err := environment.Git.GetPushURLs(cmd.Context(), repo, name)
+		if err != nil {
+			return fmt.Errorf("failed to get push urls: %w", err)
+		}
+		if len(current) == 1 && current[0] == push {
+			continue
+		}
+
+		var innerError error
+		initialMessage.Do(func() {
+			message := "Fixing remote of %q\n"
+			if f.Simulate {
+				message = "Simulate fixing remote of %q\n"
+			}
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), message, repo); err != nil {
+				innerError = fmt.Errorf("%w: %w", errGenericOutput, err)
+			}
+		})
+		if innerError != nil {
+			return innerError
+		}
+
+		if len(current) == 0 {
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Setting push url of %s: %s\n", name, push)
+		} else {
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Updating push url of %s: %s -> %s\n", name, strings.Join(current, ", "), push)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+
+		if f.Simulate {
+			continue
+		}
+		if err := environment.Git.SetPushURLs(cmd.Context(), repo, name, []string{push}); err != nil {
+			return fmt.Errorf("%q: %w", repo, err)
+		}
+	}
+	return nil
+}
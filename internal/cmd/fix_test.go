@@ -1,7 +1,10 @@
 package cmd_test
 
-//spellchecker:words testing github config ggman internal mockenv
+//spellchecker:words path filepath slices testing github config ggman internal mockenv
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/go-git/go-git/v5"
@@ -10,7 +13,7 @@ import (
 	"go.tkw01536.de/ggman/internal/mockenv"
 )
 
-//spellchecker:words GGROOT workdir tparallel paralleltest
+//spellchecker:words GGROOT workdir tparallel paralleltest CANFILE pushurl
 
 //nolint:tparallel,paralleltest
 func TestCommandFix(t *testing.T) {
@@ -156,3 +159,80 @@ func TestCommandFix_Prune(t *testing.T) {
 		})
 	}
 }
+
+//nolint:paralleltest
+func TestCommandFix_push(t *testing.T) {
+	mock := mockenv.NewMockEnv(t)
+
+	// fetch via https, but push via ssh
+	CANFILE := filepath.Join(t.TempDir(), "canfile")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte("^github.com https://^/$.git git@^:$.git\ngit@^:$.git\n"), 0600); err != nil {
+		panic(err)
+	}
+
+	mock.Register("https://github.com/hello/world.git")
+	repoPath := mock.Install(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+
+	mock.Register("git@gitlab.com:hello/world.git")
+	mock.Install(t.Context(), "git@gitlab.com:hello/world.git", "gitlab.com", "hello", "world")
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"simulate setting push urls",
+			[]string{"fix", "--simulate"},
+
+			0,
+			"Simulate fixing remote of \"${GGROOT github.com hello world}\"\nSetting push url of origin: git@github.com:hello/world.git\n",
+			"",
+		},
+		{
+			"set push urls",
+			[]string{"fix"},
+
+			0,
+			"Fixing remote of \"${GGROOT github.com hello world}\"\nSetting push url of origin: git@github.com:hello/world.git\n",
+			"",
+		},
+		{
+			"push urls already set",
+			[]string{"fix"},
+
+			0,
+			"",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+
+	// check that the push url was written to the config
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		panic(err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		panic(err)
+	}
+	got := cfg.Raw.Section("remote").Subsection("origin").Options.GetAll("pushurl")
+	if !slices.Equal(got, []string{"git@github.com:hello/world.git"}) {
+		t.Errorf("pushurl = %v, want %v", got, []string{"git@github.com:hello/world.git"})
+	}
+}
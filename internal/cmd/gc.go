@@ -0,0 +1,196 @@
+package cmd
+
+//spellchecker:words bytes errors strings tabwriter github cobra ggman internal pkglib exit sema stream
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/sema"
+	"go.tkw01536.de/pkglib/stream"
+)
+
+func NewGcCommand() *cobra.Command {
+	impl := new(gc)
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Collect garbage and repack objects of all repositories",
+		Long: `Gc removes unreachable objects from, and repacks the objects of, all repositories.
+Repositories are processed in parallel.
+
+When a native 'git' is available, 'git gc' is run in each repository.
+Otherwise, unreachable loose objects older than two weeks are removed and all reachable objects are repacked into a new pack, replacing any packs older than two weeks.
+Objects referenced by the index or a reflog, including those of linked worktrees, are kept.
+
+Once all repositories have been processed, the size of the '.git' directory of each repository before and after is printed, along with the totals.
+
+The '--register' flag registers all repositories for background maintenance using 'git maintenance register' instead.
+It requires a native 'git', and prints the path of each registered repository relative to the root directory.
+Maintenance of registered repositories only takes place once it has been scheduled, e.g. by running 'git maintenance start' in any repository.`,
+		Args: cobra.NoArgs,
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&impl.Register, "register", false, "register repositories for background maintenance instead of collecting garbage")
+	flags.IntVarP(&impl.Parallel, "parallel", "p", 4, "number of repositories to process in parallel, 0 for no limit")
+
+	return cmd
+}
+
+type gc struct {
+	Register bool
+	Parallel int
+}
+
+var (
+	errGcParallelNegative = exit.NewErrorWithCode(`argument for "--parallel" must be non-negative`, env.ExitCommandArguments)
+	errGcRegisterNoGit    = exit.NewErrorWithCode(`"--register" requires a native git`, env.ExitInvalidEnvironment)
+	errGcInterrupted      = exit.NewErrorWithCode("interrupted", env.ExitContext)
+	errGcFailed           = exit.NewErrorWithCode("failed to process repositories", env.ExitGeneric)
+)
+
+func (g *gc) ParseArgs(cmd *cobra.Command, args []string) error {
+	if g.Parallel < 0 {
+		return errGcParallelNegative
+	}
+	return nil
+}
+
+// gcResult holds the result of processing a single repository.
+type gcResult struct {
+	Before int64 // size of the git directory before collecting garbage
+	After  int64 // size of the git directory after collecting garbage
+	Err    error
+}
+
+func (g *gc) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		AllowsFilter: true,
+		NeedsRoot:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	if g.Register && environment.Git.GitPath() == "" {
+		return errGcRegisterNoGit
+	}
+
+	ctx := cmd.Context()
+
+	// process all repositories in parallel
+	repos := environment.Repos(ctx, true)
+	results := make([]gcResult, len(repos))
+	_ = sema.Schedule(func(i uint64) error {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			return nil
+		}
+		if g.Register {
+			results[i].Err = environment.Git.RegisterMaintenance(ctx, repos[i])
+			return nil
+		}
+		results[i] = g.collect(cmd, environment, repos[i])
+		return nil
+	}, uint64(len(repos)), sema.Concurrency{
+		Limit: g.Parallel,
+		Force: true,
+	})
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", errGcInterrupted, err)
+	}
+
+	var errs []error
+	for i, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", repos[i], result.Err))
+		}
+	}
+
+	if err := g.print(cmd.OutOrStdout(), environment, repos, results); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", errGcFailed, errors.Join(errs...))
+	}
+	return nil
+}
+
+// collect collects garbage in the repository at clonePath, and measures the size of its git directory before and after.
+func (g *gc) collect(cmd *cobra.Command, environment *env.Env, clonePath string) (result gcResult) {
+	ctx := cmd.Context()
+
+	result.Before, result.Err = environment.Git.GetGitSize(ctx, clonePath)
+	if result.Err != nil {
+		return
+	}
+
+	// capture any output, so that output of repositories processed in parallel does not interleave
+	var output bytes.Buffer
+	if err := environment.Git.GarbageCollect(ctx, stream.IOStream{Stdout: &output, Stderr: &output}, clonePath); err != nil {
+		if message := strings.TrimSpace(output.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		result.Err = err
+		return
+	}
+
+	result.After, result.Err = environment.Git.GetGitSize(ctx, clonePath)
+	return
+}
+
+// print prints the results of processing repos to w.
+// Repositories that could not be processed are omitted.
+func (g *gc) print(w io.Writer, environment *env.Env, repos []string, results []gcResult) error {
+	if g.Register {
+		for i, result := range results {
+			if result.Err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintln(w, relativeToRoot(environment, repos[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(table, "REPOSITORY\tBEFORE\tAFTER\tSAVED"); err != nil {
+		return err
+	}
+
+	var total gcResult
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		total.Before += result.Before
+		total.After += result.After
+
+		if err := printGcLine(table, relativeToRoot(environment, repos[i]), result); err != nil {
+			return err
+		}
+	}
+	if err := printGcLine(table, "TOTAL", total); err != nil {
+		return err
+	}
+	return table.Flush()
+}
+
+// printGcLine prints a single line of the gc table to table.
+func printGcLine(table io.Writer, name string, result gcResult) error {
+	_, err := fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", name, formatSize(result.Before), formatSize(result.After), formatSize(result.Before-result.After))
+	return err
+}
@@ -0,0 +1,144 @@
+package cmd_test
+
+//spellchecker:words context encoding json path filepath slices strings testing time ggman internal mockenv
+import (
+	"context"
+	"encoding/json/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+)
+
+//spellchecker:words workdir
+
+func TestCommandGc(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	github := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	commitFiles(t, github, map[string]string{
+		"hello.txt": "hello world\n",
+	})
+	gitlab := mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world")
+	mock.Clone(t.Context(), "user@server.com/repo", "server.com", "user", "repo")
+
+	// recent packs are kept, so make the packs created by cloning old enough to be replaced
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for _, clonePath := range []string{github, gitlab} {
+		packs, err := filepath.Glob(filepath.Join(clonePath, ".git", "objects", "pack", "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pack := range packs {
+			if err := os.Chtimes(pack, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "--for", "hello", "gc")
+	if code != 0 {
+		t.Fatalf("Code = %d, wantCode = 0, stderr = %q", code, stderr)
+	}
+	mock.AssertOutput(t, "Stderr", stderr, "")
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if got := strings.Fields(lines[0]); !slices.Equal(got, []string{"REPOSITORY", "BEFORE", "AFTER", "SAVED"}) {
+		t.Errorf("header = %q", lines[0])
+	}
+	names := make([]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		name, _, _ := strings.Cut(line, " ")
+		names = append(names, name)
+	}
+	if want := []string{"github.com/hello/world", "gitlab.com/hello/world", "TOTAL"}; !slices.Equal(names, want) {
+		t.Errorf("repositories = %v, want %v", names, want)
+	}
+
+	// all objects should have been packed into a single pack
+	code, stdout, stderr = mock.Run(t, nil, cmd.NewCommand, "", "", "stats", "--json")
+	if code != 0 {
+		t.Fatalf("Code = %d, wantCode = 0, stderr = %q", code, stderr)
+	}
+	var got statsJSON
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, repo := range got.Repos {
+		if repo.Objects != 0 || repo.Packs != 1 {
+			t.Errorf("repository %q: Objects = %d, Packs = %d, want 0 and 1", repo.Relative, repo.Objects, repo.Packs)
+		}
+	}
+	// and no commits should have been lost
+	if got.Total.Commits != 4 {
+		t.Errorf("Total.Commits = %d, want 4", got.Total.Commits)
+	}
+}
+
+func TestCommandGc_errors(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+
+	cancelled, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		args []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"register without native git",
+			nil,
+			[]string{"gc", "--register"},
+
+			5,
+			"",
+			"\"--register\" requires a native git\n",
+		},
+		{
+			"negative parallel",
+			nil,
+			[]string{"gc", "--parallel", "-1"},
+
+			4,
+			"",
+			"argument for \"--parallel\" must be non-negative\n",
+		},
+		{
+			"cancelled",
+			cancelled,
+			[]string{"gc"},
+
+			254,
+			"",
+			"interrupted: context canceled\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, tt.ctx, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+//spellchecker:words errors path filepath regexp github cobra ggman internal pkglib exit sema
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/ggman/internal/git"
+	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/sema"
+)
+
+//spellchecker:words pathspec pathspecs positionals
+
+func NewGrepCommand() *cobra.Command {
+	impl := new(grep)
+
+	cmd := &cobra.Command{
+		Use:   "grep PATTERN [PATHSPEC...]",
+		Short: "Search tracked files of all repositories",
+		Long: `Grep searches the tracked files of all repositories for lines matching an extended regular expression.
+
+Each matching line is printed as 'path:line:text', where path is the path of the file relative to the root directory containing the repository.
+For example, 'github.com/hello/world/main.go:12:func main() {'.
+Binary files are skipped.
+
+The pattern is a POSIX extended regular expression, e.g. '^(func|type) [[:upper:]]'.
+Perl-style syntax such as '\d', '\w', '\b', non-greedy repetition or flags like '(?i)' is rejected, as not every search backend supports it.
+Use bracket expressions such as '[[:digit:]]' and the '--ignore-case' flag instead.
+
+Repositories are searched in parallel.
+When a native 'git' is available, 'git grep' searches the working tree of each repository.
+Otherwise, the files of the commit currently checked out are searched.
+
+Additional arguments are pathspecs that limit the files being searched.
+Pathspecs are glob patterns where '*' also matches '/', e.g. '*.go' matches all go files.
+A pathspec naming a directory matches all files within it.
+
+The '--files-with-matches' flag prints only the paths of matching files.
+The '--ignore-case' flag searches case-insensitively.
+The '--exit-code' flag causes exit code 1 when nothing matches.`,
+		Args: cobra.MinimumNArgs(1),
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.FilesWithMatches, "files-with-matches", "l", false, "print only the paths of files containing a match")
+	flags.BoolVarP(&impl.IgnoreCase, "ignore-case", "i", false, "search case-insensitively")
+	flags.BoolVar(&impl.ExitCode, "exit-code", false, "exit with status code 1 when nothing matches")
+	flags.IntVarP(&impl.Parallel, "parallel", "p", 4, "number of repositories to search in parallel, 0 for no limit")
+
+	return cmd
+}
+
+type grep struct {
+	Positionals struct {
+		Pattern   string
+		Pathspecs []string
+	}
+
+	FilesWithMatches bool
+	IgnoreCase       bool
+	ExitCode         bool
+	Parallel         int
+}
+
+var (
+	errGrepNoMatches        = exit.NewErrorWithCode("", env.ExitGeneric)
+	errGrepInvalidPattern   = exit.NewErrorWithCode("invalid pattern", env.ExitCommandArguments)
+	errGrepParallelNegative = exit.NewErrorWithCode(`argument for "--parallel" must be non-negative`, env.ExitCommandArguments)
+	errGrepFailed           = exit.NewErrorWithCode("failed to search repositories", env.ExitGeneric)
+)
+
+func (g *grep) ParseArgs(cmd *cobra.Command, args []string) error {
+	if g.Parallel < 0 {
+		return errGrepParallelNegative
+	}
+
+	g.Positionals.Pattern = args[0]
+	g.Positionals.Pathspecs = args[1:]
+
+	if _, err := regexp.CompilePOSIX(g.Positionals.Pattern); err != nil {
+		return fmt.Errorf("%w: %w", errGrepInvalidPattern, err)
+	}
+	return nil
+}
+
+// grepResult holds the result of searching a single repository.
+type grepResult struct {
+	Matches []git.GrepMatch
+	Err     error
+}
+
+func (g *grep) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		AllowsFilter: true,
+		NeedsRoot:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	options := git.GrepOptions{
+		Pattern:    g.Positionals.Pattern,
+		IgnoreCase: g.IgnoreCase,
+		Pathspecs:  g.Positionals.Pathspecs,
+	}
+
+	// search all the repositories in parallel
+	repos := environment.Repos(cmd.Context(), true)
+	results := make([]grepResult, len(repos))
+	_ = sema.Schedule(func(i uint64) error {
+		results[i].Matches, results[i].Err = environment.Git.Grep(cmd.Context(), repos[i], options)
+		return nil
+	}, uint64(len(repos)), sema.Concurrency{
+		Limit: g.Parallel,
+		Force: true,
+	})
+
+	// print the results in order
+	var (
+		found bool
+		errs  []error
+	)
+	for i, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", repos[i], result.Err))
+			continue
+		}
+
+		relative := relativeToRoot(environment, repos[i])
+
+		var last string
+		for _, match := range result.Matches {
+			found = true
+
+			path := filepath.Join(relative, filepath.FromSlash(match.Path))
+			if g.FilesWithMatches {
+				if path == last {
+					continue
+				}
+				last = path
+
+				if _, err := fmt.Fprintln(cmd.OutOrStdout(), path); err != nil {
+					return fmt.Errorf("%w: %w", errGenericOutput, err)
+				}
+				continue
+			}
+
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s:%d:%s\n", path, match.Line, match.Text); err != nil {
+				return fmt.Errorf("%w: %w", errGenericOutput, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", errGrepFailed, errors.Join(errs...))
+	}
+
+	// if we have --exit-code set and no results
+	// we need to exit with an error code
+	if g.ExitCode && !found {
+		return errGrepNoMatches
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package cmd_test
+
+//spellchecker:words path filepath testing time github object ggman internal mockenv
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+)
+
+//spellchecker:words workdir GGROOT worktree nosec
+
+// commitFiles commits files with the given content into the repository at clonePath.
+func commitFiles(t *testing.T, clonePath string, files map[string]string) {
+	t.Helper()
+
+	repo, err := git.PlainOpen(clonePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(clonePath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := worktree.Commit("add files", &git.CommitOptions{
+		Author: &object.Signature{Name: "ggman", Email: "ggman@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandGrep(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	commitFiles(t, mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world"), map[string]string{
+		"main.go":   "package main\n\n// Hello world\nfunc main() {}\n",
+		"README.md": "# hello world\n",
+	})
+	commitFiles(t, mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world"), map[string]string{
+		"docs/index.md": "hello again\nand hello once more\n",
+	})
+	mock.Clone(t.Context(), "user@server.com/repo", "server.com", "user", "repo")
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"search all repositories",
+			"",
+			[]string{"grep", "hello"},
+
+			0,
+			"github.com/hello/world/README.md:1:# hello world\ngitlab.com/hello/world/docs/index.md:1:hello again\ngitlab.com/hello/world/docs/index.md:2:and hello once more\n",
+			"",
+		},
+		{
+			"search case-insensitively",
+			"",
+			[]string{"grep", "-i", "hello world"},
+
+			0,
+			"github.com/hello/world/README.md:1:# hello world\ngithub.com/hello/world/main.go:3:// Hello world\n",
+			"",
+		},
+		{
+			"print files with matches",
+			"",
+			[]string{"grep", "--files-with-matches", "hello"},
+
+			0,
+			"github.com/hello/world/README.md\ngitlab.com/hello/world/docs/index.md\n",
+			"",
+		},
+		{
+			"search with pathspec",
+			"",
+			[]string{"grep", "-i", "hello", "*.go", "docs"},
+
+			0,
+			"github.com/hello/world/main.go:3:// Hello world\ngitlab.com/hello/world/docs/index.md:1:hello again\ngitlab.com/hello/world/docs/index.md:2:and hello once more\n",
+			"",
+		},
+		{
+			"search filtered repositories",
+			"",
+			[]string{"--for", "gitlab.com", "grep", "once"},
+
+			0,
+			"gitlab.com/hello/world/docs/index.md:2:and hello once more\n",
+			"",
+		},
+		{
+			"no matches",
+			"",
+			[]string{"grep", "goodbye"},
+
+			0,
+			"",
+			"",
+		},
+		{
+			"no matches with exit code",
+			"",
+			[]string{"grep", "--exit-code", "goodbye"},
+
+			1,
+			"",
+			"",
+		},
+		{
+			"invalid pattern",
+			"",
+			[]string{"grep", "("},
+
+			4,
+			"",
+			"invalid pattern: error parsing regexp: missing closing ): `(`\n",
+		},
+		{
+			"perl syntax pattern",
+			"",
+			[]string{"grep", `\d+`},
+
+			4,
+			"",
+			"invalid pattern: error parsing regexp: invalid escape sequence: `\\d`\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
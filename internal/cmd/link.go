@@ -1,8 +1,11 @@
 package cmd
 
-//spellchecker:words path filepath github cobra ggman internal dirs pkglib exit
+//spellchecker:words context errors path filepath github cobra ggman internal dirs pkglib exit
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 
@@ -19,7 +22,7 @@ func NewLinkCommand() *cobra.Command {
 	impl := new(link)
 
 	cmd := &cobra.Command{
-		Use:   "link PATH",
+		Use:   "link [PATH]",
 		Short: "Symlink a repository into the local repository structure",
 		Long: `Link creates a symlink from the ggman-managed location to a repository at an external path.
 
@@ -31,13 +34,28 @@ For example
 
     ggman link ~/go/src/github.com/hello/world
 
-creates a symlink at '$GGROOT/github.com/hello/world' pointing to '~/go/src/github.com/hello/world'.`,
-		Args: cobra.ExactArgs(1),
+creates a symlink at '$GGROOT/github.com/hello/world' pointing to '~/go/src/github.com/hello/world'.
+
+By default the symlink contains an absolute path.
+The '--relative' flag creates a relative symlink instead.
+Relative symlinks continue to work when the home directory is moved.
+
+The '--list' flag lists all symlinks within '$GGROOT' along with their targets instead of creating a link.
+The '--check' flag verifies all symlinks within '$GGROOT' instead of creating a link.
+A symlink is reported if its target does not exist, or if its target is a repository whose location as per 'ggman where' is not the symlink.
+
+See 'ggman unlink' for removing links.`,
+		Args: cobra.MaximumNArgs(1),
 
 		PreRunE: impl.ParseArgs,
 		RunE:    impl.Exec,
 	}
 
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Relative, "relative", "r", false, "create a relative instead of an absolute symlink")
+	flags.BoolVarP(&impl.List, "list", "l", false, "list all symlinks and their targets instead of creating a link")
+	flags.BoolVarP(&impl.Check, "check", "c", false, "check all symlinks for dangling or retargeted links instead of creating a link")
+
 	return cmd
 }
 
@@ -45,6 +63,9 @@ type link struct {
 	Positionals struct {
 		Path string
 	}
+	Relative bool
+	List     bool
+	Check    bool
 }
 
 var (
@@ -53,10 +74,27 @@ var (
 	errLinkAlreadyExists = exit.NewErrorWithCode("failed to create link: another directory already exists in target location", env.ExitGeneric)
 	errLinkCheck         = exit.NewErrorWithCode("failed to check directory", env.ExitGeneric)
 	errLinkUnknown       = exit.NewErrorWithCode("failed to create link: unknown error", env.ExitGeneric)
+
+	errLinkModeArgs    = exit.NewErrorWithCode(`"--list" and "--check" may not be combined with each other or a path`, env.ExitCommandArguments)
+	errLinkMissingPath = exit.NewErrorWithCode("failed to create link: missing path", env.ExitCommandArguments)
+	errLinkCheckFailed = exit.NewErrorWithCode("failed to check links: found broken links", env.ExitGeneric)
 )
 
 func (l *link) ParseArgs(cmd *cobra.Command, args []string) error {
-	l.Positionals.Path = args[0]
+	if len(args) > 0 {
+		l.Positionals.Path = args[0]
+	}
+
+	if l.List || l.Check {
+		if (l.List && l.Check) || len(args) > 0 || l.Relative {
+			return errLinkModeArgs
+		}
+		return nil
+	}
+
+	if len(args) == 0 {
+		return errLinkMissingPath
+	}
 	return nil
 }
 
@@ -68,8 +106,19 @@ func (l *link) Exec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
 	}
 
-	// make sure that the path is absolute
-	// to avoid relative symlinks
+	switch {
+	case l.List:
+		return l.listLinks(cmd, environment)
+	case l.Check:
+		return l.checkLinks(cmd, environment)
+	default:
+		return l.createLink(cmd, environment)
+	}
+}
+
+func (l *link) createLink(cmd *cobra.Command, environment *env.Env) error {
+	// resolve the path to an absolute path.
+	// the symlink target is made relative below only if requested.
 	from, e := environment.Abs(l.Positionals.Path)
 	if e != nil {
 		return errLinkDoesNotExist
@@ -113,10 +162,140 @@ func (l *link) Exec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%w: %w", errLinkUnknown, e)
 	}
 
+	// use a relative target if requested
+	target := from
+	if l.Relative {
+		target, err = filepath.Rel(parentTo, from)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errLinkUnknown, err)
+		}
+	}
+
 	// and make the symlink
-	if e := os.Symlink(from, to); e != nil {
+	if e := os.Symlink(target, to); e != nil {
 		return fmt.Errorf("%w: %w", errLinkUnknown, e)
 	}
 
 	return nil
 }
+
+func (l *link) listLinks(cmd *cobra.Command, environment *env.Env) error {
+	links, err := findLinks(cmd.Context(), environment)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n", link.Path, link.Target); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+	}
+	return nil
+}
+
+func (l *link) checkLinks(cmd *cobra.Command, environment *env.Env) error {
+	links, err := findLinks(cmd.Context(), environment)
+	if err != nil {
+		return err
+	}
+
+	broken := false
+	for _, link := range links {
+		problem := checkLink(cmd, environment, link)
+		if problem == "" {
+			continue
+		}
+		broken = true
+
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s: %s\n", link.Path, link.Target, problem); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+	}
+
+	if broken {
+		return errLinkCheckFailed
+	}
+	return nil
+}
+
+// checkLink checks if a link is broken.
+// Returns a human-readable description of the problem, or an empty string if the link is fine.
+func checkLink(cmd *cobra.Command, environment *env.Env, link linkInfo) string {
+	if link.Dangling {
+		return "target does not exist"
+	}
+
+	remote, err := environment.Git.GetRemote(cmd.Context(), link.Path, "")
+	if err != nil || remote == "" {
+		// not a repository with a remote, so we can't tell where it belongs
+		return ""
+	}
+
+	shouldPath, err := environment.Local(env.ParseURL(remote))
+	if err != nil {
+		return fmt.Sprintf("failed to get local path: %s", err)
+	}
+	if filepath.Clean(shouldPath) != filepath.Clean(link.Path) {
+		return fmt.Sprintf("target has been retargeted and belongs at %q", shouldPath)
+	}
+	return ""
+}
+
+// linkInfo describes a symlink found within the root folder.
+type linkInfo struct {
+	Path     string // path of the symlink itself
+	Target   string // target the symlink points to
+	Dangling bool   // true if the target does not exist
+}
+
+var errLinkScan = exit.NewErrorWithCode("failed to scan for links", env.ExitGeneric)
+
+// findLinks finds all symlinks within the root directories of environment.
+// Repositories are not descended into.
+func findLinks(ctx context.Context, environment *env.Env) ([]linkInfo, error) {
+	var links []linkInfo
+	for _, root := range environment.Roots {
+		rootLinks, err := findLinksIn(ctx, environment, root)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, rootLinks...)
+	}
+	return links, nil
+}
+
+// findLinksIn finds all symlinks within root.
+// Repositories are not descended into.
+func findLinksIn(ctx context.Context, environment *env.Env, root string) ([]linkInfo, error) {
+	var links []linkInfo
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == root {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_, statErr := os.Stat(path)
+			links = append(links, linkInfo{Path: path, Target: target, Dangling: statErr != nil})
+			return nil
+		}
+
+		if d.IsDir() && path != root && environment.Git.IsRepositoryQuick(ctx, path) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errLinkScan, err)
+	}
+	return links, nil
+}
@@ -1,7 +1,9 @@
 package cmd_test
 
-//spellchecker:words strconv testing ggman internal mockenv
+//spellchecker:words path filepath strconv testing ggman internal mockenv
 import (
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -49,6 +51,96 @@ func TestCommandLink(t *testing.T) {
 			"",
 			"failed to create link: another directory already exists in target location\n",
 		},
+
+		{
+			"Listing links",
+			"",
+			[]string{"link", "--list"},
+
+			0,
+			"${GGROOT github.com hello world} -> " + externalRepo + "\n",
+			"",
+		},
+
+		{
+			"Checking links",
+			"",
+			[]string{"link", "--check"},
+
+			0,
+			"",
+			"",
+		},
+
+		{
+			"Unlinking external repo",
+			externalRepo,
+			[]string{"unlink", "."},
+
+			0,
+			"Unlinking \"${GGROOT github.com hello world}\" -> " + escapedExternalRepo + "\n",
+			"",
+		},
+
+		{
+			"Linking external repo relatively",
+			externalRepo,
+			[]string{"link", "--relative", "."},
+
+			0,
+			"Linking \"${GGROOT github.com hello world}\" -> " + escapedExternalRepo + "\n",
+			"",
+		},
+
+		{
+			"Listing relative links",
+			"",
+			[]string{"link", "--list"},
+
+			0,
+			"${GGROOT github.com hello world} -> " + filepath.Join("..", "..", "..", "external") + "\n",
+			"",
+		},
+
+		{
+			"Unlinking link",
+			"",
+			[]string{"unlink", mock.Resolve("github.com", "hello", "world")},
+
+			0,
+			"Unlinking \"${GGROOT github.com hello world}\" -> " + strconv.Quote(filepath.Join("..", "..", "..", "external")) + "\n",
+			"",
+		},
+
+		{
+			"Listing no links",
+			"",
+			[]string{"link", "--list"},
+
+			0,
+			"",
+			"",
+		},
+
+		{
+			"Unlinking non-link",
+			externalRepo,
+			[]string{"unlink", "."},
+
+			1,
+			"",
+			"\"" + externalRepo + "\": failed to find link: path is neither a link nor linked to\n",
+		},
+
+		{
+			"Linking without path",
+			"",
+			[]string{"link"},
+
+			4,
+			"",
+			"failed to create link: missing path\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -62,3 +154,31 @@ func TestCommandLink(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandLink_check(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	// a repository linked to the wrong location
+	externalRepo := mock.Clone(t.Context(), "https://github.com/hello/world.git", "..", "external")
+	if err := os.MkdirAll(mock.Resolve("github.com", "hello"), 0750); err != nil {
+		panic(err)
+	}
+	if err := os.Symlink(externalRepo, mock.Resolve("github.com", "hello", "mars")); err != nil {
+		panic(err)
+	}
+
+	// a dangling link
+	if err := os.Symlink(mock.Resolve("nowhere"), mock.Resolve("github.com", "dangling")); err != nil {
+		panic(err)
+	}
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "link", "--check")
+	if code != 1 {
+		t.Errorf("Code = %d, wantCode = %d", code, 1)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "${GGROOT github.com dangling} -> ${GGROOT nowhere}: target does not exist\n"+
+		"${GGROOT github.com hello mars} -> "+externalRepo+": target has been retargeted and belongs at \"${GGROOT github.com hello world}\"\n")
+	mock.AssertOutput(t, "Stderr", stderr, "failed to check links: found broken links\n")
+}
@@ -0,0 +1,401 @@
+package cmd
+
+//spellchecker:words encoding json jsontext errors path filepath regexp slices strconv strings tabwriter time github config cobra ggman internal pkglib exit sema
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/ggman/internal/git"
+	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/sema"
+)
+
+//spellchecker:words nosec
+
+func NewLogCommand() *cobra.Command {
+	impl := new(logCmd)
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Print a chronological list of commits across all repositories",
+		Long: `Log prints the commits of all repositories as a single chronological list, oldest commit first.
+
+Each line contains the date the commit was authored, the path of the repository relative to the root directory, the abbreviated commit hash, the name of the author and the subject of the commit.
+By default, only commits reachable from HEAD are considered.
+The '--all' flag considers commits reachable from any branch or tag instead.
+
+The '--since' and '--until' flags restrict commits to those authored in the given time range.
+They accept 'now', 'today', 'yesterday', a date such as '2006-01-02', a date and time such as '2006-01-02 15:04' or '2006-01-02T15:04:05Z07:00', or a duration such as '36h', '3d' or '2w' before now.
+Dates without a time refer to the start of the day.
+The range includes '--since' but excludes '--until', so '--since yesterday --until today' prints the commits of yesterday.
+
+The '--author' flag restricts commits to those whose 'Name <email>' matches a regular expression.
+The special value 'me' matches the 'user.email' configured for each repository.
+When a native 'git' is available, it is read using 'git config', respecting all configuration files git uses.
+Otherwise, the local configuration of each repository and the global git configuration are read.
+
+The '--by-day' flag groups commits by the day they were authored.
+The '--json' flag prints commits as JSON instead.`,
+		Args: cobra.NoArgs,
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&impl.Since, "since", "", "only print commits authored at or after this time")
+	flags.StringVar(&impl.Until, "until", "", "only print commits authored before this time")
+	flags.StringVar(&impl.Author, "author", "", "only print commits whose author matches this regular expression, 'me' for the current git user")
+	flags.BoolVarP(&impl.All, "all", "a", false, "consider commits reachable from any branch or tag, not just HEAD")
+	flags.BoolVar(&impl.ByDay, "by-day", false, "group commits by the day they were authored")
+	flags.BoolVar(&impl.JSON, "json", false, "print commits as JSON")
+	flags.IntVarP(&impl.Parallel, "parallel", "p", 4, "number of repositories to read in parallel, 0 for no limit")
+
+	return cmd
+}
+
+type logCmd struct {
+	Since    string
+	Until    string
+	Author   string
+	All      bool
+	ByDay    bool
+	JSON     bool
+	Parallel int
+
+	options git.LogOptions
+}
+
+// logAuthorMe is the special value of '--author' that matches the current git user.
+const logAuthorMe = "me"
+
+var (
+	errLogInvalidTime      = exit.NewErrorWithCode("invalid time", env.ExitCommandArguments)
+	errLogInvalidAuthor    = exit.NewErrorWithCode("invalid author pattern", env.ExitCommandArguments)
+	errLogParallelNegative = exit.NewErrorWithCode(`argument for "--parallel" must be non-negative`, env.ExitCommandArguments)
+	errLogNoIdentity       = errors.New(`unable to determine git user for "--author me": "user.email" is not set`)
+	errLogFailed           = exit.NewErrorWithCode("failed to read commits", env.ExitGeneric)
+)
+
+func (l *logCmd) ParseArgs(cmd *cobra.Command, args []string) (err error) {
+	if l.Parallel < 0 {
+		return errLogParallelNegative
+	}
+
+	now := time.Now()
+	if l.options.Since, err = parseLogTime(l.Since, now); err != nil {
+		return fmt.Errorf("%w %q: %w", errLogInvalidTime, l.Since, err)
+	}
+	if l.options.Until, err = parseLogTime(l.Until, now); err != nil {
+		return fmt.Errorf("%w %q: %w", errLogInvalidTime, l.Until, err)
+	}
+
+	if l.Author != logAuthorMe {
+		if _, err := regexp.Compile(l.Author); err != nil {
+			return fmt.Errorf("%w: %w", errLogInvalidAuthor, err)
+		}
+		l.options.Author = l.Author
+	}
+	l.options.All = l.All
+
+	return nil
+}
+
+// logDurationUnits are the units of durations accepted by parseLogTime in addition to those of [time.ParseDuration].
+var logDurationUnits = map[string]int{
+	"d": 1,
+	"w": 7,
+}
+
+// logTimeLayouts are the layouts of absolute times accepted by parseLogTime.
+var logTimeLayouts = []string{
+	time.DateOnly,
+	"2006-01-02 15:04",
+	time.DateTime,
+	time.RFC3339,
+}
+
+// parseLogTime parses value as a time relative to now.
+// The empty string is parsed as the zero time.
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch value {
+	case "":
+		return time.Time{}, nil
+	case "now":
+		return now, nil
+	case "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	}
+
+	// a number of days or weeks before now
+	for unit, days := range logDurationUnits {
+		count, ok := strings.CutSuffix(value, unit)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(count); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n*days), nil
+		}
+	}
+
+	// any other duration before now
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	for _, layout := range logTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New("expected a date, a duration, 'now', 'today' or 'yesterday'")
+}
+
+// gitUserEmail returns the 'user.email' set in the global git configuration of the user with the given home directory.
+// If it is not set, returns the empty string.
+func gitUserEmail(home string) (string, error) {
+	if home == "" {
+		return "", nil
+	}
+
+	for _, path := range []string{
+		filepath.Join(home, ".gitconfig"),
+		filepath.Join(home, ".config", "git", "config"),
+	} {
+		email, err := readUserEmail(path)
+		if err != nil {
+			return "", err
+		}
+		if email != "" {
+			return email, nil
+		}
+	}
+	return "", nil
+}
+
+// readUserEmail reads 'user.email' from the git config file at path.
+// A file that does not exist is treated as empty.
+func readUserEmail(path string) (email string, err error) {
+	file, err := os.Open(path) // #nosec G304 -- path is the users' git configuration
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer func() {
+		if eClose := file.Close(); eClose != nil && err == nil {
+			err = fmt.Errorf("failed to close %q: %w", path, eClose)
+		}
+	}()
+
+	cfg, err := config.ReadConfig(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	return cfg.User.Email, nil
+}
+
+// logEntry is a single commit printed by log.
+type logEntry struct {
+	Path     string
+	Relative string
+
+	Hash    string
+	Author  string
+	Email   string
+	Date    time.Time
+	Subject string
+}
+
+// logDay holds the commits authored on a single day, as printed by '--by-day --json'.
+type logDay struct {
+	Day     string
+	Commits []logEntry
+}
+
+// logResult holds the result of reading the commits of a single repository.
+type logResult struct {
+	Commits []git.Commit
+	Err     error
+}
+
+func (l *logCmd) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		AllowsFilter: true,
+		NeedsRoot:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	// the global identity is only used for repositories without a configured one
+	var globalEmail string
+	if l.Author == logAuthorMe && environment.Git.GitPath() == "" {
+		globalEmail, err = gitUserEmail(environment.Vars.HOME)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+		}
+	}
+
+	// read the commits of all repositories in parallel
+	repos := environment.Repos(cmd.Context(), true)
+	results := make([]logResult, len(repos))
+	_ = sema.Schedule(func(i uint64) error {
+		options, err := l.repoOptions(cmd, environment, repos[i], globalEmail)
+		if err != nil {
+			results[i].Err = err
+			return nil
+		}
+
+		results[i].Err = environment.Git.Log(cmd.Context(), repos[i], options, func(commit git.Commit) bool {
+			results[i].Commits = append(results[i].Commits, commit)
+			return true
+		})
+		return nil
+	}, uint64(len(repos)), sema.Concurrency{
+		Limit: l.Parallel,
+		Force: true,
+	})
+
+	// merge the commits into a single list
+	entries := make([]logEntry, 0)
+	var errs []error
+	for i, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", repos[i], result.Err))
+			continue
+		}
+
+		relative := relativeToRoot(environment, repos[i])
+		for _, commit := range result.Commits {
+			entries = append(entries, logEntry{
+				Path:     repos[i],
+				Relative: relative,
+
+				Hash:    commit.Hash,
+				Author:  commit.Author,
+				Email:   commit.Email,
+				Date:    commit.Date.Local(),
+				Subject: commit.Subject,
+			})
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b logEntry) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	if err := l.print(cmd.OutOrStdout(), entries); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", errLogFailed, errors.Join(errs...))
+	}
+	return nil
+}
+
+// repoOptions returns the options to read the commits of the repository at clonePath with.
+// When '--author me' is given, the author is set to the 'user.email' of the repository, falling back to globalEmail.
+func (l *logCmd) repoOptions(cmd *cobra.Command, environment *env.Env, clonePath string, globalEmail string) (git.LogOptions, error) {
+	options := l.options
+	if l.Author != logAuthorMe {
+		return options, nil
+	}
+
+	email, err := environment.Git.GetConfig(cmd.Context(), clonePath, "user.email")
+	if err != nil {
+		return options, err
+	}
+	if email == "" {
+		email = globalEmail
+	}
+	if email == "" {
+		return options, errLogNoIdentity
+	}
+
+	options.Author = "(?i)" + regexp.QuoteMeta("<"+email+">")
+	return options, nil
+}
+
+// logShortHash is the number of characters of abbreviated commit hashes.
+const logShortHash = 7
+
+// print prints entries to w in the format selected by the flags.
+func (l *logCmd) print(w io.Writer, entries []logEntry) error {
+	if l.JSON {
+		var value any = entries
+		if l.ByDay {
+			value = groupLogEntries(entries)
+		}
+		if err := json.MarshalWrite(w, value, jsontext.WithIndent("  ")); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if l.ByDay {
+		for i, day := range groupLogEntries(entries) {
+			if i > 0 {
+				if _, err := fmt.Fprintln(table); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintln(table, day.Day); err != nil {
+				return err
+			}
+			for _, entry := range day.Commits {
+				if err := printLogEntry(table, "  ", entry.Date.Format("15:04"), entry); err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		for _, entry := range entries {
+			if err := printLogEntry(table, "", entry.Date.Format("2006-01-02 15:04"), entry); err != nil {
+				return err
+			}
+		}
+	}
+	return table.Flush()
+}
+
+// printLogEntry prints a single line describing entry to table.
+func printLogEntry(table io.Writer, indent, date string, entry logEntry) error {
+	_, err := fmt.Fprintf(table, "%s%s\t%s\t%s\t%s\t%s\n", indent, date, entry.Relative, entry.Hash[:min(logShortHash, len(entry.Hash))], entry.Author, entry.Subject)
+	return err
+}
+
+// groupLogEntries groups sorted entries by the day they were authored.
+func groupLogEntries(entries []logEntry) []logDay {
+	days := make([]logDay, 0)
+	for _, entry := range entries {
+		day := entry.Date.Format(time.DateOnly)
+		if len(days) == 0 || days[len(days)-1].Day != day {
+			days = append(days, logDay{Day: day})
+		}
+		days[len(days)-1].Commits = append(days[len(days)-1].Commits, entry)
+	}
+	return days
+}
@@ -0,0 +1,49 @@
+package cmd
+
+//spellchecker:words testing time
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseLogTime(t *testing.T) {
+	t.Parallel()
+
+	location := time.FixedZone("test", 2*60*60)
+	now := time.Date(2020, time.March, 10, 15, 30, 0, 0, location)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"now", now, false},
+		{"today", time.Date(2020, time.March, 10, 0, 0, 0, 0, location), false},
+		{"yesterday", time.Date(2020, time.March, 9, 0, 0, 0, 0, location), false},
+		{"3d", time.Date(2020, time.March, 7, 15, 30, 0, 0, location), false},
+		{"2w", time.Date(2020, time.February, 25, 15, 30, 0, 0, location), false},
+		{"36h", time.Date(2020, time.March, 9, 3, 30, 0, 0, location), false},
+		{"2020-01-02", time.Date(2020, time.January, 2, 0, 0, 0, 0, location), false},
+		{"2020-01-02 12:34", time.Date(2020, time.January, 2, 12, 34, 0, 0, location), false},
+		{"2020-01-02 12:34:56", time.Date(2020, time.January, 2, 12, 34, 56, 0, location), false},
+		{"2020-01-02T12:34:56Z", time.Date(2020, time.January, 2, 12, 34, 56, 0, time.UTC), false},
+		{"last week", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+		{"2020-13-01", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseLogTime(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLogTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseLogTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
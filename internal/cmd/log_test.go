@@ -0,0 +1,340 @@
+package cmd_test
+
+//spellchecker:words path filepath testing time github plumbing object ggman internal mockenv
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+)
+
+//spellchecker:words workdir GGROOT worktree nosec gitconfig
+
+// commitAt creates an empty commit in the repository at clonePath and returns its abbreviated hash.
+// When branch is non-empty, the commit is created on a new branch with that name, and the previous branch is checked out again afterwards.
+func commitAt(t *testing.T, clonePath, branch, message, author string, when time.Time) string {
+	t.Helper()
+
+	repo, err := git.PlainOpen(clonePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if branch != "" {
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: true}); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()}); err != nil {
+				t.Fatal(err)
+			}
+		}()
+	}
+
+	hash, err := worktree.Commit(message, &git.CommitOptions{
+		Author:            &object.Signature{Name: author, Email: author + "@example.com", When: when},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash.String()[:7]
+}
+
+func TestCommandLog(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	day := func(d, hour int) time.Time {
+		return time.Date(2020, time.January, d, hour, 0, 0, 0, time.Local)
+	}
+
+	github := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	gitlab := mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world")
+
+	first := commitAt(t, github, "", "first commit", "alice", day(1, 10))
+	second := commitAt(t, gitlab, "", "second commit\n\nwith a body", "bob", day(1, 12))
+	third := commitAt(t, github, "", "third commit", "alice", day(2, 9))
+	feature := commitAt(t, gitlab, "feature", "feature commit", "alice", day(2, 11))
+
+	if err := os.WriteFile(filepath.Join(mock.Resolve(), ".gitconfig"), []byte("[user]\n\tname = Bob\n\temail = Bob@example.com\n"), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+		t.Fatal(err)
+	}
+
+	// only consider the commits above
+	inRange := []string{"--since", "2020-01-01", "--until", "2020-01-03"}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"commits of all repositories",
+			"",
+			append([]string{"log"}, inRange...),
+
+			0,
+			fmt.Sprintf(
+				"2020-01-01 10:00  github.com/hello/world  %s  alice  first commit\n"+
+					"2020-01-01 12:00  gitlab.com/hello/world  %s  bob    second commit\n"+
+					"2020-01-02 09:00  github.com/hello/world  %s  alice  third commit\n",
+				first, second, third,
+			),
+			"",
+		},
+		{
+			"commits of all branches",
+			"",
+			append([]string{"log", "--all"}, inRange...),
+
+			0,
+			fmt.Sprintf(
+				"2020-01-01 10:00  github.com/hello/world  %s  alice  first commit\n"+
+					"2020-01-01 12:00  gitlab.com/hello/world  %s  bob    second commit\n"+
+					"2020-01-02 09:00  github.com/hello/world  %s  alice  third commit\n"+
+					"2020-01-02 11:00  gitlab.com/hello/world  %s  alice  feature commit\n",
+				first, second, third, feature,
+			),
+			"",
+		},
+		{
+			"commits in time range",
+			"",
+			[]string{"log", "--since", "2020-01-01 11:00", "--until", "2020-01-02"},
+
+			0,
+			fmt.Sprintf("2020-01-01 12:00  gitlab.com/hello/world  %s  bob  second commit\n", second),
+			"",
+		},
+		{
+			"commits of filtered repositories",
+			"",
+			append([]string{"--for", "github.com", "log"}, inRange...),
+
+			0,
+			fmt.Sprintf(
+				"2020-01-01 10:00  github.com/hello/world  %s  alice  first commit\n"+
+					"2020-01-02 09:00  github.com/hello/world  %s  alice  third commit\n",
+				first, third,
+			),
+			"",
+		},
+		{
+			"commits by author",
+			"",
+			append([]string{"log", "--all", "--author", "^alice"}, inRange...),
+
+			0,
+			fmt.Sprintf(
+				"2020-01-01 10:00  github.com/hello/world  %s  alice  first commit\n"+
+					"2020-01-02 09:00  github.com/hello/world  %s  alice  third commit\n"+
+					"2020-01-02 11:00  gitlab.com/hello/world  %s  alice  feature commit\n",
+				first, third, feature,
+			),
+			"",
+		},
+		{
+			"commits by current user",
+			"",
+			append([]string{"log", "--author", "me"}, inRange...),
+
+			0,
+			fmt.Sprintf("2020-01-01 12:00  gitlab.com/hello/world  %s  bob  second commit\n", second),
+			"",
+		},
+		{
+			"commits grouped by day",
+			"",
+			append([]string{"log", "--by-day"}, inRange...),
+
+			0,
+			fmt.Sprintf(
+				"2020-01-01\n"+
+					"  10:00  github.com/hello/world  %s  alice  first commit\n"+
+					"  12:00  gitlab.com/hello/world  %s  bob    second commit\n"+
+					"\n"+
+					"2020-01-02\n"+
+					"  09:00  github.com/hello/world  %s  alice  third commit\n",
+				first, second, third,
+			),
+			"",
+		},
+		{
+			"no commits",
+			"",
+			[]string{"log", "--since", "2019-01-01", "--until", "2019-01-02"},
+
+			0,
+			"",
+			"",
+		},
+		{
+			"no commits as json",
+			"",
+			[]string{"log", "--json", "--since", "2019-01-01", "--until", "2019-01-02"},
+
+			0,
+			"[]\n",
+			"",
+		},
+		{
+			"invalid time",
+			"",
+			[]string{"log", "--since", "soon"},
+
+			4,
+			"",
+			"invalid time \"soon\": expected a date, a duration, 'now', 'today' or 'yesterday'\n",
+		},
+		{
+			"invalid author",
+			"",
+			[]string{"log", "--author", "("},
+
+			4,
+			"",
+			"invalid author pattern: error parsing regexp: missing closing ): `(`\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandLog_json(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	when := time.Date(2020, time.January, 1, 10, 0, 0, 0, time.Local)
+
+	clonePath := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	commitAt(t, clonePath, "", "first commit", "alice", when)
+
+	repo, err := git.PlainOpen(clonePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// entry is the json representation of the commit, with each line indented by indent
+	entry := func(indent string) string {
+		return fmt.Sprintf(`{
+%[1]s  "Path": "${GGROOT github.com hello world}",
+%[1]s  "Relative": "github.com/hello/world",
+%[1]s  "Hash": %[2]q,
+%[1]s  "Author": "alice",
+%[1]s  "Email": "alice@example.com",
+%[1]s  "Date": %[3]q,
+%[1]s  "Subject": "first commit"
+%[1]s}`, indent, head.Hash().String(), when.Format(time.RFC3339Nano))
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+	}{
+		{
+			"list",
+			[]string{"log", "--json", "--since", "2020-01-01", "--until", "2020-01-02"},
+			"[\n  " + entry("  ") + "\n]\n",
+		},
+		{
+			"grouped by day",
+			[]string{"log", "--json", "--by-day", "--since", "2020-01-01", "--until", "2020-01-02"},
+			"[\n  {\n    \"Day\": \"2020-01-01\",\n    \"Commits\": [\n      " + entry("      ") + "\n    ]\n  }\n]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != 0 {
+				t.Errorf("Code = %d, wantCode = 0", code)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, "")
+		})
+	}
+}
+
+func TestCommandLog_authorMe(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	when := time.Date(2020, time.January, 1, 10, 0, 0, 0, time.Local)
+
+	github := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	gitlab := mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world")
+
+	alice := commitAt(t, github, "", "commit by alice", "alice", when)
+	commitAt(t, github, "", "commit by bob", "bob", when.Add(time.Hour))
+	commitAt(t, gitlab, "", "commit by alice", "alice", when.Add(2*time.Hour))
+	bob := commitAt(t, gitlab, "", "commit by bob", "bob", when.Add(3*time.Hour))
+
+	// the local identity of a repository takes precedence over the global one
+	repo, err := git.PlainOpen(github)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Email = "alice@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mock.Resolve(), ".gitconfig"), []byte("[user]\n\temail = bob@example.com\n"), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "log", "--author", "me", "--since", "2020-01-01", "--until", "2020-01-02")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, fmt.Sprintf(
+		"2020-01-01 10:00  github.com/hello/world  %s  alice  commit by alice\n"+
+			"2020-01-01 13:00  gitlab.com/hello/world  %s  bob    commit by bob\n",
+		alice, bob,
+	))
+	mock.AssertOutput(t, "Stderr", stderr, "")
+}
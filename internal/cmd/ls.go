@@ -13,9 +13,10 @@ import (
 	"go.tkw01536.de/ggman/internal/env"
 	"go.tkw01536.de/pkglib/collection"
 	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/fsx"
 )
 
-//spellchecker:words wrapcheck wrld fnmatch GGROOT canonicalized
+//spellchecker:words wrapcheck wrld fnmatch GGROOT GGSCAN canonicalized
 
 func NewLsCommand() *cobra.Command {
 	impl := new(ls)
@@ -24,16 +25,19 @@ func NewLsCommand() *cobra.Command {
 		Use:   "ls",
 		Short: "List local paths of cloned repositories",
 		Long: `Ls lists all repositories found within the '$GGROOT' directory to standard output.
+When '$GGROOT' contains several directories, all of them are searched.
+Additional directories searched for repositories, but never cloned into, can be listed in '$GGSCAN', e.g. '~/go/src'.
 
 The '--exit-code' flag causes exit code 0 when at least one repository is found, and exit code 1 otherwise.
 The '--count' flag limits output to at most the specified number of repositories.
 The '--one' flag is equivalent to '--count 1' and limits output to at most one repository.
 
-The '--relative' flag prints paths relative to '$GGROOT' instead of absolute paths.
+The '--relative' flag prints paths relative to the root directory containing them instead of absolute paths.
 The '--remote' flag prints remote URLs instead of local paths.
 The '--canonical' flag prints canonicalized remote URLs instead of the original ones.
 
 The '--scores' flag shows filtering scores in addition to any paths in the output.
+The '--archived' flag lists repositories in '$GGARCHIVE' instead of '$GGROOT', see 'ggman archive'.
 
 By default, output consists of one repository (and possibly score) per line.
 The '--json' flag outputs JSON instead of plain text.
@@ -90,6 +94,7 @@ The filter flags work with other multi-repository commands such as 'ggman pull'
 	flags.BoolVarP(&impl.Relative, "relative", "l", false, "compute relative paths instead of absolute ones")
 	flags.BoolVarP(&impl.Remote, "remote", "r", false, "gather remote URLs instead of local ones")
 	flags.BoolVarP(&impl.Canonical, "canonical", "c", false, "gather canonicalized remote URLs")
+	flags.BoolVarP(&impl.Archived, "archived", "a", false, "list archived repositories instead of active ones")
 	flags.BoolVarP(&impl.JSON, "json", "j", false, "output JSON")
 	flags.BoolVarP(&impl.Export, "export", "x", false, `generate a bash script to re-clone repositories. Implies "--remote" and "--relative"`)
 
@@ -104,6 +109,7 @@ type ls struct {
 	Limit  int
 
 	Relative bool
+	Archived bool
 
 	Remote    bool
 	Canonical bool
@@ -115,6 +121,8 @@ type ls struct {
 var (
 	errLSExitFlag                = exit.NewErrorWithCode("", env.ExitGeneric)
 	errLsInvalidCanfile          = exit.NewErrorWithCode("failed to parse CANFILE", env.ExitInvalidEnvironment)
+	errLsInvalidArchive          = exit.NewErrorWithCode("failed to load archive", env.ExitInvalidEnvironment)
+	errLsScan                    = exit.NewErrorWithCode("failed to scan for repositories", env.ExitGeneric)
 	errLsOnlyOneOfOneAndLimit    = exit.NewErrorWithCode(`only one of "--one" and "--count" may be provided`, env.ExitCommandArguments)
 	errLsLimitNegative           = exit.NewErrorWithCode(`"--count" may not be negative`, env.ExitCommandArguments)
 	errLsCanonicalOnlyWithRemote = exit.NewErrorWithCode(`"--canonical" may only be used with "--remote"`, env.ExitCommandArguments)
@@ -277,7 +285,27 @@ func (l *ls) getRepositories(cmd *cobra.Command, environment *env.Env) ([]Repo,
 	}
 
 	// list all the repositories.
-	repos, scores := environment.RepoScores(cmd.Context(), true)
+	// an empty base scans all roots, and makes paths relative to the root they are in.
+	var base string
+	if l.Archived {
+		if err := environment.LoadDefaultArchive(); err != nil {
+			return nil, fmt.Errorf("%w: %w", errLsInvalidArchive, err)
+		}
+		base = environment.Archive
+	}
+
+	var repos []string
+	var scores []float64
+	if base == "" {
+		var err error
+		if repos, scores, err = environment.ScanReposScores(cmd.Context(), "", true); err != nil {
+			return nil, fmt.Errorf("%w: %w", errLsScan, err)
+		}
+	} else if exists, err := fsx.IsDirectory(base, true); err == nil && exists {
+		if repos, scores, err = environment.ScanReposScores(cmd.Context(), base, true); err != nil {
+			return nil, fmt.Errorf("%w: %w", errLsScan, err)
+		}
+	}
 	if l.Limit > 0 && len(repos) > l.Limit {
 		repos = repos[:l.Limit]
 		scores = scores[:l.Limit]
@@ -289,7 +317,7 @@ func (l *ls) getRepositories(cmd *cobra.Command, environment *env.Env) ([]Repo,
 	var wg sync.WaitGroup
 	for i, path := range repos {
 		wg.Go(func() {
-			infos[i] = l.getRepository(cmd, environment, path, scores[i], canFile)
+			infos[i] = l.getRepository(cmd, environment, base, path, scores[i], canFile)
 		})
 	}
 	wg.Wait()
@@ -300,7 +328,9 @@ func (l *ls) getRepositories(cmd *cobra.Command, environment *env.Env) ([]Repo,
 }
 
 // getRepository returns information about a single repository in accordance with flags.
-func (ls *ls) getRepository(cmd *cobra.Command, environment *env.Env, path string, score float64, canFile env.CanFile) (r Repo) {
+// base is the folder relative paths are computed against.
+// When base is empty, relative paths are computed against the root containing the repository.
+func (ls *ls) getRepository(cmd *cobra.Command, environment *env.Env, base, path string, score float64, canFile env.CanFile) (r Repo) {
 	r.Path = path
 	r.Score = score
 
@@ -318,7 +348,13 @@ func (ls *ls) getRepository(cmd *cobra.Command, environment *env.Env, path strin
 	if ls.Relative {
 		var err error
 
-		r.Relative, err = filepath.Rel(environment.Root, path)
+		if base == "" {
+			base = environment.Root
+			if root, _, ok := environment.RootOf(path); ok {
+				base = root
+			}
+		}
+		r.Relative, err = filepath.Rel(base, path)
 		if err != nil {
 			return Repo{valid: false}
 		}
@@ -1006,3 +1006,117 @@ func TestCommandLsExport(t *testing.T) {
 		t.Errorf("Stderr = %q, want empty", stderr)
 	}
 }
+
+func TestCommandLs_alias(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[alias]
+"gh:" = "git@github.com:"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world")
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "--for", "gh:hello", "ls")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "${GGROOT github.com hello world}\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+}
+
+func TestCommandLs_invalidCanfile(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte("not valid toml"), 0600); err != nil {
+		panic(err)
+	}
+
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world")
+
+	// filtering does not need the CANFILE
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "--for", "github.com", "ls")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "${GGROOT github.com hello world}\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+}
+
+func TestCommandLs_roots(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	mock.SetRoots(mock.Resolve("..", "work"))
+	mock.SetScan(mock.Resolve("..", "scan"))
+
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	mock.Clone(t.Context(), "https://gitlab.work.com/hello/world.git", "..", "work", "gitlab.work.com", "hello", "world")
+	mock.Clone(t.Context(), "https://github.com/go/tool.git", "..", "scan", "github.com", "go", "tool")
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"lists repositories in all roots",
+			"",
+			[]string{"ls"},
+
+			0,
+			"${GGROOT github.com hello world}\n${GGROOT .. scan github.com go tool}\n${GGROOT .. work gitlab.work.com hello world}\n",
+			"",
+		},
+		{
+			"lists paths relative to their roots",
+			"",
+			[]string{"ls", "--relative"},
+
+			0,
+			filepath.Join("github.com", "hello", "world") + "\n" + filepath.Join("github.com", "go", "tool") + "\n" + filepath.Join("gitlab.work.com", "hello", "world") + "\n",
+			"",
+		},
+		{
+			"filters repositories in scan-only roots",
+			"",
+			[]string{"--for", "go/tool", "ls"},
+
+			0,
+			"${GGROOT .. scan github.com go tool}\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package cmd
 
-//spellchecker:words errors path filepath essio shellescape github cobra ggman internal dirs pkglib exit
+//spellchecker:words encoding json jsontext errors maps path filepath slices strconv essio shellescape github cobra ggman internal dirs pkglib exit
 import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 
 	"al.essio.dev/pkg/shellescape"
 	"github.com/spf13/cobra"
@@ -16,7 +21,7 @@ import (
 	"go.tkw01536.de/pkglib/fsx"
 )
 
-//spellchecker:words wrapcheck
+//spellchecker:words wrapcheck sfn GGROOT GGSCAN CANFILE
 
 func NewRelocateCommand() *cobra.Command {
 	impl := new(relocate)
@@ -27,15 +32,44 @@ func NewRelocateCommand() *cobra.Command {
 		Long: `Relocate moves repositories to their canonical locations as determined by 'ggman where'.
 
 This is useful for migrating repositories into ggman's directory structure.
-It also handles repositories whose remote URL has changed.`,
+It also handles repositories whose remote URL has changed.
+
+Before moving anything, relocate plans all moves and detects conflicts.
+A conflict occurs when the destination already exists, or when several repositories would be moved to the same destination.
+The '--on-conflict' flag determines how conflicts are resolved:
+
+- 'abort' => do not move any repository (default)
+- 'skip' => do not move conflicting repositories, but move all others
+- 'suffix' => move conflicting repositories to the destination with a numeric suffix, e.g. 'world-1'
+- 'backup' => move the existing destination aside to a '.backup' suffix first
+
+Failing to move a repository does not stop other repositories from being moved.
+Symlinks created by 'ggman link' that point to a moved repository are updated to point to the new location.
+
+When '$GGROOT' contains several root directories, repositories are moved between them as selected by the 'root' key of the CANFILE, see 'ggman where'.
+Repositories within scan-only roots listed in '$GGSCAN' are never moved.
+
+Output consists of unix-like commands performing the moves.
+The '--simulate' flag only prints these commands, without moving anything.
+
+The '--plan' flag writes the planned moves to a JSON file instead of moving anything.
+For each move, it records the source, the destination, the reason for moving and the remote that determined the destination.
+Conflicts do not abort writing a plan; instead they are recorded in the plan and must be resolved before it can be applied.
+The plan can then be reviewed and edited, and later executed using the '--apply' flag.
+Before applying a plan, it is validated again against the current state of the local repositories.
+If any move is no longer valid, nothing is moved.`,
 		Args: cobra.NoArgs,
 
-		RunE: impl.Exec,
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
 	}
 
 	flags := cmd.Flags()
 	flags.BoolVarP(&impl.OnlyCurrentRemote, "only-current-remote", "o", false, "consider only the current remote (as opposed to all remotes) when checking if a repository is in the correct location")
 	flags.BoolVarP(&impl.Simulate, "simulate", "s", false, "only print unix-like commands to move repositories around")
+	flags.StringVar(&impl.OnConflict, "on-conflict", relocateAbort, "how to resolve conflicts, one of 'abort', 'skip', 'suffix' or 'backup'")
+	flags.StringVar(&impl.Plan, "plan", "", "write planned moves to the given JSON file instead of moving repositories")
+	flags.StringVar(&impl.Apply, "apply", "", "execute exactly the moves from the given JSON file written by '--plan'")
 
 	return cmd
 }
@@ -43,16 +77,78 @@ It also handles repositories whose remote URL has changed.`,
 type relocate struct {
 	Simulate          bool
 	OnlyCurrentRemote bool
+	OnConflict        string
+	Plan              string
+	Apply             string
 }
 
+// policies for resolving conflicts.
+const (
+	relocateAbort  = "abort"
+	relocateSkip   = "skip"
+	relocateSuffix = "suffix"
+	relocateBackup = "backup"
+)
+
 var (
 	errRelocateCreateParent = exit.NewErrorWithCode("failed to create parent directory for destination", env.ExitGeneric)
 	errRelocateMove         = exit.NewErrorWithCode("failed to move repository", env.ExitGeneric)
+	errRelocateBackup       = exit.NewErrorWithCode("failed to move existing destination aside", env.ExitGeneric)
+	errRelocateLink         = exit.NewErrorWithCode("failed to update link", env.ExitGeneric)
 
 	errRelocateRepoExists = exit.NewErrorWithCode("failed to move repository: repository already exists", env.ExitGeneric)
 	errRelocatePathExists = exit.NewErrorWithCode("failed to move repository: path already exists", env.ExitGeneric)
+
+	errRelocateInvalidPolicy = exit.NewErrorWithCode(`"--on-conflict" must be one of "abort", "skip", "suffix" or "backup"`, env.ExitCommandArguments)
+	errRelocateConflicts     = exit.NewErrorWithCode("failed to relocate: found conflicts (use '--on-conflict' to resolve them)", env.ExitGeneric)
+	errRelocateFailed        = exit.NewErrorWithCode("failed to relocate some repositories", env.ExitGeneric)
+
+	errRelocatePlanAndApply    = exit.NewErrorWithCode(`"--plan" and "--apply" cannot be used together`, env.ExitCommandArguments)
+	errRelocatePlanAndSimulate = exit.NewErrorWithCode(`"--plan" and "--simulate" cannot be used together`, env.ExitCommandArguments)
+	errRelocateWritePlan       = exit.NewErrorWithCode("failed to write plan", env.ExitGeneric)
+	errRelocateReadPlan        = exit.NewErrorWithCode("failed to read plan", env.ExitGeneric)
+	errRelocateInvalidPlan     = exit.NewErrorWithCode("failed to apply plan: plan is no longer valid", env.ExitGeneric)
 )
 
+func (r *relocate) ParseArgs(cmd *cobra.Command, args []string) error {
+	if r.Plan != "" && r.Apply != "" {
+		return errRelocatePlanAndApply
+	}
+	if r.Plan != "" && r.Simulate {
+		return errRelocatePlanAndSimulate
+	}
+
+	switch r.OnConflict {
+	case relocateAbort, relocateSkip, relocateSuffix, relocateBackup:
+		return nil
+	default:
+		return errRelocateInvalidPolicy
+	}
+}
+
+// relocatePlan is a plan as written by '--plan' and read by '--apply'.
+type relocatePlan struct {
+	Moves []relocation
+}
+
+// relocation represents a single planned move of a repository.
+type relocation struct {
+	Source      string // current path of the repository
+	Destination string // path the repository is moved to
+	Reason      string `json:",omitempty"` // human-readable reason for moving the repository
+	Remote      string `json:",omitempty"` // remote url that determined the destination
+
+	Conflict string `json:",omitempty"` // description of a conflict at the destination, if any
+	Skip     bool   `json:",omitzero"`  // skip this relocation
+	Backup   string `json:",omitempty"` // path to move an existing destination to, if any
+}
+
+// linkRewrite represents a symlink that needs to point to a new target.
+type linkRewrite struct {
+	Path   string // path of the symlink
+	Target string // new target of the symlink
+}
+
 func (r *relocate) Exec(cmd *cobra.Command, args []string) error {
 	environment, err := env.GetEnv(cmd, env.Requirement{
 		NeedsRoot:    true,
@@ -63,7 +159,73 @@ func (r *relocate) Exec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
 	}
 
+	failed := false
+	fail := func(err error) {
+		failed = true
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), err.Error()) // no way to report error
+	}
+
+	var plan []relocation
+	if r.Apply != "" {
+		plan, err = r.readPlan(cmd, environment)
+		if err != nil {
+			return err
+		}
+	} else {
+		plan = r.makePlan(cmd, environment, fail)
+		if err := r.resolveConflicts(cmd, environment, plan); err != nil {
+			return err
+		}
+	}
+
+	if r.Plan != "" {
+		if err := r.writePlan(environment, plan); err != nil {
+			return err
+		}
+		if failed {
+			return errRelocateFailed
+		}
+		return nil
+	}
+
+	rewrites, err := planLinkRewrites(cmd, environment, plan)
+	if err != nil {
+		fail(err)
+	}
+
+	for _, move := range plan {
+		if err := r.move(cmd, environment, move); err != nil {
+			fail(err)
+		}
+	}
+
+	for _, rewrite := range rewrites {
+		if err := r.rewriteLink(cmd, rewrite); err != nil {
+			fail(err)
+		}
+	}
+
+	if failed {
+		return errRelocateFailed
+	}
+	return nil
+}
+
+// makePlan determines all repositories that need to be moved.
+// Repositories for which no location can be determined are reported to fail.
+func (r *relocate) makePlan(cmd *cobra.Command, environment *env.Env, fail func(err error)) []relocation {
+	reason := "location does not match any remote"
+	if r.OnlyCurrentRemote {
+		reason = "location does not match current remote"
+	}
+
+	var plan []relocation
 	for _, gotPath := range environment.Repos(cmd.Context(), false) {
+		// repositories in scan-only roots are not managed by ggman
+		if _, scanOnly, ok := environment.RootOf(gotPath); ok && scanOnly {
+			continue
+		}
+
 		// check if we are in a valid location
 		valid, err := isValidLocation(gotPath, r.OnlyCurrentRemote, cmd, environment)
 		if err != nil || valid {
@@ -71,65 +233,366 @@ func (r *relocate) Exec(cmd *cobra.Command, args []string) error {
 		}
 
 		// find the path it should go to!
-		shouldPath, err := getCanonicalLocation(gotPath, cmd, environment)
+		shouldPath, remote, err := getCanonicalLocation(gotPath, cmd, environment)
 		if err != nil {
-			return fmt.Errorf("failed to determine canonical location: %w", err)
+			fail(fmt.Errorf("%q: failed to determine canonical location: %w", gotPath, err))
+			continue
+		}
+
+		plan = append(plan, relocation{
+			Source:      gotPath,
+			Destination: shouldPath,
+			Reason:      reason,
+			Remote:      remote,
+		})
+	}
+	return plan
+}
+
+// resolveConflicts detects conflicts in the plan and resolves them according to the conflict policy.
+func (r *relocate) resolveConflicts(cmd *cobra.Command, environment *env.Env, plan []relocation) error {
+	// claimed holds all paths that are the destination of some relocation
+	claimed := make(map[string]struct{}, len(plan))
+	isFree := func(path string) bool {
+		if _, ok := claimed[path]; ok {
+			return false
 		}
+		exists, err := fsx.Exists(path)
+		return err == nil && !exists
+	}
 
-		parentPath := filepath.Dir(shouldPath)
+	conflicts := false
+	for i := range plan {
+		move := &plan[i]
 
-		// print what is being done
-		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "mkdir -p %s\n", shellescape.Quote(parentPath)); err != nil {
-			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		// find the conflict (if any)
+		if _, ok := claimed[move.Destination]; ok {
+			move.Conflict = fmt.Sprintf("another repository is moved to %q", move.Destination)
+		} else if got, err := environment.AtRoot(cmd.Context(), move.Destination); err == nil && got != "" {
+			move.Conflict = fmt.Sprintf("repository already exists at %q", got)
+		} else if exists, err := fsx.Exists(move.Destination); err != nil || exists {
+			move.Conflict = fmt.Sprintf("path already exists at %q", move.Destination)
+		}
+
+		if move.Conflict == "" {
+			claimed[move.Destination] = struct{}{}
+			continue
+		}
+		conflicts = true
+
+		// apply the policy
+		policy := r.OnConflict
+		if _, ok := claimed[move.Destination]; ok && policy == relocateBackup {
+			policy = relocateSuffix // can not backup a repository that is moved in this run
+		}
+
+		switch policy {
+		case relocateSkip:
+			move.Skip = true
+		case relocateSuffix:
+			move.Destination = findFreePath(move.Destination, "-", isFree)
+			claimed[move.Destination] = struct{}{}
+		case relocateBackup:
+			move.Backup = findFreePath(move.Destination+".backup", "-", isFree)
+			claimed[move.Destination] = struct{}{}
+			claimed[move.Backup] = struct{}{}
 		}
-		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "mv %s %s\n", shellescape.Quote(gotPath), shellescape.Quote(shouldPath)); err != nil {
+	}
+
+	// when writing a plan, conflicts are recorded in the plan instead
+	if !conflicts || r.OnConflict != relocateAbort || r.Plan != "" {
+		return nil
+	}
+
+	for _, move := range plan {
+		if move.Conflict == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "%q: %s\n", move.Source, move.Conflict); err != nil {
 			return fmt.Errorf("%w: %w", errGenericOutput, err)
 		}
-		if r.Simulate {
+	}
+	return errRelocateConflicts
+}
+
+// writePlan writes plan to the file given by the '--plan' flag.
+func (r *relocate) writePlan(environment *env.Env, plan []relocation) (err error) {
+	path, err := environment.Abs(r.Plan)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRelocateWritePlan, err)
+	}
+
+	file, err := os.Create(path) /* #nosec G304 -- explicitly passed as a parameter */
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRelocateWritePlan, err)
+	}
+	defer func() {
+		if eClose := file.Close(); eClose != nil && err == nil {
+			err = fmt.Errorf("%w: %w", errRelocateWritePlan, eClose)
+		}
+	}()
+
+	if plan == nil {
+		plan = []relocation{}
+	}
+	if err := json.MarshalWrite(file, relocatePlan{Moves: plan}, jsontext.WithIndent("  ")); err != nil {
+		return fmt.Errorf("%w: %w", errRelocateWritePlan, err)
+	}
+	return nil
+}
+
+// readPlan reads the plan from the file given by the '--apply' flag and validates it.
+// If the plan is not valid, all problems are printed to standard error.
+func (r *relocate) readPlan(cmd *cobra.Command, environment *env.Env) ([]relocation, error) {
+	path, err := environment.Abs(r.Apply)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errRelocateReadPlan, err)
+	}
+
+	data, err := os.ReadFile(path) /* #nosec G304 -- explicitly passed as a parameter */
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errRelocateReadPlan, err)
+	}
+
+	var plan relocatePlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("%w: %w", errRelocateReadPlan, err)
+	}
+
+	problems := validatePlan(cmd, environment, plan.Moves)
+	if len(problems) == 0 {
+		return plan.Moves, nil
+	}
+
+	for _, problem := range problems {
+		if _, err := fmt.Fprintln(cmd.ErrOrStderr(), problem); err != nil {
+			return nil, fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+	}
+	return nil, errRelocateInvalidPlan
+}
+
+// validatePlan checks that plan can be executed given the current state of the local repositories.
+// It returns a human-readable list of problems.
+func validatePlan(cmd *cobra.Command, environment *env.Env, plan []relocation) (problems []string) {
+	claimed := make(map[string]struct{}, len(plan))
+	claim := func(path string) bool {
+		if _, ok := claimed[path]; ok {
+			return false
+		}
+		claimed[path] = struct{}{}
+		return true
+	}
+
+	for _, move := range plan {
+		if move.Skip {
+			continue
+		}
+		if !filepath.IsAbs(move.Source) || !filepath.IsAbs(move.Destination) || (move.Backup != "" && !filepath.IsAbs(move.Backup)) {
+			problems = append(problems, fmt.Sprintf("%q: paths must be absolute", move.Source))
+			continue
+		}
+
+		// the source must still be the same repository
+		if got, err := environment.AtRoot(cmd.Context(), move.Source); err != nil || got == "" || !fsx.Same(got, move.Source) {
+			problems = append(problems, fmt.Sprintf("%q: no repository at source", move.Source))
+			continue
+		}
+		if move.Remote != "" {
+			remotes, err := environment.Git.GetAllRemotes(cmd.Context(), move.Source)
+			if err != nil || !slices.Contains(slices.Collect(maps.Values(remotes)), move.Remote) {
+				problems = append(problems, fmt.Sprintf("%q: repository no longer has remote %q", move.Source, move.Remote))
+			}
+		}
+
+		// the destination (and backup) must be free
+		if !claim(move.Destination) {
+			problems = append(problems, fmt.Sprintf("%q: another repository is moved to %q", move.Source, move.Destination))
+		}
+		exists, err := fsx.Exists(move.Destination)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%q: failed to check destination %q", move.Source, move.Destination))
+		case exists && move.Backup == "":
+			problems = append(problems, fmt.Sprintf("%q: path already exists at %q", move.Source, move.Destination))
+		case !exists && move.Backup != "":
+			problems = append(problems, fmt.Sprintf("%q: nothing to backup at %q", move.Source, move.Destination))
+		}
+		if move.Backup == "" {
 			continue
 		}
+		if !claim(move.Backup) {
+			problems = append(problems, fmt.Sprintf("%q: backup %q is used more than once", move.Source, move.Backup))
+		}
+		if exists, err := fsx.Exists(move.Backup); err != nil || exists {
+			problems = append(problems, fmt.Sprintf("%q: path already exists at %q", move.Source, move.Backup))
+		}
+	}
+	return problems
+}
+
+// findFreePath finds the first path of the form path, path + sep + "1", path + sep + "2", ... that is free.
+func findFreePath(path string, sep string, isFree func(path string) bool) string {
+	if isFree(path) {
+		return path
+	}
+	for i := 1; ; i++ {
+		candidate := path + sep + strconv.Itoa(i)
+		if isFree(candidate) {
+			return candidate
+		}
+	}
+}
+
+// planLinkRewrites finds all links whose target changes because of the plan.
+// These are links pointing to repositories that are moved, and relative links that are moved themselves.
+func planLinkRewrites(cmd *cobra.Command, environment *env.Env, plan []relocation) ([]linkRewrite, error) {
+	moved := make(map[string]string, len(plan))
+	for _, move := range plan {
+		if !move.Skip {
+			moved[filepath.Clean(move.Source)] = move.Destination
+		}
+	}
+	if len(moved) == 0 {
+		return nil, nil
+	}
+
+	links, err := findLinks(cmd.Context(), environment)
+	if err != nil {
+		return nil, err
+	}
+
+	var rewrites []linkRewrite
+	for _, link := range links {
+		relative := !filepath.IsAbs(link.Target)
+
+		// the target of a relative link is relative to the directory containing it
+		target := link.Target
+		if relative {
+			target = filepath.Join(filepath.Dir(link.Path), target)
+		}
 
-		// do it!
-		if err := os.MkdirAll(parentPath, dirs.NewModBits); err != nil {
-			return fmt.Errorf("%q: %w: %w", parentPath, errRelocateCreateParent, err)
+		// find the new path and target
+		newPath, pathMoved := moved[filepath.Clean(link.Path)]
+		if !pathMoved {
+			newPath = link.Path
+		}
+		newTarget, targetMoved := moved[filepath.Clean(target)]
+		if !targetMoved {
+			newTarget = target
 		}
 
-		// if there already is a target repository at the path
-		{
-			got, err := environment.AtRoot(cmd.Context(), shouldPath)
+		// absolute links only change when the target moves
+		if !targetMoved && (!pathMoved || !relative) {
+			continue
+		}
+
+		if relative {
+			newTarget, err = filepath.Rel(filepath.Dir(newPath), newTarget)
 			if err != nil {
-				return fmt.Errorf("%w: %w", errRelocateMove, err)
+				return nil, fmt.Errorf("%q: %w: %w", link.Path, errRelocateLink, err)
 			}
-			if got != "" {
-				return fmt.Errorf("%w at %q", errRelocateRepoExists, got)
+			if newTarget == link.Target {
+				continue
 			}
 		}
+		rewrites = append(rewrites, linkRewrite{Path: newPath, Target: newTarget})
+	}
+	return rewrites, nil
+}
+
+// move performs a single relocation.
+func (r *relocate) move(cmd *cobra.Command, environment *env.Env, move relocation) error {
+	if move.Skip {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "# skipping %s: %s\n", shellescape.Quote(move.Source), move.Conflict); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+		return nil
+	}
 
-		// do the rename
-		{
-			err := os.Rename(gotPath, shouldPath)
+	parentPath := filepath.Dir(move.Destination)
 
-			// check if an error was returned because the path already existed
-			// (fs.ErrPermission is returned by Windows)
-			if errors.Is(err, fs.ErrExist) || errors.Is(err, fs.ErrPermission) {
-				if exists, _ := fsx.Exists(shouldPath); exists {
-					return fmt.Errorf("%q: %w", shouldPath, errRelocatePathExists)
-				}
-			}
+	// print what is being done
+	if move.Backup != "" {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "mv %s %s\n", shellescape.Quote(move.Destination), shellescape.Quote(move.Backup)); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+	}
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "mkdir -p %s\n", shellescape.Quote(parentPath)); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "mv %s %s\n", shellescape.Quote(move.Source), shellescape.Quote(move.Destination)); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	if r.Simulate {
+		return nil
+	}
 
-			if err != nil {
-				return fmt.Errorf("%w: %w", errRelocateMove, err)
+	// move the existing destination aside
+	if move.Backup != "" {
+		if err := os.Rename(move.Destination, move.Backup); err != nil {
+			return fmt.Errorf("%q: %w: %w", move.Destination, errRelocateBackup, err)
+		}
+	}
+
+	// do it!
+	if err := os.MkdirAll(parentPath, dirs.NewModBits); err != nil {
+		return fmt.Errorf("%q: %w: %w", parentPath, errRelocateCreateParent, err)
+	}
+
+	// if there already is a target repository at the path
+	{
+		got, err := environment.AtRoot(cmd.Context(), move.Destination)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errRelocateMove, err)
+		}
+		if got != "" {
+			return fmt.Errorf("%w at %q", errRelocateRepoExists, got)
+		}
+	}
+
+	// do the rename
+	{
+		err := os.Rename(move.Source, move.Destination)
+
+		// check if an error was returned because the path already existed
+		// (fs.ErrPermission is returned by Windows)
+		if errors.Is(err, fs.ErrExist) || errors.Is(err, fs.ErrPermission) {
+			if exists, _ := fsx.Exists(move.Destination); exists {
+				return fmt.Errorf("%q: %w", move.Destination, errRelocatePathExists)
 			}
 		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %w", errRelocateMove, err)
+		}
 	}
 
 	return nil
 }
 
+// rewriteLink points an existing symlink to a new target.
+func (r *relocate) rewriteLink(cmd *cobra.Command, rewrite linkRewrite) error {
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "ln -sfn %s %s\n", shellescape.Quote(rewrite.Target), shellescape.Quote(rewrite.Path)); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+	if r.Simulate {
+		return nil
+	}
+
+	if err := os.Remove(rewrite.Path); err != nil {
+		return fmt.Errorf("%q: %w: %w", rewrite.Path, errRelocateLink, err)
+	}
+	if err := os.Symlink(rewrite.Target, rewrite.Path); err != nil {
+		return fmt.Errorf("%q: %w: %w", rewrite.Path, errRelocateLink, err)
+	}
+	return nil
+}
+
 // isValidLocation checks if the repository at path is in the correct location.
 func isValidLocation(path string, onlyCurrentRemote bool, cmd *cobra.Command, environment *env.Env) (bool, error) {
 	if onlyCurrentRemote {
-		remote, err := getCanonicalLocation(path, cmd, environment)
+		remote, _, err := getCanonicalLocation(path, cmd, environment)
 		if err != nil {
 			return false, fmt.Errorf("failed to get canonical location: %w", err)
 		}
@@ -163,14 +626,15 @@ func isValidLocation(path string, onlyCurrentRemote bool, cmd *cobra.Command, en
 }
 
 // getCanonicalLocation gets the canonical location for a given repository.
-func getCanonicalLocation(path string, cmd *cobra.Command, environment *env.Env) (string, error) {
-	remote, err := environment.Git.GetRemote(cmd.Context(), path, "")
+// It also returns the remote the location was determined from.
+func getCanonicalLocation(path string, cmd *cobra.Command, environment *env.Env) (location, remote string, err error) {
+	remote, err = environment.Git.GetRemote(cmd.Context(), path, "")
 	if err != nil || remote == "" { // ignore remotes that don't exist
-		return "", fmt.Errorf("failed to get remotes: %w", err)
+		return "", "", fmt.Errorf("failed to get remotes: %w", err)
 	}
 	shouldPath, err := environment.Local(env.ParseURL(remote))
 	if err != nil {
-		return "", fmt.Errorf("%w: %w", env.ErrUnableLocalPath, err)
+		return "", "", fmt.Errorf("%w: %w", env.ErrUnableLocalPath, err)
 	}
-	return shouldPath, nil
+	return shouldPath, remote, nil
 }
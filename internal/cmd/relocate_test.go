@@ -1,9 +1,10 @@
 package cmd_test
 
-//spellchecker:words path filepath testing github config plumbing ggman internal mockenv testutil
+//spellchecker:words path filepath strings testing github config plumbing ggman internal mockenv testutil
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/go-git/go-git/v5"
@@ -115,25 +116,54 @@ func TestCommandRelocate_existsRepo(t *testing.T) {
 		wantStderr string
 	}{
 		{
-			"relocate with simulate",
+			"relocate aborts on conflict",
 			"",
 			[]string{"relocate", "--simulate"},
 
+			1,
+			"",
+
+			"\"${GGROOT github.com right other}\": repository already exists at \"${GGROOT github.com right directory}\"\nfailed to relocate: found conflicts (use '--on-conflict' to resolve them)\n",
+		},
+		{
+			"relocate skips conflict",
+			"",
+			[]string{"relocate", "--simulate", "--on-conflict", "skip"},
+
 			0,
-			"mkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com right other}` `${GGROOT github.com right directory}`\n",
+			"# skipping `${GGROOT github.com right other}`: repository already exists at \"${GGROOT github.com right directory}\"\n",
 
 			"",
 		},
+		{
+			"relocate suffixes conflict",
+			"",
+			[]string{"relocate", "--simulate", "--on-conflict", "suffix"},
+
+			0,
+			"mkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com right other}` `${GGROOT github.com right directory-1}`\n",
 
+			"",
+		},
 		{
-			"relocate without simulate",
+			"relocate backs up conflict",
 			"",
-			[]string{"relocate"},
+			[]string{"relocate", "--simulate", "--on-conflict", "backup"},
 
-			1,
-			"mkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com right other}` `${GGROOT github.com right directory}`\n",
+			0,
+			"mv `${GGROOT github.com right directory}` `${GGROOT github.com right directory.backup}`\nmkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com right other}` `${GGROOT github.com right directory}`\n",
 
-			"failed to move repository: repository already exists at \"${GGROOT github.com right directory}\"\n",
+			"",
+		},
+		{
+			"relocate with invalid policy",
+			"",
+			[]string{"relocate", "--simulate", "--on-conflict", "whatever"},
+
+			4,
+			"",
+
+			"\"--on-conflict\" must be one of \"abort\", \"skip\", \"suffix\" or \"backup\"\n",
 		},
 	}
 
@@ -173,25 +203,24 @@ func TestCommandRelocate_existsPath(t *testing.T) {
 		wantStderr string
 	}{
 		{
-			"relocate with simulate",
+			"relocate aborts on conflict",
 			"",
 			[]string{"relocate", "--simulate"},
 
-			0,
-			"mkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com wrong directory}` `${GGROOT github.com right directory}`\n",
-
+			1,
 			"",
-		},
 
+			"\"${GGROOT github.com wrong directory}\": path already exists at \"${GGROOT github.com right directory}\"\nfailed to relocate: found conflicts (use '--on-conflict' to resolve them)\n",
+		},
 		{
-			"relocate without simulate",
+			"relocate suffixes conflict",
 			"",
-			[]string{"relocate"},
+			[]string{"relocate", "--simulate", "--on-conflict", "suffix"},
 
-			1,
-			"mkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com wrong directory}` `${GGROOT github.com right directory}`\n",
+			0,
+			"mkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com wrong directory}` `${GGROOT github.com right directory-1}`\n",
 
-			"\"${GGROOT github.com right directory}\": failed to move repository: path already exists\n",
+			"",
 		},
 	}
 
@@ -354,3 +383,240 @@ func TestCommandRelocate_multipleRemotes(t *testing.T) {
 		mock.AssertOutput(t, "Stderr", stderr, "")
 	})
 }
+
+//nolint:paralleltest
+func TestCommandRelocate_links(t *testing.T) {
+	mock := mockenv.NewMockEnv(t)
+
+	// link an external repository into the wrong place using a relative link
+	external := mock.Clone(t.Context(), "https://github.com/right/external.git", "..", "external-path")
+	if err := os.MkdirAll(mock.Resolve("github.com", "wrong", "place"), os.ModePerm|os.ModeDir); err != nil {
+		panic(err)
+	}
+	relative, err := filepath.Rel(mock.Resolve("github.com", "wrong", "place"), external)
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Symlink(relative, mock.Resolve("github.com", "wrong", "place", "external")); err != nil {
+		panic(err)
+	}
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "relocate")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "mkdir -p `${GGROOT github.com right}`\nmv `${GGROOT github.com wrong place external}` `${GGROOT github.com right external}`\nln -sfn ../../../external-path `${GGROOT github.com right external}`\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+
+	// check that the moved link still points to the repository
+	got, err := filepath.EvalSymlinks(mock.Resolve("github.com", "right", "external"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := filepath.EvalSymlinks(external)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("link points to %q, want %q", got, want)
+	}
+}
+
+//nolint:paralleltest
+func TestCommandRelocate_plan(t *testing.T) {
+	mock := mockenv.NewMockEnv(t)
+
+	mock.Clone(t.Context(), "https://github.com/correct/directory.git", "github.com", "incorrect", "directory")
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+
+	// write the plan
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "relocate", "--plan", planPath)
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+
+	data, err := os.ReadFile(planPath) // #nosec G304 -- test file
+	if err != nil {
+		panic(err)
+	}
+	mock.AssertOutput(t, "Plan", string(data), `{
+  "Moves": [
+    {
+      "Source": "${GGROOT github.com incorrect directory}",
+      "Destination": "${GGROOT github.com correct directory}",
+      "Reason": "location does not match any remote",
+      "Remote": "https://github.com/correct/directory.git"
+    }
+  ]
+}`)
+
+	// edit the plan to move somewhere else
+	edited := strings.ReplaceAll(string(data), mock.Resolve("github.com", "correct"), mock.Resolve("github.com", "edited"))
+	if err := os.WriteFile(planPath, []byte(edited), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"plan and apply together",
+			[]string{"relocate", "--plan", planPath, "--apply", planPath},
+
+			4,
+			"",
+			"\"--plan\" and \"--apply\" cannot be used together\n",
+		},
+		{
+			"plan and simulate together",
+			[]string{"relocate", "--plan", planPath, "--simulate"},
+
+			4,
+			"",
+			"\"--plan\" and \"--simulate\" cannot be used together\n",
+		},
+		{
+			"apply plan with simulate",
+			[]string{"relocate", "--apply", planPath, "--simulate"},
+
+			0,
+			"mkdir -p `${GGROOT github.com edited}`\nmv `${GGROOT github.com incorrect directory}` `${GGROOT github.com edited directory}`\n",
+			"",
+		},
+		{
+			"apply plan",
+			[]string{"relocate", "--apply", planPath},
+
+			0,
+			"mkdir -p `${GGROOT github.com edited}`\nmv `${GGROOT github.com incorrect directory}` `${GGROOT github.com edited directory}`\n",
+			"",
+		},
+		{
+			"apply outdated plan",
+			[]string{"relocate", "--apply", planPath},
+
+			1,
+			"",
+			"\"${GGROOT github.com incorrect directory}\": no repository at source\nfailed to apply plan: plan is no longer valid\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestCommandRelocate_planConflict(t *testing.T) {
+	mock := mockenv.NewMockEnv(t)
+
+	mock.Clone(t.Context(), "https://github.com/correct/directory.git", "github.com", "incorrect", "directory")
+	if err := os.MkdirAll(mock.Resolve("github.com", "correct", "directory"), os.ModePerm); err != nil {
+		panic(err)
+	}
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+
+	// the conflict is recorded in the plan instead of aborting
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "relocate", "--plan", planPath)
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+
+	data, err := os.ReadFile(planPath) // #nosec G304 -- test file
+	if err != nil {
+		panic(err)
+	}
+	mock.AssertOutput(t, "Plan", string(data), `{
+  "Moves": [
+    {
+      "Source": "${GGROOT github.com incorrect directory}",
+      "Destination": "${GGROOT github.com correct directory}",
+      "Reason": "location does not match any remote",
+      "Remote": "https://github.com/correct/directory.git",
+      "Conflict": "path already exists at \"${GGROOT github.com correct directory}\""
+    }
+  ]
+}`)
+
+	// the unresolved conflict prevents applying the plan
+	code, stdout, stderr = mock.Run(t, nil, cmd.NewCommand, "", "", "relocate", "--apply", planPath)
+	if code != 1 {
+		t.Errorf("Code = %d, wantCode = 1", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "")
+	mock.AssertOutput(t, "Stderr", stderr, "\"${GGROOT github.com incorrect directory}\": path already exists at \"${GGROOT github.com correct directory}\"\nfailed to apply plan: plan is no longer valid\n")
+}
+
+func TestCommandRelocate_roots(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	work := mock.Resolve("..", "work")
+	mock.SetRoots(work)
+	mock.SetScan(mock.Resolve("..", "scan"))
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[[rule]]
+pattern = "^gitlab.work.com"
+canonical = "git@^:$.git"
+root = '`+work+`'
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	// in the wrong root
+	mock.Clone(t.Context(), "https://gitlab.work.com/hello/world.git", "gitlab.work.com", "hello", "world")
+
+	// in the right root
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+
+	// in a scan-only root, never moved
+	mock.Clone(t.Context(), "https://github.com/go/tool.git", "..", "scan", "tool")
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "relocate", "--simulate")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "mkdir -p `${GGROOT .. work gitlab.work.com hello}`\nmv `${GGROOT gitlab.work.com hello world}` `${GGROOT .. work gitlab.work.com hello world}`\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+}
+
+func TestCommandRelocate_layout(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	mock.SetLayout("{host}/{name}")
+
+	mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	mock.Clone(t.Context(), "https://github.com/other/repo.git", "github.com", "repo")
+
+	code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "relocate", "--simulate")
+	if code != 0 {
+		t.Errorf("Code = %d, wantCode = 0", code)
+	}
+	mock.AssertOutput(t, "Stdout", stdout, "mkdir -p `${GGROOT github.com}`\nmv `${GGROOT github.com hello world}` `${GGROOT github.com world}`\n")
+	mock.AssertOutput(t, "Stderr", stderr, "")
+}
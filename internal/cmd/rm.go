@@ -0,0 +1,178 @@
+package cmd
+
+//spellchecker:words essio shellescape github cobra ggman internal pkglib exit
+import (
+	"fmt"
+	"os"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/pkglib/exit"
+)
+
+//spellchecker:words positionals rmdir unpushed wrapcheck GGROOT
+
+func NewRmCommand() *cobra.Command {
+	impl := new(rm)
+
+	cmd := &cobra.Command{
+		Use:   "rm PATTERN",
+		Short: "Remove local clones of repositories matching a pattern",
+		Long: `Rm removes all repositories matching PATTERN from '$GGROOT'.
+PATTERN is interpreted like the '--for' flag, except that fuzzy matching is disabled.
+Repositories outside of '$GGROOT', such as those only found in '$GGSCAN', are never removed.
+
+Before removing anything, each repository is checked for data that would be lost:
+
+- uncommitted changes
+- branches with commits not pushed to their upstream
+- branches without an upstream
+- stashed changes
+
+Any such data is printed as a comment.
+If any repository contains such data, nothing is removed unless the '--force' flag is given.
+
+After removing the repositories, parent directories that became empty are removed as well.
+These are the same directories that 'ggman sweep' would list.
+
+Output consists of unix-like commands performing the removal.
+The '--simulate' flag only prints these commands, without removing anything.`,
+		Args: cobra.ExactArgs(1),
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Force, "force", "f", false, "remove repositories even if local data would be lost")
+	flags.BoolVarP(&impl.Simulate, "simulate", "s", false, "only print unix-like commands to remove repositories")
+
+	return cmd
+}
+
+type rm struct {
+	Positionals struct {
+		Pattern string
+	}
+	Force    bool
+	Simulate bool
+}
+
+var (
+	errRmNoRepos = exit.NewErrorWithCode("failed to find any matching repositories", env.ExitInvalidRepo)
+	errRmOutside = exit.NewErrorWithCode("failed to remove repository: repository is outside of '$GGROOT'", env.ExitGeneric)
+	errRmUnsafe  = exit.NewErrorWithCode("failed to remove repositories: local data would be lost (use '--force' to remove anyway)", env.ExitGeneric)
+	errRmCheck   = exit.NewErrorWithCode("failed to check repository for local data", env.ExitGeneric)
+	errRmRemove  = exit.NewErrorWithCode("failed to remove repository", env.ExitGeneric)
+	errRmSweep   = exit.NewErrorWithCode("failed to remove empty directory", env.ExitGeneric)
+)
+
+func (r *rm) ParseArgs(cmd *cobra.Command, args []string) error {
+	r.Positionals.Pattern = args[0]
+	return nil
+}
+
+func (r *rm) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		NeedsRoot: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+	environment.Filter = environment.NewForFilter(cmd.Context(), r.Positionals.Pattern, false)
+
+	repos := environment.Repos(cmd.Context(), false)
+	if len(repos) == 0 {
+		return errRmNoRepos
+	}
+
+	// only ever remove repositories managed by ggman
+	for _, repo := range repos {
+		if _, scanOnly, ok := environment.RootOf(repo); !ok || scanOnly {
+			return fmt.Errorf("%q: %w", repo, errRmOutside)
+		}
+	}
+
+	// check what would be lost
+	unsafe := false
+	for _, repo := range repos {
+		losses, err := getLocalLosses(cmd, environment, repo)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errRmCheck, err)
+		}
+		for _, loss := range losses {
+			unsafe = true
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "# %s: %s\n", repo, loss); err != nil {
+				return fmt.Errorf("%w: %w", errGenericOutput, err)
+			}
+		}
+	}
+	if unsafe && !r.Force {
+		return errRmUnsafe
+	}
+
+	// find the parent directories that will be empty
+	parents, err := sweepRootParents(cmd.Context(), environment, repos...)
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "rm -rf %s\n", shellescape.Quote(repo)); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+		if r.Simulate {
+			continue
+		}
+		if err := os.RemoveAll(repo); err != nil {
+			return fmt.Errorf("%q: %w: %w", repo, errRmRemove, err)
+		}
+	}
+
+	for _, dir := range parents {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "rmdir %s\n", shellescape.Quote(dir)); err != nil {
+			return fmt.Errorf("%w: %w", errGenericOutput, err)
+		}
+		if r.Simulate {
+			continue
+		}
+		if err := removeEmptyDir(environment, dir); err != nil {
+			return fmt.Errorf("%q: %w: %w", dir, errRmSweep, err)
+		}
+	}
+
+	return nil
+}
+
+// getLocalLosses returns a human-readable list of local data that would be lost if the repository at path was removed.
+func getLocalLosses(cmd *cobra.Command, environment *env.Env, path string) (losses []string, err error) {
+	dirty, err := environment.Git.IsDirty(cmd.Context(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for uncommitted changes: %w", err)
+	}
+	if dirty {
+		losses = append(losses, "uncommitted changes")
+	}
+
+	unpushed, localOnly, err := environment.Git.GetUnpushedBranches(cmd.Context(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for unpushed branches: %w", err)
+	}
+	for _, branch := range unpushed {
+		losses = append(losses, fmt.Sprintf("unpushed commits on branch %q", branch))
+	}
+	for _, branch := range localOnly {
+		losses = append(losses, fmt.Sprintf("local-only branch %q", branch))
+	}
+
+	stashed, err := environment.Git.HasStash(cmd.Context(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for stashed changes: %w", err)
+	}
+	if stashed {
+		losses = append(losses, "stashed changes")
+	}
+
+	return losses, nil
+}
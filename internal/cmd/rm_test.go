@@ -0,0 +1,184 @@
+package cmd_test
+
+//spellchecker:words path filepath testing github ggman internal mockenv pkglib
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+	"go.tkw01536.de/pkglib/fsx"
+)
+
+//spellchecker:words GGROOT workdir rmdir
+
+func TestCommandRm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		args  []string
+		dirty string // kind of uncommitted changes to make, if any
+
+		wantCode    uint8
+		wantStdout  string
+		wantStderr  string
+		wantRemoved bool
+	}{
+		{
+			"remove clean repository",
+			[]string{"rm", "github.com/hello/world"},
+			"",
+
+			0,
+			"rm -rf `${GGROOT github.com hello world}`\nrmdir `${GGROOT github.com hello}`\n",
+			"",
+			true,
+		},
+		{
+			"simulate removing clean repository",
+			[]string{"rm", "--simulate", "github.com/hello/world"},
+			"",
+
+			0,
+			"rm -rf `${GGROOT github.com hello world}`\nrmdir `${GGROOT github.com hello}`\n",
+			"",
+			false,
+		},
+		{
+			"refuse to remove dirty repository",
+			[]string{"rm", "github.com/hello/world"},
+			"untracked",
+
+			1,
+			"# ${GGROOT github.com hello world}: uncommitted changes\n",
+			"failed to remove repositories: local data would be lost (use '--force' to remove anyway)\n",
+			false,
+		},
+		{
+			"refuse to remove repository with staged changes",
+			[]string{"rm", "github.com/hello/world"},
+			"staged",
+
+			1,
+			"# ${GGROOT github.com hello world}: uncommitted changes\n",
+			"failed to remove repositories: local data would be lost (use '--force' to remove anyway)\n",
+			false,
+		},
+		{
+			"force remove dirty repository",
+			[]string{"rm", "--force", "github.com/hello/world"},
+			"untracked",
+
+			0,
+			"# ${GGROOT github.com hello world}: uncommitted changes\nrm -rf `${GGROOT github.com hello world}`\nrmdir `${GGROOT github.com hello}`\n",
+			"",
+			true,
+		},
+		{
+			"no matching repository",
+			[]string{"rm", "github.com/hello/mars"},
+			"",
+
+			6,
+			"",
+			"failed to find any matching repositories\n",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := mockenv.NewMockEnv(t)
+			repo := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+			mock.Clone(t.Context(), "https://github.com/other/world.git", "github.com", "other", "world")
+
+			if tt.dirty != "" {
+				if err := os.WriteFile(filepath.Join(repo, "dirty"), []byte("dirty"), 0600); err != nil {
+					panic(err)
+				}
+			}
+			if tt.dirty == "staged" {
+				r, err := git.PlainOpen(repo)
+				if err != nil {
+					panic(err)
+				}
+				worktree, err := r.Worktree()
+				if err != nil {
+					panic(err)
+				}
+				if _, err := worktree.Add("dirty"); err != nil {
+					panic(err)
+				}
+			}
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+
+			exists, err := fsx.Exists(repo)
+			if err != nil {
+				panic(err)
+			}
+			if exists == tt.wantRemoved {
+				t.Errorf("repository exists = %v, wantRemoved = %v", exists, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestCommandRm_outside(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path []string
+
+		wantStderr string
+	}{
+		{
+			"repository outside of root",
+			[]string{"..", "outside", "world"},
+
+			"\"${GGROOT .. outside world}\": failed to remove repository: repository is outside of '$GGROOT'\n",
+		},
+		{
+			"repository in scan-only root",
+			[]string{"..", "scan", "world"},
+
+			"\"${GGROOT .. scan world}\": failed to remove repository: repository is outside of '$GGROOT'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := mockenv.NewMockEnv(t)
+			mock.SetScan(mock.Resolve("..", "scan"))
+			repo := mock.Clone(t.Context(), "https://github.com/hello/world.git", tt.path...)
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "rm", "--force", repo)
+			if code != 1 {
+				t.Errorf("Code = %d, wantCode = 1", code)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, "")
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+
+			exists, err := fsx.Exists(repo)
+			if err != nil {
+				panic(err)
+			}
+			if !exists {
+				t.Error("repository was removed")
+			}
+		})
+	}
+}
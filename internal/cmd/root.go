@@ -109,23 +109,33 @@ The goals and principles of ggman are:
 
 	// add all the commands
 	root.AddCommand(
+		NewArchiveCommand(),
 		NewCanonCommand(),
 		NewCloneCommand(),
 		NewCompsCommand(),
+		NewDoctorCommand(),
+		NewDupesCommand(),
 		NewEnvCommand(),
 		NewExecCommand(),
 		NewFetchCommand(),
 		NewFindBranchCommand(),
 		NewFindFileCommand(),
 		NewFixCommand(),
+		NewGcCommand(),
+		NewGrepCommand(),
 		NewHereCommand(),
 		NewLicenseCommand(),
 		NewLinkCommand(),
+		NewLogCommand(),
 		NewLsCommand(),
 		NewPullCommand(),
 		NewRelocateCommand(),
+		NewRmCommand(),
 		NewShellrcCommand(),
+		NewStatsCommand(),
 		NewSweepCommand(),
+		NewUnarchiveCommand(),
+		NewUnlinkCommand(),
 		NewWhereCommand(),
 		NewWebCommand(),
 		NewVersionCommand(),
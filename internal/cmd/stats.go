@@ -0,0 +1,272 @@
+package cmd
+
+//spellchecker:words cmp encoding json jsontext errors maps slices strings tabwriter time github cobra ggman internal pkglib exit sema
+import (
+	"cmp"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/ggman/internal/git"
+	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/sema"
+)
+
+func NewStatsCommand() *cobra.Command {
+	impl := new(stats)
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Print size and activity statistics of all repositories",
+		Long: `Stats prints size and activity statistics of all repositories, one repository per line, followed by a line holding the totals.
+
+For each repository, it prints the size of the working tree and the size of the '.git' directory, the number of loose objects and packs, the number of commits reachable from HEAD, the number of local branches and the date of the HEAD commit.
+Nested repositories are not included in the working tree size of the repository containing them.
+Statistics of repositories are computed in parallel.
+
+The '--sort' flag sorts repositories by one of 'path', 'worktree', 'git', 'size' (working tree and git directory combined), 'objects', 'packs', 'commits', 'branches' or 'last'.
+Paths are sorted alphabetically, all other keys largest or most recent first.
+The '--reverse' flag reverses the order.
+For example, 'ggman stats --sort size' lists the largest repositories first, and 'ggman stats --sort last --reverse' lists the least recently committed to repositories first.
+
+The '--json' flag prints statistics as JSON instead, with sizes in bytes.`,
+		Args: cobra.NoArgs,
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&impl.Sort, "sort", "s", statsSortPath, "key to sort repositories by, one of "+strings.Join(slices.Sorted(maps.Keys(statsSortKeys)), ", "))
+	flags.BoolVarP(&impl.Reverse, "reverse", "r", false, "reverse the sort order")
+	flags.BoolVar(&impl.JSON, "json", false, "print statistics as JSON")
+	flags.IntVarP(&impl.Parallel, "parallel", "p", 4, "number of repositories to compute statistics of in parallel, 0 for no limit")
+
+	return cmd
+}
+
+type stats struct {
+	Sort     string
+	Reverse  bool
+	JSON     bool
+	Parallel int
+}
+
+var (
+	errStatsInvalidSort      = exit.NewErrorWithCode(`invalid argument for "--sort"`, env.ExitCommandArguments)
+	errStatsParallelNegative = exit.NewErrorWithCode(`argument for "--parallel" must be non-negative`, env.ExitCommandArguments)
+	errStatsInterrupted      = exit.NewErrorWithCode("interrupted", env.ExitContext)
+	errStatsFailed           = exit.NewErrorWithCode("failed to compute statistics", env.ExitGeneric)
+)
+
+const statsSortPath = "path"
+
+// statsSortKeys maps the keys accepted by '--sort' to functions comparing statistics.
+// Functions sort in the default order, i.e. paths alphabetically and everything else largest first.
+var statsSortKeys = map[string]func(a, b statsRepo) int{
+	statsSortPath: func(a, b statsRepo) int { return cmp.Compare(a.Relative, b.Relative) },
+	"worktree":    func(a, b statsRepo) int { return cmp.Compare(b.WorktreeSize, a.WorktreeSize) },
+	"git":         func(a, b statsRepo) int { return cmp.Compare(b.GitSize, a.GitSize) },
+	"size":        func(a, b statsRepo) int { return cmp.Compare(b.WorktreeSize+b.GitSize, a.WorktreeSize+a.GitSize) },
+	"objects":     func(a, b statsRepo) int { return cmp.Compare(b.Objects, a.Objects) },
+	"packs":       func(a, b statsRepo) int { return cmp.Compare(b.Packs, a.Packs) },
+	"commits":     func(a, b statsRepo) int { return cmp.Compare(b.Commits, a.Commits) },
+	"branches":    func(a, b statsRepo) int { return cmp.Compare(b.Branches, a.Branches) },
+	"last":        func(a, b statsRepo) int { return b.LastCommit.Compare(a.LastCommit) },
+}
+
+func (s *stats) ParseArgs(cmd *cobra.Command, args []string) error {
+	if s.Parallel < 0 {
+		return errStatsParallelNegative
+	}
+	if _, ok := statsSortKeys[s.Sort]; !ok {
+		return fmt.Errorf("%w: unknown key %q", errStatsInvalidSort, s.Sort)
+	}
+	return nil
+}
+
+// statsReport holds the statistics printed by stats.
+type statsReport struct {
+	Repos []statsRepo
+	Total statsTotal
+}
+
+// statsRepo holds the statistics of a single repository.
+type statsRepo struct {
+	Path     string
+	Relative string
+
+	statsTotal
+}
+
+// statsTotal holds statistics of one or more repositories.
+type statsTotal struct {
+	WorktreeSize int64
+	GitSize      int64
+
+	Objects int
+	Packs   int
+
+	Commits    int
+	Branches   int
+	LastCommit time.Time `json:",omitzero"`
+}
+
+// Add adds other to these statistics.
+// The last commit becomes the most recent of both.
+func (total *statsTotal) Add(other statsTotal) {
+	total.WorktreeSize += other.WorktreeSize
+	total.GitSize += other.GitSize
+	total.Objects += other.Objects
+	total.Packs += other.Packs
+	total.Commits += other.Commits
+	total.Branches += other.Branches
+	if other.LastCommit.After(total.LastCommit) {
+		total.LastCommit = other.LastCommit
+	}
+}
+
+// statsResult holds the result of computing the statistics of a single repository.
+type statsResult struct {
+	Stats git.Stats
+	Err   error
+}
+
+func (s *stats) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		AllowsFilter: true,
+		NeedsRoot:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	ctx := cmd.Context()
+
+	// compute the statistics of all repositories in parallel
+	repos := environment.Repos(ctx, true)
+	results := make([]statsResult, len(repos))
+	_ = sema.Schedule(func(i uint64) error {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			return nil
+		}
+		results[i].Stats, results[i].Err = environment.Git.GetStats(ctx, repos[i])
+		return nil
+	}, uint64(len(repos)), sema.Concurrency{
+		Limit: s.Parallel,
+		Force: true,
+	})
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", errStatsInterrupted, err)
+	}
+
+	// collect the report
+	report := statsReport{Repos: make([]statsRepo, 0, len(repos))}
+	var errs []error
+	for i, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", repos[i], result.Err))
+			continue
+		}
+
+		repo := statsRepo{
+			Path:     repos[i],
+			Relative: relativeToRoot(environment, repos[i]),
+			statsTotal: statsTotal{
+				WorktreeSize: result.Stats.WorktreeSize,
+				GitSize:      result.Stats.GitSize,
+				Objects:      result.Stats.Objects,
+				Packs:        result.Stats.Packs,
+				Commits:      result.Stats.Commits,
+				Branches:     result.Stats.Branches,
+				LastCommit:   result.Stats.LastCommit,
+			},
+		}
+		report.Repos = append(report.Repos, repo)
+		report.Total.Add(repo.statsTotal)
+	}
+
+	compare := statsSortKeys[s.Sort]
+	slices.SortStableFunc(report.Repos, func(a, b statsRepo) int {
+		if s.Reverse {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+
+	if err := s.print(cmd.OutOrStdout(), report); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", errStatsFailed, errors.Join(errs...))
+	}
+	return nil
+}
+
+// print prints report to w in the format selected by the flags.
+func (s *stats) print(w io.Writer, report statsReport) error {
+	if s.JSON {
+		if err := json.MarshalWrite(w, report, jsontext.WithIndent("  ")); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(table, "REPOSITORY\tWORKTREE\tGIT\tOBJECTS\tPACKS\tCOMMITS\tBRANCHES\tLAST COMMIT"); err != nil {
+		return err
+	}
+	for _, repo := range report.Repos {
+		if err := printStatsLine(table, repo.Relative, repo.statsTotal); err != nil {
+			return err
+		}
+	}
+	if err := printStatsLine(table, "TOTAL", report.Total); err != nil {
+		return err
+	}
+	return table.Flush()
+}
+
+// printStatsLine prints a single line of the stats table to table.
+func printStatsLine(table io.Writer, name string, stats statsTotal) error {
+	last := "-"
+	if !stats.LastCommit.IsZero() {
+		last = stats.LastCommit.Local().Format(time.DateOnly)
+	}
+
+	_, err := fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", name, formatSize(stats.WorktreeSize), formatSize(stats.GitSize), stats.Objects, stats.Packs, stats.Commits, stats.Branches, last)
+	return err
+}
+
+// formatSize formats size bytes using binary units.
+func formatSize(size int64) string {
+	if size < 0 {
+		return "-" + formatSize(-size)
+	}
+
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / unit
+	prefixes := "KMGTPE"
+	for value >= unit && len(prefixes) > 1 {
+		value /= unit
+		prefixes = prefixes[1:]
+	}
+	return fmt.Sprintf("%.1f %ciB", value, prefixes[0])
+}
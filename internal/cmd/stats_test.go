@@ -0,0 +1,151 @@
+package cmd_test
+
+//spellchecker:words context encoding json slices strings testing ggman internal mockenv
+import (
+	"context"
+	"encoding/json/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.tkw01536.de/ggman/internal/cmd"
+	"go.tkw01536.de/ggman/internal/mockenv"
+)
+
+//spellchecker:words workdir
+
+// statsJSON is the json output of 'ggman stats --json'.
+type statsJSON struct {
+	Repos []statsJSONRepo
+	Total statsJSONRepo
+}
+
+type statsJSONRepo struct {
+	Relative     string
+	WorktreeSize int64
+	GitSize      int64
+	Objects      int
+	Packs        int
+	Commits      int
+	Branches     int
+}
+
+func TestCommandStats(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	commitFiles(t, mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world"), map[string]string{
+		"small.txt": "hello world\n",
+	})
+	commitFiles(t, mock.Clone(t.Context(), "https://gitlab.com/hello/world.git", "gitlab.com", "hello", "world"), map[string]string{
+		"large.txt": strings.Repeat("hello world\n", 100),
+	})
+	mock.Clone(t.Context(), "user@server.com/repo", "server.com", "user", "repo")
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "stats", "--json")
+		if code != 0 {
+			t.Fatalf("Code = %d, wantCode = 0, stderr = %q", code, stderr)
+		}
+
+		var got statsJSON
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []statsJSONRepo{
+			// clones are packed, the additional commit creates a blob, a tree and a commit object
+			{Relative: "github.com/hello/world", WorktreeSize: 12, Objects: 3, Packs: 1, Commits: 2, Branches: 1},
+			{Relative: "gitlab.com/hello/world", WorktreeSize: 1200, Objects: 3, Packs: 1, Commits: 2, Branches: 1},
+			{Relative: "server.com/user/repo", WorktreeSize: 0, Objects: 0, Packs: 1, Commits: 1, Branches: 1},
+		}
+		if len(got.Repos) != len(want) {
+			t.Fatalf("got %d repositories, want %d", len(got.Repos), len(want))
+		}
+
+		var total statsJSONRepo
+		for i, repo := range got.Repos {
+			if repo.GitSize <= 0 {
+				t.Errorf("repository %q: GitSize = %d, want positive", repo.Relative, repo.GitSize)
+			}
+			total.GitSize += repo.GitSize
+
+			repo.GitSize = 0
+			if repo != want[i] {
+				t.Errorf("repository %d = %#v, want %#v", i, repo, want[i])
+			}
+			total.WorktreeSize += want[i].WorktreeSize
+			total.Objects += want[i].Objects
+			total.Packs += want[i].Packs
+			total.Commits += want[i].Commits
+			total.Branches += want[i].Branches
+		}
+
+		if got.Total != total {
+			t.Errorf("Total = %#v, want %#v", got.Total, total)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"sort by path", []string{"stats"}, []string{"github.com/hello/world", "gitlab.com/hello/world", "server.com/user/repo", "TOTAL"}},
+		{"sort by worktree size", []string{"stats", "--sort", "worktree"}, []string{"gitlab.com/hello/world", "github.com/hello/world", "server.com/user/repo", "TOTAL"}},
+		{"sort by worktree size reversed", []string{"stats", "--sort", "worktree", "--reverse"}, []string{"server.com/user/repo", "github.com/hello/world", "gitlab.com/hello/world", "TOTAL"}},
+		{"sort by commits reversed", []string{"stats", "--sort", "commits", "--reverse"}, []string{"server.com/user/repo", "github.com/hello/world", "gitlab.com/hello/world", "TOTAL"}},
+		{"filtered repositories", []string{"--for", "gitlab.com", "stats"}, []string{"gitlab.com/hello/world", "TOTAL"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", tt.args...)
+			if code != 0 {
+				t.Fatalf("Code = %d, wantCode = 0, stderr = %q", code, stderr)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+			if got := strings.Fields(lines[0]); !slices.Equal(got, []string{"REPOSITORY", "WORKTREE", "GIT", "OBJECTS", "PACKS", "COMMITS", "BRANCHES", "LAST", "COMMIT"}) {
+				t.Errorf("header = %q", lines[0])
+			}
+
+			names := make([]string, 0, len(lines)-1)
+			for _, line := range lines[1:] {
+				name, _, _ := strings.Cut(line, " ")
+				names = append(names, name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("repositories = %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid sort key", func(t *testing.T) {
+		t.Parallel()
+
+		code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, "", "", "stats", "--sort", "nope")
+		if code != 4 {
+			t.Errorf("Code = %d, wantCode = 4", code)
+		}
+		mock.AssertOutput(t, "Stdout", stdout, "")
+		mock.AssertOutput(t, "Stderr", stderr, "invalid argument for \"--sort\": unknown key \"nope\"\n")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		code, stdout, stderr := mock.Run(t, ctx, cmd.NewCommand, "", "", "stats")
+		if code != 254 {
+			t.Errorf("Code = %d, wantCode = 254", code)
+		}
+		mock.AssertOutput(t, "Stdout", stdout, "")
+		mock.AssertOutput(t, "Stderr", stderr, "interrupted: context canceled\n")
+	})
+}
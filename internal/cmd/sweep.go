@@ -1,16 +1,25 @@
 package cmd
 
-//spellchecker:words github cobra ggman internal walker pkglib exit
+//spellchecker:words context errors maps path filepath slices strings github cobra ggman internal walker pkglib exit
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"go.tkw01536.de/ggman/internal/env"
 	"go.tkw01536.de/ggman/internal/walker"
 	"go.tkw01536.de/pkglib/exit"
+	"go.tkw01536.de/pkglib/fsx"
 )
 
-//spellchecker:words GGROOT wrapcheck
+//spellchecker:words GGROOT GGJUNK rmdir wrapcheck
 
 func NewSweepCommand() *cobra.Command {
 	impl := new(sweep)
@@ -22,25 +31,38 @@ func NewSweepCommand() *cobra.Command {
 A directory is empty if it contains only recursively empty subdirectories.
 These directories remain after 'ggman relocate' or manual repository deletion.
 
+Files with names listed in the '$GGJUNK' variable, such as '.DS_Store' or 'Thumbs.db', do not prevent a directory from being empty.
+Multiple names are separated by the os-specific path list separator, ':' on unix-like systems.
+
 Output is ordered such that
 
     ggman sweep | xargs rmdir
 
-can remove all directories.
+can remove all directories, provided they do not contain junk files.
+
+The '--delete' flag removes the directories directly, including any junk files within them.
 `,
 		Args: cobra.NoArgs,
 
 		RunE: impl.Exec,
 	}
 
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Delete, "delete", "d", false, "remove empty directories instead of only printing them")
+
 	return cmd
 }
 
-type sweep struct{}
+type sweep struct {
+	Delete bool
+}
 
-var errSweepScan = exit.NewErrorWithCode("failed to scan for empty directories", env.ExitGeneric)
+var (
+	errSweepScan   = exit.NewErrorWithCode("failed to scan for empty directories", env.ExitGeneric)
+	errSweepDelete = exit.NewErrorWithCode("failed to remove empty directory", env.ExitGeneric)
+)
 
-func (sweep) Exec(cmd *cobra.Command, args []string) error {
+func (s *sweep) Exec(cmd *cobra.Command, args []string) error {
 	environment, err := env.GetEnv(cmd, env.Requirement{
 		NeedsRoot: true,
 	})
@@ -48,19 +70,122 @@ func (sweep) Exec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
 	}
 
-	results, err := walker.Sweep(func(path string, root walker.FS, depth int) (stop bool) {
-		return environment.Git.IsRepository(cmd.Context(), path)
-	}, walker.Params{
-		Root: walker.NewRealFS(environment.Root, false),
-	})
+	results, err := sweepRoot(cmd.Context(), environment)
 	if err != nil {
-		return fmt.Errorf("%w: %w", errSweepScan, err)
+		return err
 	}
 
 	for _, r := range results {
 		if _, err := fmt.Fprintln(cmd.OutOrStdout(), r); err != nil {
 			return fmt.Errorf("%w: %w", errGenericOutput, err)
 		}
+		if !s.Delete {
+			continue
+		}
+		if err := removeEmptyDir(environment, r); err != nil {
+			return fmt.Errorf("%q: %w: %w", r, errSweepDelete, err)
+		}
 	}
 	return nil
 }
+
+// sweepRoot returns the empty directories within the root directories of environment.
+// Scan-only roots are not swept.
+// Paths in exclude are treated as if they did not exist.
+func sweepRoot(ctx context.Context, environment *env.Env, exclude ...string) ([]string, error) {
+	var results []string
+	for _, root := range environment.Roots {
+		empty, err := sweepFolder(ctx, environment, root, exclude...)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, empty...)
+	}
+	return results, nil
+}
+
+// sweepFolder returns the empty directories within folder.
+// Paths in exclude are treated as if they did not exist.
+func sweepFolder(ctx context.Context, environment *env.Env, folder string, exclude ...string) ([]string, error) {
+	results, err := walker.Sweep(func(path string, root walker.FS, depth int) (stop bool) {
+		return environment.Git.IsRepository(ctx, path)
+	}, walker.Params{
+		Root: walker.NewIgnoreFS(walker.NewExcludeFS(walker.NewRealFS(folder, false), exclude...), environment.Junk()...),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSweepScan, err)
+	}
+	return results, nil
+}
+
+// sweepParents returns the parent directories of paths within folder that are empty once paths are removed.
+// Directories are ordered such that they can be removed in order.
+// The folder itself is never returned.
+func sweepParents(ctx context.Context, environment *env.Env, folder string, paths ...string) ([]string, error) {
+	empty, err := sweepFolder(ctx, environment, folder, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	folder = filepath.Clean(folder)
+	parents := make([]string, 0, len(empty))
+	for _, dir := range empty {
+		if filepath.Clean(dir) != folder && isParentOfAny(dir, paths) {
+			parents = append(parents, dir)
+		}
+	}
+	return parents, nil
+}
+
+// sweepRootParents is like sweepParents, but uses the root containing each path as the folder.
+// Paths outside of any root are ignored.
+func sweepRootParents(ctx context.Context, environment *env.Env, paths ...string) ([]string, error) {
+	byRoot := make(map[string][]string)
+	for _, path := range paths {
+		root, _, ok := environment.RootOf(path)
+		if !ok {
+			continue
+		}
+		byRoot[root] = append(byRoot[root], path)
+	}
+
+	var parents []string
+	for _, root := range slices.Sorted(maps.Keys(byRoot)) {
+		rootParents, err := sweepParents(ctx, environment, root, byRoot[root]...)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, rootParents...)
+	}
+	return parents, nil
+}
+
+// removeEmptyDir removes dir, which must be empty except for junk files.
+// Junk files directly within dir are removed first.
+func removeEmptyDir(environment *env.Env, dir string) error {
+	for _, name := range environment.Junk() {
+		path := filepath.Join(dir, name)
+		if isDir, err := fsx.IsDirectory(path, false); err != nil || isDir {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove junk file: %w", err)
+		}
+	}
+
+	if err := os.Remove(dir); err != nil {
+		return fmt.Errorf("failed to remove directory: %w", err)
+	}
+	return nil
+}
+
+// isParentOfAny checks if dir is a strict parent directory of any of paths.
+func isParentOfAny(dir string, paths []string) bool {
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	for _, path := range paths {
+		if strings.HasPrefix(filepath.Clean(path), prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -10,7 +10,7 @@ import (
 	"go.tkw01536.de/ggman/internal/mockenv"
 )
 
-//spellchecker:words GGROOT workdir
+//spellchecker:words GGROOT workdir nolint paralleltest
 
 func TestCommandSweep(t *testing.T) {
 	t.Parallel()
@@ -70,3 +70,82 @@ func TestCommandSweep(t *testing.T) {
 		})
 	}
 }
+
+//nolint:paralleltest
+func TestCommandSweep_delete(t *testing.T) {
+	mock := mockenv.NewMockEnv(t)
+	mock.SetJunk(".DS_Store", "Thumbs.db")
+
+	path := mock.Clone(t.Context(), "https://github.com/hello/world.git", "github.com", "hello", "world")
+	base := filepath.Join(path, "..", "..", "..")
+
+	mkdir := func(s string, files ...string) {
+		path := filepath.Join(base, s)
+		err := os.MkdirAll(path, 0750)
+		if err != nil {
+			panic(err)
+		}
+		for _, f := range files {
+			if err := os.WriteFile(filepath.Join(path, f), nil, 0600); err != nil {
+				panic(err)
+			}
+		}
+	}
+	mkdir(filepath.Join("github.com", "empty", "empty1"))
+	mkdir(filepath.Join("github.com", "empty", "junk"), ".DS_Store", "Thumbs.db")
+	mkdir(filepath.Join("github.com", "full"), ".DS_Store", "file")
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"list empty directories including junk",
+			"",
+			[]string{"sweep"},
+
+			0,
+			"${GGROOT github.com empty empty1}\n${GGROOT github.com empty junk}\n${GGROOT github.com empty}\n",
+			"",
+		},
+		{
+			"delete empty directories",
+			"",
+			[]string{"sweep", "--delete"},
+
+			0,
+			"${GGROOT github.com empty empty1}\n${GGROOT github.com empty junk}\n${GGROOT github.com empty}\n",
+			"",
+		},
+		{
+			"nothing left to delete",
+			"",
+			[]string{"sweep", "--delete"},
+
+			0,
+			"",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+
+	// the full directory must still exist
+	if _, err := os.Stat(filepath.Join(base, "github.com", "full", ".DS_Store")); err != nil {
+		t.Errorf("junk in non-empty directory was removed: %v", err)
+	}
+}
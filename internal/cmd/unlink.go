@@ -0,0 +1,146 @@
+package cmd
+
+//spellchecker:words path filepath github cobra ggman internal pkglib exit
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/pkglib/exit"
+)
+
+//spellchecker:words positionals wrapcheck GGROOT
+
+func NewUnlinkCommand() *cobra.Command {
+	impl := new(unlink)
+
+	cmd := &cobra.Command{
+		Use:   "unlink PATH",
+		Short: "Remove a symlink created by \"ggman link\"",
+		Long: `Unlink removes a symlink within '$GGROOT' previously created by 'ggman link'.
+
+PATH may either be the symlink itself, or the external repository it points to.
+The external repository is left untouched.
+Parent directories that become empty are removed as well.
+
+For example
+
+    ggman unlink ~/go/src/github.com/hello/world
+
+removes the symlink at '$GGROOT/github.com/hello/world'.`,
+		Args: cobra.ExactArgs(1),
+
+		PreRunE: impl.ParseArgs,
+		RunE:    impl.Exec,
+	}
+
+	return cmd
+}
+
+type unlink struct {
+	Positionals struct {
+		Path string
+	}
+}
+
+var (
+	errUnlinkNotALink   = exit.NewErrorWithCode("failed to find link: path is neither a link nor linked to", env.ExitGeneric)
+	errUnlinkOutside    = exit.NewErrorWithCode("failed to remove link: link is not inside of the root folder", env.ExitGeneric)
+	errUnlinkRemove     = exit.NewErrorWithCode("failed to remove link", env.ExitGeneric)
+	errUnlinkRemoveDirs = exit.NewErrorWithCode("failed to remove empty directory", env.ExitGeneric)
+)
+
+func (u *unlink) ParseArgs(cmd *cobra.Command, args []string) error {
+	u.Positionals.Path = args[0]
+	return nil
+}
+
+func (u *unlink) Exec(cmd *cobra.Command, args []string) error {
+	environment, err := env.GetEnv(cmd, env.Requirement{
+		NeedsRoot: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
+	}
+
+	path, err := environment.Abs(u.Positionals.Path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUnlinkNotALink, err)
+	}
+
+	link, err := u.findLink(cmd, environment, path)
+	if err != nil {
+		return err
+	}
+
+	root, scanOnly, ok := environment.RootOf(link)
+	if !ok || scanOnly || !isParentOfAny(root, []string{link}) {
+		return fmt.Errorf("%q: %w", link, errUnlinkOutside)
+	}
+
+	target, err := os.Readlink(link)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUnlinkRemove, err)
+	}
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Unlinking %q -> %q\n", link, target); err != nil {
+		return fmt.Errorf("%w: %w", errGenericOutput, err)
+	}
+
+	if err := os.Remove(link); err != nil {
+		return fmt.Errorf("%w: %w", errUnlinkRemove, err)
+	}
+
+	// remove now empty parents
+	parents, err := sweepParents(cmd.Context(), environment, root, link)
+	if err != nil {
+		return err
+	}
+	for _, dir := range parents {
+		if err := removeEmptyDir(environment, dir); err != nil {
+			return fmt.Errorf("%q: %w: %w", dir, errUnlinkRemoveDirs, err)
+		}
+	}
+
+	return nil
+}
+
+// findLink finds the link to be removed.
+// path is either the link itself, or a repository that is linked to.
+func (u *unlink) findLink(cmd *cobra.Command, environment *env.Env, path string) (string, error) {
+	if isSymlink(path) {
+		return path, nil
+	}
+
+	remote, err := environment.Git.GetRemote(cmd.Context(), path, "")
+	if err != nil || remote == "" {
+		return "", fmt.Errorf("%q: %w", path, errUnlinkNotALink)
+	}
+	link, err := environment.Local(env.ParseURL(remote))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", env.ErrUnableLocalPath, err)
+	}
+
+	if !isSymlink(link) {
+		return "", fmt.Errorf("%q: %w", path, errUnlinkNotALink)
+	}
+
+	// make sure the link actually points to path
+	linkTarget, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return "", fmt.Errorf("%q: %w", path, errUnlinkNotALink)
+	}
+	pathTarget, err := filepath.EvalSymlinks(path)
+	if err != nil || linkTarget != pathTarget {
+		return "", fmt.Errorf("%q: %w", path, errUnlinkNotALink)
+	}
+	return link, nil
+}
+
+// isSymlink checks if path is a symbolic link.
+func isSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&fs.ModeSymlink != 0
+}
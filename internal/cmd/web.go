@@ -10,7 +10,6 @@ import (
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
 	"go.tkw01536.de/ggman/internal/env"
-	"go.tkw01536.de/ggman/internal/path"
 	"go.tkw01536.de/pkglib/exit"
 )
 
@@ -50,7 +49,12 @@ For example
     ggman web godoc
 
 opens the current repository on pkg.go.dev.
-The '--list-bases' flag shows supported base URLs.`,
+The '--list-bases' flag shows supported base URLs.
+
+When no base URL is given and the CANFILE uses the structured format, the 'web' key of the matching rule is used as a base URL instead.
+
+The '--force-repo-here' flag pretends there is a repository in the current directory.
+Its url is determined from the path relative to the root directory, reversing the layout used by 'ggman where'.`,
 		Args: cobra.MaximumNArgs(1),
 
 		PreRunE: impl.ParseArgs,
@@ -131,6 +135,7 @@ func (w *web) ParseArgs(cmd *cobra.Command, args []string) error {
 
 var (
 	errWebNoRelative        = exit.NewErrorWithCode(`failed to use "--relative": not inside GGROOT`, env.ExitInvalidRepo)
+	errWebNoLayout          = exit.NewErrorWithCode(`failed to use "--force-repo-here": layout does not determine a url`, env.ExitInvalidRepo)
 	errWebNoRemote          = exit.NewErrorWithCode("failed to find remote: repository does not have a remote", env.ExitInvalidRepo)
 	errWebOutsideRepository = exit.NewErrorWithCode("failed to resolve repository: not inside a ggman-controlled repository", env.ExitInvalidRepo)
 
@@ -144,7 +149,8 @@ func (w *web) Exec(cmd *cobra.Command, args []string) error {
 	}
 
 	environment, err := env.GetEnv(cmd, env.Requirement{
-		NeedsRoot: true,
+		NeedsRoot:    true,
+		NeedsCanFile: true,
 	})
 	if err != nil {
 		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
@@ -183,6 +189,13 @@ func (w *web) Exec(cmd *cobra.Command, args []string) error {
 			}
 		}
 
+		// if there is no base argument, use the one from the CANFILE
+		if len(w.Positionals.Base) == 0 {
+			if line, ok := environment.CanLine(url); ok && line.Web != "" {
+				base = line.Web
+			}
+		}
+
 		// set the hostname to the base
 		url.HostName = base
 
@@ -285,17 +298,22 @@ func (w *web) getRemoteURLFake(environment *env.Env) (root string, remote string
 		return "", "", "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	// check that the
-	if !path.HasChild(environment.Root, workdir) {
+	// find the root directory containing the working directory
+	root, _, ok := environment.RootOf(workdir)
+	if !ok {
 		return "", "", "", errWebNoRelative
 	}
 
 	// determine the relative path to the root directory
-	relPath, err := filepath.Rel(environment.Root, workdir)
+	relPath, err := filepath.Rel(root, workdir)
 	if err != nil {
 		return "", "", "", errWebNoRelative
 	}
 
-	// turn it into a fake url by prepending a protocol
-	return "", "file://" + relPath, "", nil
+	// turn it into a fake url according to the layout
+	remote, ok = environment.RemoteOf(relPath)
+	if !ok {
+		return "", "", "", errWebNoLayout
+	}
+	return "", remote, "", nil
 }
@@ -455,3 +455,152 @@ func TestCommandURL_MultipleRemotes(t *testing.T) {
 		mock.AssertOutput(t, "feature branch with --remote: Stderr", stderr, "")
 	})
 }
+
+func TestCommandURL_CanFile(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[[rule]]
+pattern = "^git.example.com"
+canonical = "git@^:$.git"
+web = "https://web.example.com"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	examplePath := mock.Clone(t.Context(), "git@git.example.com:hello/world.git", "git.example.com", "hello", "world")
+	githubPath := mock.Clone(t.Context(), "git@github.com:hello/world.git", "github.com", "hello", "world")
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"url uses base from CANFILE",
+			examplePath,
+			[]string{"url"},
+			0,
+			"https://web.example.com/hello/world\n",
+			"",
+		},
+		{
+			"url with base argument ignores CANFILE",
+			examplePath,
+			[]string{"url", "godoc"},
+			0,
+			"https://pkg.go.dev/git.example.com/hello/world\n",
+			"",
+		},
+		{
+			"url without web base in CANFILE",
+			githubPath,
+			[]string{"url"},
+			0,
+			"https://github.com/hello/world\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandURL_layout(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	mock.SetLayout("src/{host}/{owner}/{name}")
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[[rule]]
+pattern = "^gitlab.com"
+canonical = "git@^:$.git"
+layout = "{host}/{group}/{name}"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	globalPath := mock.Resolve("src", "example.com", "hello", "world")
+	rulePath := mock.Resolve("gitlab.com", "group", "subgroup", "repo")
+	unknownPath := mock.Resolve("other")
+	for _, path := range []string{globalPath, rulePath, unknownPath} {
+		if err := os.MkdirAll(path, 0750); err != nil {
+			panic(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"faked root uses global layout",
+			globalPath,
+			[]string{"url", "--force-repo-here"},
+			0,
+			"https://example.com/hello/world\n",
+			"",
+		},
+		{
+			"faked root uses layout of rule",
+			rulePath,
+			[]string{"url", "--force-repo-here"},
+			0,
+			"https://gitlab.com/group/subgroup/repo\n",
+			"",
+		},
+		{
+			"faked root not matching any layout",
+			unknownPath,
+			[]string{"url", "--force-repo-here"},
+			6,
+			"",
+			"failed to use \"--force-repo-here\": layout does not determine a url\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
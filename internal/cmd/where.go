@@ -1,14 +1,15 @@
 package cmd
 
-//spellchecker:words github cobra ggman internal
+//spellchecker:words github cobra ggman internal pkglib exit
 import (
 	"fmt"
 
 	"github.com/spf13/cobra"
 	"go.tkw01536.de/ggman/internal/env"
+	"go.tkw01536.de/pkglib/exit"
 )
 
-//spellchecker:words positionals wrapcheck GGROOT GGNORM
+//spellchecker:words positionals wrapcheck GGROOT GGNORM GGLAYOUT GOPATH CANFILE
 
 func NewWhereCommand() *cobra.Command {
 	impl := new(where)
@@ -22,9 +23,28 @@ Each path segment corresponds to a URL component.
 The root defaults to '~/Projects' and can be customized via '$GGROOT'.
 The 'ggman root' alias prints the root directory.
 
+Like '$GOPATH', '$GGROOT' may contain several directories separated by the os-specific path list separator, ':' on unix-like systems.
+Repositories are cloned into the first directory, unless the matching rule of a structured CANFILE sets a different 'root', see 'ggman canon'.
+For example, a rule with pattern 'gitlab.work.com' and root '~/Work' places work repositories into '~/Work' and everything else into the first directory.
+
 For example, 'https://github.com/hello/world.git' clones to '$GGROOT/github.com/hello/world'.
 This works for any URL, not just 'github.com'.
 
+The '$GGLAYOUT' variable customizes the layout of paths within the root directory using a template.
+The 'layout' key of a rule in a structured CANFILE overrides it for matching repositories.
+Templates consist of segments separated by '/' and may contain the following references:
+
+- '{host}' => the first component, typically the hostname
+- '{owner}' => the second component, typically a user or organization
+- '{name}' => the last component, typically the repository name
+- '{group}' => all components between the first and the last one
+- '{path}' => all components except for the first one
+- '{N}' => the Nth component, negative numbers count from the end
+
+References may use the 'lower' and 'upper' modifiers, e.g. '{lower:owner}'.
+For example, the layout '{owner}/{name}' clones 'https://github.com/hello/world.git' to '$GGROOT/hello/world'.
+The default layout is '{host}/{path}'.
+
 Since ggman 1.12, path resolution considers existing directories.
 Existing sub-paths differing only by case are reused.
 
@@ -40,13 +60,18 @@ The '$GGNORM' environment variable controls normalization:
 
 - 'smart' => use first matching path, prefer exact matches (default)
 - 'fold' => fold paths, do not prefer exact matches
-- 'none' => always use exact paths (legacy behavior)`,
+- 'none' => always use exact paths (legacy behavior)
+
+The '--strict' flag parses the URL strictly, see 'ggman comps'.`,
 		Args: cobra.ExactArgs(1),
 
 		PreRunE: impl.ParseArgs,
 		RunE:    impl.Exec,
 	}
 
+	flags := cmd.Flags()
+	flags.BoolVarP(&impl.Strict, "strict", "s", false, "parse the URL strictly and fail on invalid or ambiguous input")
+
 	return cmd
 }
 
@@ -54,8 +79,11 @@ type where struct {
 	Positionals struct {
 		URL string
 	}
+	Strict bool
 }
 
+var errWhereInvalidURL = exit.NewErrorWithCode("failed to parse URL", env.ExitInvalidRepo)
+
 func (w *where) ParseArgs(cmd *cobra.Command, args []string) error {
 	w.Positionals.URL = args[0]
 	return nil
@@ -63,13 +91,22 @@ func (w *where) ParseArgs(cmd *cobra.Command, args []string) error {
 
 func (w *where) Exec(cmd *cobra.Command, args []string) error {
 	environment, err := env.GetEnv(cmd, env.Requirement{
-		NeedsRoot: true,
+		NeedsRoot:    true,
+		NeedsCanFile: true,
 	})
 	if err != nil {
 		return fmt.Errorf("%w: %w", errGenericEnvironment, err)
 	}
 
-	localPath, err := environment.Local(env.ParseURL(w.Positionals.URL))
+	url := environment.ParseURL(w.Positionals.URL)
+	if w.Strict {
+		url, err = environment.ParseURLStrict(w.Positionals.URL)
+		if err != nil {
+			return fmt.Errorf("%w %q: %w", errWhereInvalidURL, w.Positionals.URL, err)
+		}
+	}
+
+	localPath, err := environment.Local(url)
 	if err != nil {
 		return fmt.Errorf("%w: %w", env.ErrUnableLocalPath, err)
 	}
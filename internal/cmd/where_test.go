@@ -1,7 +1,9 @@
 package cmd_test
 
-//spellchecker:words testing ggman internal mockenv
+//spellchecker:words path filepath testing ggman internal mockenv
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"go.tkw01536.de/ggman/internal/cmd"
@@ -33,6 +35,214 @@ func TestCommandWhere(t *testing.T) {
 			"${GGROOT github.com hello world}\n",
 			"",
 		},
+
+		{
+			"show directory of strictly parsed repository",
+			"",
+			[]string{"where", "--strict", "https://github.com/hello/w%6Frld.git#readme"},
+
+			0,
+			"${GGROOT github.com hello world}\n",
+			"",
+		},
+
+		{
+			"fail on invalid url when parsing strictly",
+			"",
+			[]string{"where", "--strict", "ssh://host.xz:abc/repo.git"},
+
+			6,
+			"",
+			"failed to parse URL \"ssh://host.xz:abc/repo.git\": \"abc\": invalid port\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandWhere_alias(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[alias]
+"gh:" = "git@github.com:"
+"work:" = "https://git.work.example.com/"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"expands alias",
+			"",
+			[]string{"where", "gh:hello/world"},
+
+			0,
+			"${GGROOT github.com hello world}\n",
+			"",
+		},
+		{
+			"expands other alias",
+			"",
+			[]string{"where", "work:payments/ledger"},
+
+			0,
+			"${GGROOT git.work.example.com payments ledger}\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandWhere_roots(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	work := mock.Resolve("..", "work")
+	mock.SetRoots(work)
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[[rule]]
+pattern = "^gitlab.work.com"
+canonical = "git@^:$.git"
+root = '`+work+`'
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"uses root of matching rule",
+			"",
+			[]string{"where", "git@gitlab.work.com:hello/world.git"},
+
+			0,
+			"${GGROOT .. work gitlab.work.com hello world}\n",
+			"",
+		},
+		{
+			"uses primary root by default",
+			"",
+			[]string{"where", "git@github.com:hello/world.git"},
+
+			0,
+			"${GGROOT github.com hello world}\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, stdout, stderr := mock.Run(t, nil, cmd.NewCommand, tt.workdir, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("Code = %d, wantCode = %d", code, tt.wantCode)
+			}
+			mock.AssertOutput(t, "Stdout", stdout, tt.wantStdout)
+			mock.AssertOutput(t, "Stderr", stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCommandWhere_layout(t *testing.T) {
+	t.Parallel()
+
+	mock := mockenv.NewMockEnv(t)
+	mock.SetLayout("{owner}/{name}")
+
+	CANFILE := filepath.Join(t.TempDir(), "canfile.toml")
+	mock.SetCanfile(CANFILE)
+	if err := os.WriteFile(CANFILE, []byte(`
+[[rule]]
+pattern = "^gitlab.com"
+canonical = "git@^:$.git"
+layout = "{host}/{name}"
+
+[[rule]]
+canonical = "git@^:$.git"
+`), 0600); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		workdir string
+		args    []string
+
+		wantCode   uint8
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"uses global layout",
+			"",
+			[]string{"where", "git@github.com:hello/world.git"},
+
+			0,
+			"${GGROOT hello world}\n",
+			"",
+		},
+		{
+			"uses layout of matching rule",
+			"",
+			[]string{"where", "git@gitlab.com:group/subgroup/repo.git"},
+
+			0,
+			"${GGROOT gitlab.com repo}\n",
+			"",
+		},
 	}
 
 	for _, tt := range tests {
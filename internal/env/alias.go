@@ -0,0 +1,67 @@
+package env
+
+//spellchecker:words maps slices strings
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+//spellchecker:words canfile
+
+// Alias is a shorthand for the beginning of a URL.
+// It works like the 'url.<base>.insteadOf' setting of git.
+type Alias struct {
+	Prefix string // e.g. "gh:"
+	Base   string // e.g. "git@github.com:"
+}
+
+// Aliases is a list of aliases.
+type Aliases []Alias
+
+// NewAliases creates a new list of aliases from a map from prefix to base.
+// The aliases are sorted by prefix.
+func NewAliases(m map[string]string) Aliases {
+	if len(m) == 0 {
+		return nil
+	}
+
+	aliases := make(Aliases, 0, len(m))
+	for _, prefix := range slices.Sorted(maps.Keys(m)) {
+		aliases = append(aliases, Alias{Prefix: prefix, Base: m[prefix]})
+	}
+	return aliases
+}
+
+// Expand expands the alias with the longest prefix of s.
+// If no alias matches, returns s unchanged.
+//
+// Like git, only a single alias is expanded.
+func (aliases Aliases) Expand(s string) string {
+	best := -1
+	for i, alias := range aliases {
+		if alias.Prefix == "" || !strings.HasPrefix(s, alias.Prefix) {
+			continue
+		}
+		if best == -1 || len(alias.Prefix) > len(aliases[best].Prefix) {
+			best = i
+		}
+	}
+
+	if best == -1 {
+		return s
+	}
+	return aliases[best].Base + s[len(aliases[best].Prefix):]
+}
+
+// ParseURL expands aliases in s and then parses it into a URL.
+// See [Aliases.Expand] and [ParseURL].
+func (aliases Aliases) ParseURL(s string) URL {
+	return ParseURL(aliases.Expand(s))
+}
+
+// ParseURLStrict expands aliases in s and then strictly parses it into a URL.
+// See [Aliases.Expand] and [ParseURLStrict].
+func (aliases Aliases) ParseURLStrict(s string) (URL, error) {
+	return ParseURLStrict(aliases.Expand(s))
+}
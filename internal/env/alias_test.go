@@ -0,0 +1,40 @@
+package env_test
+
+//spellchecker:words testing ggman internal
+import (
+	"testing"
+
+	"go.tkw01536.de/ggman/internal/env"
+)
+
+func TestAliases_Expand(t *testing.T) {
+	t.Parallel()
+
+	aliases := env.NewAliases(map[string]string{
+		"gh:":        "git@github.com:",
+		"work:":      "git@git.work.example.com:",
+		"work:team/": "git@team.work.example.com:",
+		"":           "ignored",
+	})
+
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"no alias", "git@github.com:hello/world.git", "git@github.com:hello/world.git"},
+		{"simple alias", "gh:hello/world", "git@github.com:hello/world"},
+		{"longest alias wins", "work:team/payments", "git@team.work.example.com:payments"},
+		{"shorter alias", "work:payments/ledger", "git@git.work.example.com:payments/ledger"},
+		{"alias only at the start", "x/gh:hello", "x/gh:hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := aliases.Expand(tt.s); got != tt.want {
+				t.Errorf("Aliases.Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
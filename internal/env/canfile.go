@@ -1,20 +1,57 @@
 package env
 
-//spellchecker:words bufio errors strings
+//spellchecker:words bufio errors path filepath strings github burntsushi toml
 import (
 	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
+
+	"github.com/BurntSushi/toml"
 )
 
 //spellchecker:words canfile unmarshals
 
 // CanLine represents a line within in the canonical configuration file.
+//
+// In the line-based format, only Pattern, Canonical and Push can be set.
+// The structured format (see [CanFile.ReadTOMLFrom]) can set all fields.
 type CanLine struct {
 	Pattern   string
 	Canonical string
+
+	// Push is an optional CANSPEC used for push urls.
+	// When empty, push urls are left untouched.
+	Push string
+
+	// CloneArgs are additional arguments passed to 'git clone'.
+	CloneArgs []string
+
+	// Branch is the branch to check out when cloning.
+	// When empty, the default branch of the remote is used.
+	Branch string
+
+	// Web is the base url used by 'ggman web' and 'ggman url'.
+	// When empty, the hostname of the repository is used.
+	Web string
+
+	// Config are git config values to set after cloning.
+	Config map[string]string
+
+	// Root is the root directory matching repositories are cloned into.
+	// A leading '~' refers to the home directory.
+	// When empty, the primary root directory is used.
+	Root string
+
+	// Layout is the layout template of matching repositories within their root directory, see [Layout].
+	// When empty, the global layout is used.
+	Layout string
+
+	// Extended indicates if Canonical and Push use the extended CANSPEC syntax, see [CanSpec].
+	// It is set for all rules read from the structured format.
+	Extended bool
 }
 
 var errCanLineEmpty = errors.New("CanLine.UnmarshalText: CanLine is empty")
@@ -40,9 +77,15 @@ func (cl *CanLine) UnmarshalText(text []byte) error {
 	case 1:
 		cl.Pattern = ""
 		cl.Canonical = fields[0]
+		cl.Push = ""
+	case 2:
+		cl.Pattern = fields[0]
+		cl.Canonical = fields[1]
+		cl.Push = ""
 	default:
 		cl.Pattern = fields[0]
 		cl.Canonical = fields[1]
+		cl.Push = fields[2]
 	}
 
 	return nil
@@ -84,6 +127,90 @@ func (cf *CanFile) ReadFrom(reader io.Reader) (int64, error) {
 	return bytes, nil
 }
 
+// canFileTOML is the structure of a CANFILE in the structured format.
+type canFileTOML struct {
+	Aliases map[string]string `toml:"alias"`
+	Rules   []canRuleTOML     `toml:"rule"`
+}
+
+// canRuleTOML is the structure of a single rule in the structured format.
+// It is separate from [CanLine], as CanLine implements [encoding.TextUnmarshaler].
+type canRuleTOML struct {
+	Pattern   string            `toml:"pattern"`
+	Canonical string            `toml:"canonical"`
+	Push      string            `toml:"push"`
+	CloneArgs []string          `toml:"clone-args"`
+	Branch    string            `toml:"branch"`
+	Web       string            `toml:"web"`
+	Config    map[string]string `toml:"config"`
+	Root      string            `toml:"root"`
+	Layout    string            `toml:"layout"`
+	Extended  bool              `toml:"-"`
+}
+
+var (
+	errCanFileInvalidTOML = errors.New("unable to parse CANFILE")
+	errCanFileUndecoded   = errors.New("unknown keys")
+	errCanFileNoCanonical = errors.New("missing key \"canonical\"")
+)
+
+// ReadTOMLFrom populates this CanFile with CanLines read from the given reader in the structured format.
+// It furthermore returns the aliases defined in the file.
+//
+// The structured format is a TOML document with one '[[rule]]' table per CanLine.
+// The keys of each table are 'pattern', 'canonical', 'push', 'clone-args', 'branch', 'web', 'config', 'root' and 'layout'.
+// Each rule must have a canonical key; unknown keys result in an error.
+//
+// Aliases are defined in an optional '[alias]' table, mapping prefixes to their expansion.
+func (cf *CanFile) ReadTOMLFrom(reader io.Reader) (aliases Aliases, err error) {
+	*cf = nil
+
+	var file canFileTOML
+	md, err := toml.NewDecoder(reader).Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCanFileInvalidTOML, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("%w: %w: %v", errCanFileInvalidTOML, errCanFileUndecoded, undecoded)
+	}
+
+	// an empty canonical is a valid CANSPEC, so check that each rule defines it explicitly.
+	// md.Keys() lists the key "rule" once for every rule, followed by the keys of that rule.
+	defined := make([]bool, 0, len(file.Rules))
+	for _, key := range md.Keys() {
+		switch {
+		case len(key) == 1 && key[0] == "rule":
+			defined = append(defined, false)
+		case len(key) == 2 && key[0] == "rule" && key[1] == "canonical":
+			defined[len(defined)-1] = true
+		}
+	}
+	for i, ok := range defined {
+		if !ok {
+			return nil, fmt.Errorf("%w: rule %d: %w", errCanFileInvalidTOML, i+1, errCanFileNoCanonical)
+		}
+	}
+
+	for i, rule := range file.Rules {
+		if _, err := ParseLayout(rule.Layout); err != nil {
+			return nil, fmt.Errorf("%w: rule %d: %w", errCanFileInvalidTOML, i+1, err)
+		}
+	}
+
+	for _, rule := range file.Rules {
+		line := CanLine(rule)
+		line.Extended = true
+		*cf = append(*cf, line)
+	}
+	return NewAliases(file.Aliases), nil
+}
+
+// IsStructuredCanFile checks if the CANFILE at the given path uses the structured format.
+// This is the case when it has a '.toml' extension.
+func IsStructuredCanFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
 var defaultCanFile = []string{
 	"git@^:$.git",
 }
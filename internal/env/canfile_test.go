@@ -9,7 +9,7 @@ import (
 	"go.tkw01536.de/ggman/internal/env"
 )
 
-//spellchecker:words canfile
+//spellchecker:words canfile canonnical
 
 func TestCanLine_UnmarshalText(t *testing.T) {
 	t.Parallel()
@@ -23,9 +23,10 @@ func TestCanLine_UnmarshalText(t *testing.T) {
 		wantCl  *env.CanLine
 		wantErr bool
 	}{
-		{"reading pattern-only line", args{[]byte("git@^:$.git")}, &env.CanLine{"", "git@^:$.git"}, false},
-		{"reading normal line", args{[]byte("* git@^:$.git")}, &env.CanLine{"*", "git@^:$.git"}, false},
-		{"reading line with extra args", args{[]byte("* git@^:$.git extra stuff")}, &env.CanLine{"*", "git@^:$.git"}, false},
+		{"reading pattern-only line", args{[]byte("git@^:$.git")}, &env.CanLine{Canonical: "git@^:$.git"}, false},
+		{"reading normal line", args{[]byte("* git@^:$.git")}, &env.CanLine{Pattern: "*", Canonical: "git@^:$.git"}, false},
+		{"reading line with push spec", args{[]byte("* https://^/$.git git@^:$.git")}, &env.CanLine{Pattern: "*", Canonical: "https://^/$.git", Push: "git@^:$.git"}, false},
+		{"reading line with extra args", args{[]byte("* git@^:$.git push extra stuff")}, &env.CanLine{Pattern: "*", Canonical: "git@^:$.git", Push: "push"}, false},
 		{"empty line is not read", args{[]byte("")}, &env.CanLine{}, true},
 		{"comment line is not read", args{[]byte("  //* git@^:$.git extra stuff")}, &env.CanLine{}, true},
 	}
@@ -78,7 +79,7 @@ git@^:$.git
 				env.CanLine{Pattern: "^git.example.com", Canonical: "https://$.git"},
 				env.CanLine{Pattern: "^git2.example.com/my_namespace", Canonical: "git@!ssh.example.com:$.git"},
 				env.CanLine{Pattern: "^git2.example.com", Canonical: "$$"},
-				env.CanLine{Pattern: "", Canonical: "git@^:$.git"},
+				env.CanLine{Canonical: "git@^:$.git"},
 			},
 			wantErr: false,
 		},
@@ -101,3 +102,118 @@ git@^:$.git
 		})
 	}
 }
+
+func TestCanFile_ReadTOMLFrom(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		src         string
+		wantCF      env.CanFile
+		wantAliases env.Aliases
+		wantErr     bool
+	}{
+		{
+			name:    "empty",
+			src:     "",
+			wantCF:  env.CanFile(nil),
+			wantErr: false,
+		},
+		{
+			name: "canfile with several rules",
+			src: `
+[alias]
+"ex:" = "git@git.example.com:"
+"gh:" = "git@github.com:"
+
+# for anything on git.example.com, clone with https and a shallow history
+[[rule]]
+pattern = "^git.example.com"
+canonical = "https://$.git"
+push = "git@^:$.git"
+clone-args = ["--depth", "1"]
+branch = "develop"
+web = "https://web.example.com"
+root = "~/Work"
+layout = "{host}/{name}"
+
+[rule.config]
+"user.email" = "me@example.com"
+
+# by default, clone via ssh
+[[rule]]
+canonical = "git@^:$.git"
+`,
+			wantCF: env.CanFile{
+				env.CanLine{
+					Pattern:   "^git.example.com",
+					Canonical: "https://$.git",
+					Push:      "git@^:$.git",
+					CloneArgs: []string{"--depth", "1"},
+					Branch:    "develop",
+					Web:       "https://web.example.com",
+					Config:    map[string]string{"user.email": "me@example.com"},
+					Root:      "~/Work",
+					Layout:    "{host}/{name}",
+					Extended:  true,
+				},
+				env.CanLine{Canonical: "git@^:$.git", Extended: true},
+			},
+			wantAliases: env.Aliases{
+				{Prefix: "ex:", Base: "git@git.example.com:"},
+				{Prefix: "gh:", Base: "git@github.com:"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty canonical",
+			src:     "[[rule]]\ncanonical = \"\"\n",
+			wantCF:  env.CanFile{env.CanLine{Extended: true}},
+			wantErr: false,
+		},
+		{
+			name:    "missing canonical",
+			src:     "[[rule]]\ncanonical = \"git@^:$.git\"\n[[rule]]\npattern = \"*\"\n",
+			wantCF:  env.CanFile(nil),
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			src:     "[[rule]]\ncanonical = \"git@^:$.git\"\ncanonnical = \"typo\"\n",
+			wantCF:  env.CanFile(nil),
+			wantErr: true,
+		},
+		{
+			name:    "invalid layout",
+			src:     "[[rule]]\ncanonical = \"git@^:$.git\"\n[[rule]]\ncanonical = \"git@^:$.git\"\nlayout = \"{host}/{repo}\"\n",
+			wantCF:  env.CanFile(nil),
+			wantErr: true,
+		},
+		{
+			name:    "invalid toml",
+			src:     "[[rule]\n",
+			wantCF:  env.CanFile(nil),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var cf env.CanFile
+
+			gotAliases, gotErr := cf.ReadTOMLFrom(strings.NewReader(tt.src))
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("CanFile.ReadTOMLFrom() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(cf, tt.wantCF) {
+				t.Errorf("CanFile.ReadTOMLFrom() = %#v, want %#v", cf, tt.wantCF)
+			}
+			if !reflect.DeepEqual(gotAliases, tt.wantAliases) {
+				t.Errorf("CanFile.ReadTOMLFrom() aliases = %#v, want %#v", gotAliases, tt.wantAliases)
+			}
+		})
+	}
+}
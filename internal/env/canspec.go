@@ -0,0 +1,321 @@
+package env
+
+//spellchecker:words slices strconv strings unicode
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//spellchecker:words canspec
+
+// CanSpec is a parsed canonical specification (CANSPEC).
+//
+// A CANSPEC is a string that describes how to build a canonical url from the components of a URL.
+// Any character is copied literally, except for the following special ones:
+//
+//	^ -- replaced by the first unused component of the URL
+//	% -- replaced by the second unused component of the URL (commonly username)
+//	! -- consumes a component without using it
+//	$ -- replaced by all unused components of the URL joined with a '/'. Also stops processing of '^', '%', '!' and '$' afterwards.
+//	\ -- escapes the next character, which is copied literally
+//	{ -- starts a reference, which is closed by '}'
+//
+// The characters '\' and '{' are only special in the extended syntax, see [URL.CanonicalExtended].
+// The extended syntax is used for rules of structured CANFILEs, see [CanLine.Extended].
+//
+// A reference has the form '{[modifier:]reference}'.
+// The reference can be one of:
+//
+//	N      -- the Nth component of the URL, starting at 1. Negative numbers count from the end, i.e. -1 is the last component.
+//	^ % $  -- as the respective special characters
+//	scheme, user, password, host, port -- the respective part of the URL, or the empty string if not set
+//
+// The optional modifier can be 'lower' or 'upper', to convert the reference to lower or upper case respectively.
+//
+// Components referenced by index are considered used, and are not used by '^', '%', '!' or '$' afterwards.
+// If there are insufficient components for a special character or reference, it is copied literally.
+// If neither '$' nor a reference by index is used, a '$' is assumed at the end of the CANSPEC.
+//
+// The special CANSPEC '$$' returns the original url unchanged.
+type CanSpec struct {
+	tokens       []canToken
+	implicitRest bool // add the remaining components at the end
+	verbatim     bool // return the url unchanged
+}
+
+// canTokenKind is the kind of a canToken.
+type canTokenKind uint8
+
+const (
+	canLiteral canTokenKind = iota // literal text
+	canFirst                       // first unused component
+	canSecond                      // second unused component
+	canDrop                        // drop the first unused component
+	canRest                        // remaining components
+	canIndex                       // component by index
+	canField                       // part of the url
+)
+
+// canToken is a single token of a CanSpec.
+type canToken struct {
+	kind canTokenKind
+
+	source   string               // source of the token, written literally if there are insufficient components
+	index    int                  // index of the component for canIndex
+	field    func(url URL) string // the field for canField
+	modifier func(string) string  // optional modifier
+}
+
+// canModifiers are the supported modifiers of references.
+var canModifiers = map[string]func(string) string{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// canFields are the supported fields of references.
+var canFields = map[string]func(url URL) string{
+	"scheme":   func(url URL) string { return url.Scheme },
+	"user":     func(url URL) string { return url.User },
+	"password": func(url URL) string { return url.Password },
+	"host":     func(url URL) string { return url.HostName },
+	"port": func(url URL) string {
+		if url.Port == 0 {
+			return ""
+		}
+		return strconv.FormatUint(uint64(url.Port), 10)
+	},
+}
+
+// CanSpecError is returned when a CANSPEC cannot be parsed.
+type CanSpecError struct {
+	Spec     string // the CANSPEC being parsed
+	Position int    // byte offset of the error within Spec
+	Reason   string // human-readable reason
+}
+
+func (cse *CanSpecError) Error() string {
+	return fmt.Sprintf("invalid CANSPEC %q: %s at position %d", cse.Spec, cse.Reason, cse.Position)
+}
+
+// Explain returns a human-readable explanation of the error.
+// It consists of the reason, followed by the CANSPEC and a marker pointing to the position of the error.
+func (cse *CanSpecError) Explain() string {
+	return explainPosition(cse.Spec, cse.Position, cse.Reason)
+}
+
+// explainPosition explains an error at the given byte offset of source.
+func explainPosition(source string, position int, reason string) string {
+	marker := strings.Repeat(" ", utf8.RuneCountInString(source[:position])) + "^"
+	return reason + ":\n\n    " + source + "\n    " + marker
+}
+
+// ParseCanSpec parses a CANSPEC.
+// See [CanSpec] for a description of the syntax.
+//
+// If the CANSPEC is invalid, returns an error of type *[CanSpecError].
+func ParseCanSpec(spec string) (CanSpec, error) {
+	return parseCanSpec(spec, true)
+}
+
+// parseCanSpec parses a CANSPEC.
+// When extended is false, only the special characters '^', '%', '!' and '$' are handled and parsing never fails.
+func parseCanSpec(spec string, extended bool) (cs CanSpec, err error) {
+	if spec == "$$" {
+		cs.verbatim = true
+		return cs, nil
+	}
+
+	var (
+		literal  strings.Builder
+		hasRest  bool // a '$' has been seen
+		hasIndex bool // a reference by index has been seen
+	)
+
+	flush := func() {
+		if literal.Len() == 0 {
+			return
+		}
+		cs.tokens = append(cs.tokens, canToken{kind: canLiteral, source: literal.String()})
+		literal.Reset()
+	}
+
+	for i := 0; i < len(spec); {
+		r, size := utf8.DecodeRuneInString(spec[i:])
+
+		switch {
+		case extended && r == '\\':
+			if i+size == len(spec) {
+				return CanSpec{}, &CanSpecError{Spec: spec, Position: i, Reason: "escape character at end of CANSPEC"}
+			}
+			escaped, escapedSize := utf8.DecodeRuneInString(spec[i+size:])
+			literal.WriteRune(escaped)
+			i += size + escapedSize
+			continue
+
+		case extended && r == '{':
+			end := strings.IndexRune(spec[i:], '}')
+			if end < 0 {
+				return CanSpec{}, &CanSpecError{Spec: spec, Position: i, Reason: "unclosed '{'"}
+			}
+
+			token, reason := parseCanReference(spec[i+1 : i+end])
+			if reason != "" {
+				return CanSpec{}, &CanSpecError{Spec: spec, Position: i, Reason: reason}
+			}
+			token.source = spec[i : i+end+1]
+
+			hasRest = hasRest || token.kind == canRest
+			hasIndex = hasIndex || token.kind == canIndex
+
+			flush()
+			cs.tokens = append(cs.tokens, token)
+			i += end + 1
+			continue
+
+		case !hasRest && (r == '^' || r == '%' || r == '!' || r == '$'):
+			kind := map[rune]canTokenKind{'^': canFirst, '%': canSecond, '!': canDrop, '$': canRest}[r]
+			hasRest = kind == canRest
+
+			flush()
+			cs.tokens = append(cs.tokens, canToken{kind: kind, source: string(r)})
+
+		default:
+			literal.WriteRune(r)
+		}
+
+		i += size
+	}
+	flush()
+
+	cs.implicitRest = !hasRest && !hasIndex
+	return cs, nil
+}
+
+// parseCanReference parses the body of a reference, that is the part between '{' and '}'.
+// If the body is invalid, returns a non-empty reason.
+func parseCanReference(body string) (token canToken, reason string) {
+	if name, ref, ok := strings.Cut(body, ":"); ok {
+		token.modifier, ok = canModifiers[name]
+		if !ok {
+			return canToken{}, fmt.Sprintf("unknown modifier %q (expected 'lower' or 'upper')", name)
+		}
+		body = ref
+	}
+
+	switch body {
+	case "":
+		return canToken{}, "empty reference"
+	case "^":
+		token.kind = canFirst
+		return token, ""
+	case "%":
+		token.kind = canSecond
+		return token, ""
+	case "$":
+		token.kind = canRest
+		return token, ""
+	}
+
+	if field, ok := canFields[body]; ok {
+		token.kind = canField
+		token.field = field
+		return token, ""
+	}
+
+	index, err := strconv.Atoi(body)
+	switch {
+	case err != nil:
+		return canToken{}, fmt.Sprintf("unknown reference %q (expected a component index, '^', '%%', '$', 'scheme', 'user', 'password', 'host' or 'port')", body)
+	case index == 0:
+		return canToken{}, "component index must not be 0 (components are counted from 1)"
+	}
+
+	token.kind = canIndex
+	token.index = index
+	return token, ""
+}
+
+// Apply applies this CanSpec to the given url.
+func (cs CanSpec) Apply(url URL) string {
+	if cs.verbatim {
+		return url.String()
+	}
+
+	components := url.Components()
+
+	// unused holds the indexes of unused components, in order
+	unused := make([]int, len(components))
+	for i := range unused {
+		unused[i] = i
+	}
+
+	var builder strings.Builder
+	for _, token := range cs.tokens {
+		switch token.kind {
+		case canLiteral:
+			builder.WriteString(token.source)
+		case canFirst:
+			if len(unused) < 1 {
+				builder.WriteString(token.source)
+				break
+			}
+			builder.WriteString(token.modify(components[unused[0]]))
+			unused = unused[1:]
+		case canSecond:
+			if len(unused) < 2 {
+				builder.WriteString(token.source)
+				break
+			}
+			builder.WriteString(token.modify(components[unused[1]]))
+			unused = slices.Delete(unused, 1, 2)
+		case canDrop:
+			if len(unused) < 1 {
+				builder.WriteString(token.source)
+				break
+			}
+			unused = unused[1:]
+		case canRest:
+			builder.WriteString(token.modify(joinComponents(components, unused)))
+			unused = nil
+		case canIndex:
+			index := token.index - 1
+			if token.index < 0 {
+				index = len(components) + token.index
+			}
+			if index < 0 || index >= len(components) {
+				builder.WriteString(token.source)
+				break
+			}
+			builder.WriteString(token.modify(components[index]))
+			unused = slices.DeleteFunc(unused, func(i int) bool { return i == index })
+		case canField:
+			builder.WriteString(token.modify(token.field(url)))
+		}
+	}
+
+	if cs.implicitRest {
+		builder.WriteString(joinComponents(components, unused))
+	}
+
+	return builder.String()
+}
+
+// modify applies the modifier of this token, if any.
+func (token canToken) modify(value string) string {
+	if token.modifier == nil {
+		return value
+	}
+	return token.modifier(value)
+}
+
+// joinComponents joins the components with the given indexes using '/'.
+func joinComponents(components []string, indexes []int) string {
+	parts := make([]string, len(indexes))
+	for i, index := range indexes {
+		parts[i] = components[index]
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,58 @@
+package env_test
+
+//spellchecker:words errors testing ggman internal
+import (
+	"errors"
+	"testing"
+
+	"go.tkw01536.de/ggman/internal/env"
+)
+
+//spellchecker:words canspec
+
+func TestParseCanSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		spec         string
+		wantPosition int
+		wantExplain  string
+		wantErr      bool
+	}{
+		{"legacy spec", "git@^:$.git", 0, "", false},
+		{"verbatim spec", "$$", 0, "", false},
+		{"spec with references", "https://{lower:^}/{-1}/{2}", 0, "", false},
+		{"escaped characters", `\{\}\\\$`, 0, "", false},
+		{"trailing escape", `git@^:$.git\`, 11, "escape character at end of CANSPEC:\n\n    git@^:$.git\\\n               ^", true},
+		{"unclosed reference", "git@{^:$.git", 4, "unclosed '{':\n\n    git@{^:$.git\n        ^", true},
+		{"empty reference", "git@{}:$.git", 4, "empty reference:\n\n    git@{}:$.git\n        ^", true},
+		{"zero index", "{0}", 0, "component index must not be 0 (components are counted from 1):\n\n    {0}\n    ^", true},
+		{"unknown modifier", "{title:^}", 0, "unknown modifier \"title\" (expected 'lower' or 'upper'):\n\n    {title:^}\n    ^", true},
+		{"unknown reference", "ü{path}", 2, "unknown reference \"path\" (expected a component index, '^', '%', '$', 'scheme', 'user', 'password', 'host' or 'port'):\n\n    ü{path}\n     ^", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := env.ParseCanSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCanSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			var specErr *env.CanSpecError
+			if !errors.As(err, &specErr) {
+				t.Fatalf("ParseCanSpec() error = %v, want *CanSpecError", err)
+			}
+			if specErr.Position != tt.wantPosition {
+				t.Errorf("CanSpecError.Position = %d, want %d", specErr.Position, tt.wantPosition)
+			}
+			if got := specErr.Explain(); got != tt.wantExplain {
+				t.Errorf("CanSpecError.Explain() = %q, want %q", got, tt.wantExplain)
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 package env
 
-//spellchecker:words context errors path filepath strings ggman internal walker pkglib exit
+//spellchecker:words context errors path filepath slices strings ggman internal walker pkglib exit
 import (
 	"context"
 	"errors"
@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"go.tkw01536.de/ggman/internal/git"
@@ -17,7 +18,7 @@ import (
 	"go.tkw01536.de/pkglib/fsx"
 )
 
-//spellchecker:words worktree canonicalized canonicalize CANFILE workdir GGNORM GGROOT Wrapf wrapcheck recvcheck
+//spellchecker:words worktree canonicalized canonicalize CANFILE workdir GGNORM GGROOT GGSCAN GGLAYOUT GGARCHIVE GGJUNK Wrapf wrapcheck recvcheck
 
 // Env represents an environment to be used by ggman.
 //
@@ -39,6 +40,18 @@ type Env struct {
 	// See the Local() method.
 	Root string
 
+	// Roots are all root folders of the environment, the first of which is Root.
+	// Repositories are cloned into the root selected by the CanFile, defaulting to Root.
+	Roots []string
+
+	// ScanRoots are additional folders that are searched for repositories.
+	// Repositories are never cloned into these folders.
+	ScanRoots []string
+
+	// Archive is the folder archived repositories are moved to.
+	// It mirrors the layout of Root.
+	Archive string
+
 	// Workdir is the working directory of this environment.
 	Workdir string
 
@@ -50,17 +63,48 @@ type Env struct {
 	// CanFile is the CanFile used to canonicalize repositories.
 	// See the Canonical() method.
 	CanFile CanFile
+
+	// CanFileSource is the path of the file CanFile was read from.
+	// It is empty when CanFile was not read from a file.
+	CanFileSource string
+
+	// Aliases are the url aliases read along with the CanFile.
+	// See the ParseURL() method.
+	Aliases Aliases
 }
 
 // Normalization returns the path Normalization used by this environment.
 func (env *Env) Normalization() path.Normalization {
-	switch strings.ToLower(env.Vars.GGNORM) {
+	norm, _ := ParseNormalization(env.Vars.GGNORM)
+	return norm
+}
+
+// Junk returns the names of junk files that do not prevent a directory from being considered empty.
+// It is read from the GGJUNK variable, a list of file names separated by the os-specific path list separator.
+func (env *Env) Junk() []string {
+	return splitList(env.Vars.GGJUNK)
+}
+
+// splitList splits value using the os-specific path list separator, omitting empty elements.
+func splitList(value string) []string {
+	elems := filepath.SplitList(value)
+	return slices.DeleteFunc(elems, func(elem string) bool { return elem == "" })
+}
+
+// ParseNormalization parses the value of the GGNORM variable into a path Normalization.
+// Values are matched case-insensitively.
+//
+// Unknown values fall back to the default normalization and return ok = false.
+func ParseNormalization(value string) (norm path.Normalization, ok bool) {
+	switch strings.ToLower(value) {
 	case "exact":
-		return path.NoNorm
+		return path.NoNorm, true
 	case "fold":
-		return path.FoldNorm
+		return path.FoldNorm, true
+	case "", "smart":
+		return path.FoldPreferExactNorm, true
 	default:
-		return path.FoldPreferExactNorm
+		return path.FoldPreferExactNorm, false
 	}
 }
 
@@ -103,6 +147,12 @@ func NewEnv(r Requirement, params Parameters) (*Env, error) {
 		}
 	}
 
+	if r.NeedsArchive {
+		if err := env.LoadDefaultArchive(); err != nil {
+			return nil, err
+		}
+	}
+
 	return env, nil
 }
 
@@ -123,21 +173,111 @@ func (env *Env) absRoot() (string, error) {
 	return root, nil
 }
 
-// LoadDefaultRoot sets env.Root according to the environment variables in e.Vars.
-// If e.Root is already set, does nothing and returns nil.
+// absRoots returns the absolute paths to all root directories, starting with the primary root.
+// If the root directory is not set, returns an error of type Error.
+func (env *Env) absRoots() ([]string, error) {
+	roots := env.Roots
+	if len(roots) == 0 {
+		roots = []string{env.Root}
+	}
+
+	abs := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if root == "" {
+			return nil, errMissingRoot
+		}
+		root, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidRoot, err)
+		}
+		abs = append(abs, root)
+	}
+	return abs, nil
+}
+
+// SearchRoots returns the absolute paths of all folders searched for repositories.
+// These are the root directories, followed by the scan-only roots.
+// Duplicates are omitted.
+//
+// Assumes that the root directory is set.
+// If that is not the case, calls panic().
+func (env *Env) SearchRoots() []string {
+	roots, err := env.absRoots()
+	if err != nil {
+		panic("Env.SearchRoots: Root not resolved")
+	}
+
+	for _, root := range env.ScanRoots {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		roots = append(roots, root)
+	}
+
+	seen := make(map[string]struct{}, len(roots))
+	return slices.DeleteFunc(roots, func(root string) bool {
+		if _, ok := seen[root]; ok {
+			return true
+		}
+		seen[root] = struct{}{}
+		return false
+	})
+}
+
+// RootOf returns the absolute path of the root directory or scan-only root containing path.
+// When several roots contain path, the innermost one is returned.
+// scanOnly indicates if the returned root is a scan-only root.
 //
-// If the GGROOT variable is set, it is used as the root directory.
+// Assumes that the root directory is set.
+// If that is not the case, calls panic().
+func (env *Env) RootOf(p string) (root string, scanOnly bool, ok bool) {
+	abs, err := env.Abs(p)
+	if err != nil {
+		return "", false, false
+	}
+
+	managed, err := env.absRoots()
+	if err != nil {
+		panic("Env.RootOf: Root not resolved")
+	}
+
+	for _, candidate := range env.SearchRoots() {
+		if !path.HasChild(candidate, abs) || (ok && len(candidate) <= len(root)) {
+			continue
+		}
+		root, ok = candidate, true
+		scanOnly = !slices.Contains(managed, candidate)
+	}
+	return root, scanOnly, ok
+}
+
+// LoadDefaultRoot sets env.Root and env.Roots according to the environment variables in e.Vars.
+// If e.Root is already set, only ensures that e.Roots contains it.
+//
+// If the GGROOT variable is set, it is used as a list of root directories separated by the os-specific path list separator.
+// The first directory is the primary root directory stored in env.Root.
 // If it is not set, the subdirectory 'Projects' of the home directory is used.
 //
-// The root directory does not have to exist for this function to return nil.
+// Furthermore, if env.ScanRoots is nil, it is read from the GGSCAN variable in the same way.
+//
+// The root directories do not have to exist for this function to return nil.
 // However if both GGROOT and Home are unset, this function returns an error of type Error.
 func (env *Env) LoadDefaultRoot() error {
+	if env.ScanRoots == nil {
+		env.ScanRoots = splitList(env.Vars.GGSCAN)
+	}
+
 	if env.Root != "" {
+		if len(env.Roots) == 0 {
+			env.Roots = []string{env.Root}
+		}
 		return nil
 	}
 
-	env.Root = env.Vars.GGROOT
-	if env.Root != "" {
+	env.Roots = splitList(env.Vars.GGROOT)
+	if len(env.Roots) > 0 {
+		env.Root = env.Roots[0]
 		return nil
 	}
 
@@ -146,6 +286,27 @@ func (env *Env) LoadDefaultRoot() error {
 	}
 
 	env.Root = filepath.Join(env.Vars.HOME, "Projects")
+	env.Roots = []string{env.Root}
+	return nil
+}
+
+var errMissingArchive = exit.NewErrorWithCode("failed to find GGARCHIVE directory: variable is not set", ExitInvalidEnvironment)
+
+// LoadDefaultArchive sets env.Archive according to the environment variables in e.Vars.
+// If e.Archive is already set, does nothing and returns nil.
+//
+// The archive directory is taken from the GGARCHIVE variable.
+// There is no default; if the variable is unset, this function returns an error of type Error.
+// The archive directory does not have to exist for this function to return nil.
+func (env *Env) LoadDefaultArchive() error {
+	if env.Archive != "" {
+		return nil
+	}
+
+	env.Archive = env.Vars.GGARCHIVE
+	if env.Archive == "" {
+		return errMissingArchive
+	}
 	return nil
 }
 
@@ -155,6 +316,9 @@ func (env *Env) LoadDefaultRoot() error {
 // If the GGMAN_CANFILE variable is set, it will use it as a filepath to read the CanFile from.
 // If it is not set it will attempt to load the file '.ggman' in the home directory.
 // If neither is set, this function will load an in-memory default CanFile.
+//
+// Files with a '.toml' extension are read using the structured format, see [CanFile.ReadTOMLFrom].
+// All other files are read line by line, see [CanFile.ReadFrom].
 func (env *Env) LoadDefaultCANFILE() (cf CanFile, err error) {
 	if env.CanFile != nil {
 		return env.CanFile, nil
@@ -188,10 +352,16 @@ func (env *Env) LoadDefaultCANFILE() (cf CanFile, err error) {
 				err = errClose
 			}
 		}()
-		if _, err := cf.ReadFrom(f); err != nil {
+		if IsStructuredCanFile(file) {
+			env.Aliases, err = cf.ReadTOMLFrom(f)
+		} else {
+			_, err = cf.ReadFrom(f)
+		}
+		if err != nil {
 			return nil, err
 		}
 		env.CanFile = cf
+		env.CanFileSource = file
 		return cf, nil
 	}
 
@@ -207,21 +377,117 @@ var (
 	ErrUnableLocalPath = exit.NewErrorWithCode("failed to get local path", ExitInvalidRepo)
 )
 
+// Layout returns the layout used to place the repository with the given url.
+// This is the layout of the CanFile line matching url, if any.
+// Otherwise it is the layout read from the GGLAYOUT variable.
+//
+// If the layout is invalid, returns an error wrapping *[LayoutError].
+func (env *Env) Layout(url URL) (Layout, error) {
+	source := env.Vars.GGLAYOUT
+	if env.CanFile != nil {
+		if line, ok := env.CanLine(url); ok && line.Layout != "" {
+			source = line.Layout
+		}
+	}
+
+	layout, err := ParseLayout(source)
+	if err != nil {
+		return Layout{}, fmt.Errorf("%w: %w", errInvalidLayout, err)
+	}
+	return layout, nil
+}
+
+// RemoteOf is the inverse of Local.
+// It takes the path of a repository relative to its root directory, and returns a url that is placed at this path.
+// The returned url has the 'file' scheme.
+//
+// The layouts of all CanFile lines and then the global layout are tried in order.
+// A layout is only used when the returned url is placed using the same layout.
+// If no layout can be used, returns ok = false.
+func (env *Env) RemoteOf(relative string) (remote string, ok bool) {
+	segments := splitPath(relative)
+
+	var sources []string
+	for _, line := range env.CanFile {
+		if line.Layout != "" {
+			sources = append(sources, line.Layout)
+		}
+	}
+	sources = append(sources, env.Vars.GGLAYOUT)
+
+	for _, source := range sources {
+		layout, err := ParseLayout(source)
+		if err != nil {
+			continue
+		}
+		components, ok := layout.Match(segments)
+		if !ok {
+			continue
+		}
+
+		remote := "file://" + strings.Join(components, "/")
+		if used, err := env.Layout(ParseURL(remote)); err != nil || used.String() != layout.String() {
+			continue
+		}
+		return remote, true
+	}
+	return "", false
+}
+
+// splitPath splits a relative path into its non-empty segments.
+func splitPath(relative string) []string {
+	segments := strings.Split(filepath.ToSlash(relative), "/")
+	return slices.DeleteFunc(segments, func(segment string) bool { return segment == "" || segment == "." })
+}
+
 // Local returns the path that a repository named URL should be cloned to.
 // Normalization of paths is controlled by the norm parameter.
+//
+// The repository is placed inside the root directory of the CanFile line matching url.
+// If there is no such line, or the CanFile is not loaded, the primary root directory is used.
+// Within the root directory, the repository is placed according to the layout returned by Layout.
 func (env *Env) Local(url URL) (string, error) {
 	root, err := env.absRoot()
 	if err != nil {
 		panic("Env.Local: Root not resolved")
 	}
+	if env.CanFile != nil {
+		if line, ok := env.CanLine(url); ok && line.Root != "" {
+			root, err = env.ExpandRoot(line.Root)
+			if err != nil {
+				return "", fmt.Errorf("%w: %w", errUnableToReadDirectory, err)
+			}
+		}
+	}
+
+	layout, err := env.Layout(url)
+	if err != nil {
+		return "", err
+	}
 
-	path, err := path.JoinNormalized(env.Normalization(), root, url.Components()...)
+	path, err := path.JoinNormalized(env.Normalization(), root, layout.Apply(url)...)
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", errUnableToReadDirectory, err)
 	}
 	return path, nil
 }
 
+// ExpandRoot returns the absolute path of a root directory as found in the CanFile.
+// A leading '~' is replaced by the home directory.
+func (env *Env) ExpandRoot(root string) (string, error) {
+	if root == "~" || strings.HasPrefix(root, "~/") || strings.HasPrefix(root, "~"+string(filepath.Separator)) {
+		if env.Vars.HOME == "" {
+			return "", errMissingHome
+		}
+		root = filepath.Join(env.Vars.HOME, root[1:])
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidRoot, err)
+	}
+	return abs, nil
+}
+
 const (
 	// ExitZero indicates that no error occurred.
 	// It is the zero value of type ExitCode.
@@ -256,8 +522,10 @@ const (
 )
 
 var (
-	errInvalidRoot = exit.NewErrorWithCode("failed to resolve root directory", ExitInvalidEnvironment)
-	errNotResolved = exit.NewErrorWithCode("failed to resolve repository", ExitInvalidRepo)
+	errInvalidRoot   = exit.NewErrorWithCode("failed to resolve root directory", ExitInvalidEnvironment)
+	errInvalidLayout = exit.NewErrorWithCode("failed to parse layout", ExitInvalidEnvironment)
+	errMissingHome   = exit.NewErrorWithCode("failed to expand '~': home directory is not set", ExitInvalidEnvironment)
+	errNotResolved   = exit.NewErrorWithCode("failed to resolve repository", ExitInvalidRepo)
 )
 
 // Abs returns the absolute path to path, unless it is already absolute.
@@ -284,7 +552,7 @@ const atMaxIterCount = 1000
 //
 // First check if there is a repository at the provided path.
 // If there is a repository, returns it.
-// If there is not, recursively try parent directories until outside of the root directory.
+// If there is not, recursively try parent directories until outside of the root directory containing the path.
 //
 // Assumes that the root directory is set.
 // If that is not the case, calls panic().
@@ -303,6 +571,11 @@ func (env *Env) At(ctx context.Context, p string) (repo, worktree string, err er
 		return "", "", fmt.Errorf("%w %q", errNotResolved, p)
 	}
 
+	// search within the root containing the path, if any
+	if containing, _, ok := env.RootOf(path); ok {
+		root = containing
+	}
+
 	// start recursively searching, starting at 'path' doing at most count iterations.
 	// the regular exit condition is that repo should be the root of a repository.
 	// we additionally need to check that the path is inside of the root.
@@ -360,6 +633,41 @@ func (env *Env) Canonical(url URL) string {
 	return url.CanonicalWith(env.CanFile)
 }
 
+// CanonicalPush returns the canonical push url of the URL url.
+// This requires that CanFile is not nil.
+// See the [url.CanonicalPushWith] method of URL.
+//
+// This function is untested.
+func (env *Env) CanonicalPush(url URL) (push string, ok bool) {
+	if env.CanFile == nil {
+		panic("Env.CanonicalPush: CanFile is nil")
+	}
+	return url.CanonicalPushWith(env.CanFile)
+}
+
+// ParseURL expands the aliases of this environment in s and then parses it into a URL.
+// Aliases are only available once the CanFile has been loaded.
+// See the [Aliases.ParseURL] method.
+func (env *Env) ParseURL(s string) URL {
+	return env.Aliases.ParseURL(s)
+}
+
+// ParseURLStrict is like ParseURL, but parses the url strictly.
+// See the [Aliases.ParseURLStrict] method.
+func (env *Env) ParseURLStrict(s string) (URL, error) {
+	return env.Aliases.ParseURLStrict(s)
+}
+
+// CanLine returns the first line of the CanFile matching url.
+// This requires that CanFile is not nil.
+// See the [url.CanLineWith] method of URL.
+func (env *Env) CanLine(url URL) (line CanLine, ok bool) {
+	if env.CanFile == nil {
+		panic("Env.CanLine: CanFile is nil")
+	}
+	return url.CanLineWith(env.CanFile)
+}
+
 // reposBufferSize is the (currently hard-coded) size for the cache of the Repos function.
 // 200 should be larger than the largest number of repositories expected.
 // Note that this is only an optimization, the algorithm should perform even for a non-buffered channel.
@@ -394,22 +702,22 @@ func (env *Env) Repos(ctx context.Context, resolved bool) []string {
 }
 
 // ScanRepoScores scans for repositories in the provided folder that match the Filter of this environment.
+// If folder is the empty string, scans all root directories and scan-only roots, see SearchRoots().
 // Resolved indicates if the paths returned should resolve the final path of repositories.
 // Repositories are returned in order of their scores, which are returned in the second argument.
 //
 // When an error occurs, this function may still return a list of (incomplete) repositories along with an error.
 func (env *Env) ScanReposScores(ctx context.Context, folder string, resolved bool) ([]string, []float64, error) {
 	// NOTE: This function is untested, only ScanRepos() itself is tested
-	if folder == "" {
-		var err error
-		folder, err = env.absRoot()
-		if err != nil {
-			panic("Env.Repos: Root not resolved")
-		}
-	}
-
 	// grab extra candidates from the filter
 	extraRoots := Candidates(env.Filter)
+
+	// by default, scan the primary root and all other roots
+	if folder == "" {
+		roots := env.SearchRoots()
+		folder = roots[0]
+		extraRoots = append(extraRoots, roots[1:]...)
+	}
 	n := 0
 	for _, path := range extraRoots {
 		if ok, err := fsx.IsDirectory(path, true); err == nil && ok {
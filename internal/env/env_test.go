@@ -1,10 +1,11 @@
 package env_test
 
-//spellchecker:words path filepath reflect testing ggman internal testutil pkglib testlib
+//spellchecker:words path filepath reflect strings testing ggman internal testutil pkglib testlib
 import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"go.tkw01536.de/ggman/internal/env"
@@ -14,7 +15,7 @@ import (
 	"go.tkw01536.de/pkglib/testlib"
 )
 
-//spellchecker:words GGNORM GGROOT worktree
+//spellchecker:words GGNORM GGROOT GGSCAN GGLAYOUT worktree
 
 func TestEnv_LoadDefaultRoot(t *testing.T) {
 	t.Parallel()
@@ -34,19 +35,29 @@ func TestEnv_LoadDefaultRoot(t *testing.T) {
 	// noExistsDir doesn't exist
 	noExistsDir := filepath.Join(testlib.TempDirAbs(t), "noExist")
 
+	// list joins paths with the os-specific path list separator
+	list := func(paths ...string) string {
+		return strings.Join(paths, string(filepath.ListSeparator))
+	}
+
 	tests := []struct {
-		name     string
-		vars     env.Variables
-		wantRoot string
-		wantErr  bool
+		name          string
+		vars          env.Variables
+		wantRoot      string
+		wantRoots     []string
+		wantScanRoots []string
+		wantErr       bool
 	}{
-		{"GGROOT exists", env.Variables{GGROOT: noProjectsDir}, noProjectsDir, false},
-		{"GGROOT not exists", env.Variables{GGROOT: noExistsDir}, noExistsDir, false},
+		{"GGROOT exists", env.Variables{GGROOT: noProjectsDir}, noProjectsDir, []string{noProjectsDir}, []string{}, false},
+		{"GGROOT not exists", env.Variables{GGROOT: noExistsDir}, noExistsDir, []string{noExistsDir}, []string{}, false},
 
-		{"GGROOT unset, HOME unset", env.Variables{}, "", true},
+		{"GGROOT list", env.Variables{GGROOT: list(noExistsDir, "", noProjectsDir)}, noExistsDir, []string{noExistsDir, noProjectsDir}, []string{}, false},
+		{"GGROOT and GGSCAN", env.Variables{GGROOT: noProjectsDir, GGSCAN: list(noExistsDir, withProjectsDir)}, noProjectsDir, []string{noProjectsDir}, []string{noExistsDir, withProjectsDir}, false},
 
-		{"GGROOT unset, HOME/Projects exists", env.Variables{HOME: noProjectsDir}, missingProjectsDir, false},
-		{"GGROOT unset, HOME/Projects not exists", env.Variables{HOME: withProjectsDir}, existingProjectsDir, false},
+		{"GGROOT unset, HOME unset", env.Variables{}, "", []string{}, []string{}, true},
+
+		{"GGROOT unset, HOME/Projects exists", env.Variables{HOME: noProjectsDir}, missingProjectsDir, []string{missingProjectsDir}, []string{}, false},
+		{"GGROOT unset, HOME/Projects not exists", env.Variables{HOME: withProjectsDir}, existingProjectsDir, []string{existingProjectsDir}, []string{}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -61,6 +72,53 @@ func TestEnv_LoadDefaultRoot(t *testing.T) {
 			if env.Root != tt.wantRoot {
 				t.Errorf("Env.LoadDefaultRoot() root = %v, wantRoot %v", env.Root, tt.wantRoot)
 			}
+			if !reflect.DeepEqual(env.Roots, tt.wantRoots) {
+				t.Errorf("Env.LoadDefaultRoot() roots = %v, wantRoots %v", env.Roots, tt.wantRoots)
+			}
+			if !reflect.DeepEqual(env.ScanRoots, tt.wantScanRoots) {
+				t.Errorf("Env.LoadDefaultRoot() scanRoots = %v, wantScanRoots %v", env.ScanRoots, tt.wantScanRoots)
+			}
+		})
+	}
+}
+
+func TestEnv_RootOf(t *testing.T) {
+	t.Parallel()
+
+	base := testlib.TempDirAbs(t)
+	projects := filepath.Join(base, "Projects")
+	work := filepath.Join(base, "Work")
+	scan := filepath.Join(base, "go", "src")
+	nested := filepath.Join(projects, "nested")
+
+	env := env.Env{
+		Root:      projects,
+		Roots:     []string{projects, work},
+		ScanRoots: []string{scan, nested},
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		wantRoot     string
+		wantScanOnly bool
+		wantOK       bool
+	}{
+		{"primary root", filepath.Join(projects, "github.com", "hello", "world"), projects, false, true},
+		{"secondary root", filepath.Join(work, "github.com", "hello", "world"), work, false, true},
+		{"root itself", work, work, false, true},
+		{"scan root", filepath.Join(scan, "github.com", "hello", "world"), scan, true, true},
+		{"nested scan root", filepath.Join(nested, "world"), nested, true, true},
+		{"outside", filepath.Join(base, "other", "world"), "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotRoot, gotScanOnly, gotOK := env.RootOf(tt.path)
+			if gotRoot != tt.wantRoot || gotScanOnly != tt.wantScanOnly || gotOK != tt.wantOK {
+				t.Errorf("Env.RootOf() = (%q, %v, %v), want (%q, %v, %v)", gotRoot, gotScanOnly, gotOK, tt.wantRoot, tt.wantScanOnly, tt.wantOK)
+			}
 		})
 	}
 }
@@ -74,7 +132,7 @@ func TestEnv_LoadDefaultCANFILE(t *testing.T) {
 
 	// sampleCanFile is the sample CanFile]
 	const canLineContent = "sample@^:$.git"
-	var sampleCanFile env.CanFile = []env.CanLine{{"", canLineContent}}
+	var sampleCanFile env.CanFile = []env.CanLine{{Canonical: canLineContent}}
 
 	// emptyDir is an empty directory without a canFile
 	emptyDir := testlib.TempDirAbs(t)
@@ -184,6 +242,148 @@ func TestEnv_Local_Exact(t *testing.T) {
 	}
 }
 
+func TestEnv_Local_Root(t *testing.T) {
+	t.Parallel()
+
+	home := testlib.TempDirAbs(t)
+	projects := filepath.Join(home, "Projects")
+	work := filepath.Join(home, "Work")
+
+	e := env.Env{
+		Root:  projects,
+		Roots: []string{projects, work},
+		Vars:  env.Variables{HOME: home},
+		CanFile: env.CanFile{
+			{Pattern: "^gitlab.work.com", Canonical: "git@^:$.git", Root: "~/Work"},
+			{Pattern: "^other.work.com", Canonical: "git@^:$.git", Root: work},
+			{Canonical: "git@^:$.git"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"git@gitlab.work.com:hello/world.git", filepath.Join(work, "gitlab.work.com", "hello", "world")},
+		{"https://other.work.com/hello/world.git", filepath.Join(work, "other.work.com", "hello", "world")},
+		{"git@github.com:hello/world.git", filepath.Join(projects, "github.com", "hello", "world")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := e.Local(env.ParseURL(tt.name))
+			if gotErr != nil {
+				t.Errorf("Env.Local() err = %v, want err = nil", gotErr)
+			}
+			if got != tt.want {
+				t.Errorf("Env.Local() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv_Local_Layout(t *testing.T) {
+	t.Parallel()
+
+	root := testlib.TempDirAbs(t)
+
+	e := env.Env{
+		Root: root,
+		Vars: env.Variables{GGLAYOUT: "{owner}/{name}"},
+		CanFile: env.CanFile{
+			{Pattern: "^gitlab.com", Canonical: "git@^:$.git", Layout: "{host}/{name}"},
+			{Canonical: "git@^:$.git"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"git@github.com:hello/world.git", filepath.Join(root, "hello", "world")},
+		{"git@gitlab.com:group/subgroup/repo.git", filepath.Join(root, "gitlab.com", "repo")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := e.Local(env.ParseURL(tt.name))
+			if gotErr != nil {
+				t.Errorf("Env.Local() err = %v, want err = nil", gotErr)
+			}
+			if got != tt.want {
+				t.Errorf("Env.Local() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid layout", func(t *testing.T) {
+		t.Parallel()
+
+		e := env.Env{
+			Root: root,
+			Vars: env.Variables{GGLAYOUT: "{repo}"},
+		}
+		if _, err := e.Local(env.ParseURL("git@github.com:hello/world.git")); err == nil {
+			t.Error("Env.Local() err = nil, want err != nil")
+		}
+	})
+}
+
+func TestEnv_RemoteOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		GGLAYOUT   string
+		canFile    env.CanFile
+		relative   string
+		wantRemote string
+		wantOK     bool
+	}{
+		{"default layout", "", nil, filepath.Join("github.com", "hello", "world"), "file://github.com/hello/world", true},
+		{"global layout", "src/{host}/{owner}/{name}", nil, filepath.Join("src", "github.com", "hello", "world"), "file://github.com/hello/world", true},
+		{"global layout without host", "{owner}/{name}", nil, filepath.Join("hello", "world"), "", false},
+		{
+			"rule layout",
+			"",
+			env.CanFile{
+				{Pattern: "^gitlab.com", Canonical: "git@^:$.git", Layout: "flat/{host}/{name}"},
+				{Canonical: "git@^:$.git"},
+			},
+			filepath.Join("flat", "gitlab.com", "repo"),
+			"file://gitlab.com/repo",
+			true,
+		},
+		{
+			"rule layout of non-matching rule",
+			"",
+			env.CanFile{
+				{Pattern: "^gitlab.com", Canonical: "git@^:$.git", Layout: "flat/{host}/{name}"},
+				{Canonical: "git@^:$.git"},
+			},
+			filepath.Join("flat", "github.com", "repo"),
+			"file://flat/github.com/repo",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := env.Env{
+				Vars:    env.Variables{GGLAYOUT: tt.GGLAYOUT},
+				CanFile: tt.canFile,
+			}
+			gotRemote, gotOK := e.RemoteOf(tt.relative)
+			if gotRemote != tt.wantRemote || gotOK != tt.wantOK {
+				t.Errorf("Env.RemoteOf() = (%q, %v), want (%q, %v)", gotRemote, gotOK, tt.wantRemote, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestEnv_At(t *testing.T) {
 	t.Parallel()
 
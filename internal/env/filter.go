@@ -126,6 +126,9 @@ func (pat *PatternFilter) Score(ctx context.Context, env *Env, clonePath string)
 		if err != nil { // root not resolved
 			return FilterDoesNotMatch
 		}
+		if containing, _, ok := env.RootOf(clonePath); ok {
+			root = containing
+		}
 		actualClonePath, err := filepath.Abs(clonePath)
 		if err != nil { // clone path not resolved
 			return FilterDoesNotMatch
@@ -36,7 +36,16 @@ type Flags struct {
 var errNotADirectory = exit.NewErrorWithCode("failed to resolve path: not a directory", ExitInvalidRepo)
 
 // NewFilter creates a new filter corresponding to the given Flags and Environment.
+//
+// When patterns are given, the CanFile of the environment is loaded to expand aliases in them.
+// If it cannot be loaded, no aliases are expanded.
 func NewFilter(ctx context.Context, flags Flags, env *Env) (filter Filter, err error) {
+	// load the CANFILE, so that aliases in patterns can be expanded.
+	// an invalid CANFILE is only reported by commands that need it.
+	if len(flags.For) > 0 || len(flags.FromFile) > 0 {
+		_, _ = env.LoadDefaultCANFILE()
+	}
+
 	// generate pattern filters for the "--for" arguments
 	clauses := make([]Filter, len(flags.For))
 	for i, pat := range flags.For {
@@ -94,7 +103,7 @@ func NewFilter(ctx context.Context, flags Flags, env *Env) (filter Filter, err e
 //
 // A 'for' filter may be either:
 //   - a (relative or absolute) path to the root of a repository (see env.AtRoot)
-//   - a repository url or pattern (see NewPatternFilter)
+//   - a repository url or pattern (see NewPatternFilter), in which aliases are expanded
 func (env *Env) NewForFilter(ctx context.Context, filter string, fuzzy bool) Filter {
 	// check if 'pat' represents the root of a repository
 	if repo, err := env.AtRoot(ctx, filter); err == nil && repo != "" {
@@ -102,7 +111,7 @@ func (env *Env) NewForFilter(ctx context.Context, filter string, fuzzy bool) Fil
 	}
 
 	// create a normal pattern filter
-	return NewPatternFilter(filter, fuzzy)
+	return NewPatternFilter(env.Aliases.Expand(filter), fuzzy)
 }
 
 // NewFromFileFilter creates a list of filters from the file at path.
@@ -0,0 +1,362 @@
+package env
+
+//spellchecker:words strconv strings unicode
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//spellchecker:words GGLAYOUT
+
+// Layout is a parsed directory layout template.
+//
+// A layout describes where a repository is placed within a root directory.
+// It consists of segments separated by '/'.
+// Any character is copied literally, except for '\', which escapes the next character, and references.
+//
+// A reference has the form '{[modifier:]reference}'.
+// The reference can be one of:
+//
+//	host  -- the first component of the URL, typically the hostname
+//	owner -- the second component of the URL, typically a user or organization; empty if the URL has fewer than three components
+//	name  -- the last component of the URL, typically the repository name; empty if the URL has a single component
+//	group -- all components between the first and the last one, joined with '/'
+//	path  -- all components except for the first one, joined with '/'
+//	N     -- the Nth component of the URL, starting at 1. Negative numbers count from the end, i.e. -1 is the last component.
+//
+// The optional modifier can be 'lower' or 'upper', as in a CANSPEC.
+// Segments that are empty after replacing references are omitted.
+//
+// The zero Layout places repositories according to all components of their URL, equivalent to '{host}/{path}'.
+type Layout struct {
+	source   string
+	segments [][]layoutToken
+}
+
+// layoutToken is a single token of a Layout.
+type layoutToken struct {
+	literal  string // literal text, when ref is empty
+	ref      string // name of the reference, one of the layoutRefs or "index"
+	index    int    // index of the component for index references
+	modifier func(string) string
+}
+
+// layoutRefs are the supported named references of a Layout.
+var layoutRefs = map[string]func(components []string) string{
+	"host": func(components []string) string {
+		return components[0]
+	},
+	"owner": func(components []string) string {
+		if len(components) < 3 {
+			return ""
+		}
+		return components[1]
+	},
+	"name": func(components []string) string {
+		if len(components) < 2 {
+			return ""
+		}
+		return components[len(components)-1]
+	},
+	"group": func(components []string) string {
+		if len(components) < 2 {
+			return ""
+		}
+		return strings.Join(components[1:len(components)-1], "/")
+	},
+	"path": func(components []string) string {
+		return strings.Join(components[1:], "/")
+	},
+}
+
+// LayoutError is returned when a Layout cannot be parsed.
+type LayoutError struct {
+	Layout   string // the layout being parsed
+	Position int    // byte offset of the error within Layout
+	Reason   string // human-readable reason
+}
+
+func (le *LayoutError) Error() string {
+	return fmt.Sprintf("invalid layout %q: %s at position %d", le.Layout, le.Reason, le.Position)
+}
+
+// Explain returns a human-readable explanation of the error.
+// See [CanSpecError.Explain].
+func (le *LayoutError) Explain() string {
+	return explainPosition(le.Layout, le.Position, le.Reason)
+}
+
+// ParseLayout parses a layout template.
+// See [Layout] for a description of the syntax.
+//
+// The empty string results in the zero Layout.
+// If the layout is invalid, returns an error of type *[LayoutError].
+func ParseLayout(layout string) (Layout, error) {
+	if layout == "" {
+		return Layout{}, nil
+	}
+
+	l := Layout{source: layout}
+
+	var (
+		segment []layoutToken
+		literal strings.Builder
+		start   int // start of the current segment
+	)
+
+	flush := func() {
+		if literal.Len() == 0 {
+			return
+		}
+		segment = append(segment, layoutToken{literal: literal.String()})
+		literal.Reset()
+	}
+	endSegment := func(end int) error {
+		flush()
+		if len(segment) == 1 && segment[0].ref == "" && (segment[0].literal == "." || segment[0].literal == "..") {
+			return &LayoutError{Layout: layout, Position: start, Reason: fmt.Sprintf("segment %q is not allowed", segment[0].literal)}
+		}
+		if len(segment) > 0 {
+			l.segments = append(l.segments, segment)
+		}
+		segment = nil
+		start = end + 1
+		return nil
+	}
+
+	for i := 0; i < len(layout); {
+		r, size := utf8.DecodeRuneInString(layout[i:])
+
+		switch r {
+		case '\\':
+			if i+size == len(layout) {
+				return Layout{}, &LayoutError{Layout: layout, Position: i, Reason: "escape character at end of layout"}
+			}
+			escaped, escapedSize := utf8.DecodeRuneInString(layout[i+size:])
+			literal.WriteRune(escaped)
+			i += size + escapedSize
+			continue
+
+		case '{':
+			end := strings.IndexRune(layout[i:], '}')
+			if end < 0 {
+				return Layout{}, &LayoutError{Layout: layout, Position: i, Reason: "unclosed '{'"}
+			}
+
+			token, reason := parseLayoutReference(layout[i+1 : i+end])
+			if reason != "" {
+				return Layout{}, &LayoutError{Layout: layout, Position: i, Reason: reason}
+			}
+
+			flush()
+			segment = append(segment, token)
+			i += end + 1
+			continue
+
+		case '/':
+			if err := endSegment(i); err != nil {
+				return Layout{}, err
+			}
+
+		default:
+			literal.WriteRune(r)
+		}
+
+		i += size
+	}
+	if err := endSegment(len(layout)); err != nil {
+		return Layout{}, err
+	}
+
+	if len(l.segments) == 0 {
+		return Layout{}, &LayoutError{Layout: layout, Position: 0, Reason: "layout has no segments"}
+	}
+	return l, nil
+}
+
+// parseLayoutReference parses the body of a reference, that is the part between '{' and '}'.
+// If the body is invalid, returns a non-empty reason.
+func parseLayoutReference(body string) (token layoutToken, reason string) {
+	if name, ref, ok := strings.Cut(body, ":"); ok {
+		token.modifier, ok = canModifiers[name]
+		if !ok {
+			return layoutToken{}, fmt.Sprintf("unknown modifier %q (expected 'lower' or 'upper')", name)
+		}
+		body = ref
+	}
+
+	if body == "" {
+		return layoutToken{}, "empty reference"
+	}
+
+	if _, ok := layoutRefs[body]; ok {
+		token.ref = body
+		return token, ""
+	}
+
+	index, err := strconv.Atoi(body)
+	switch {
+	case err != nil:
+		return layoutToken{}, fmt.Sprintf("unknown reference %q (expected a component index, 'host', 'owner', 'name', 'group' or 'path')", body)
+	case index == 0:
+		return layoutToken{}, "component index must not be 0 (components are counted from 1)"
+	}
+
+	token.ref = "index"
+	token.index = index
+	return token, ""
+}
+
+// String returns the source of this layout.
+func (l Layout) String() string {
+	return l.source
+}
+
+// IsZero checks if this is the zero layout.
+func (l Layout) IsZero() bool {
+	return len(l.segments) == 0
+}
+
+// Apply returns the path components the repository with the given url is placed at.
+// The returned components may be empty, for instance when the url has no components.
+func (l Layout) Apply(url URL) []string {
+	components := url.Components()
+	if l.IsZero() || len(components) == 0 {
+		return components
+	}
+
+	var builder strings.Builder
+	result := make([]string, 0, len(l.segments))
+	for _, segment := range l.segments {
+		builder.Reset()
+		for _, token := range segment {
+			builder.WriteString(token.value(components))
+		}
+
+		// references may expand to several segments
+		for part := range strings.SplitSeq(builder.String(), "/") {
+			if part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// value returns the value of this token for the given (non-empty) components.
+func (token layoutToken) value(components []string) string {
+	var value string
+	switch token.ref {
+	case "":
+		return token.literal
+	case "index":
+		index := token.index - 1
+		if token.index < 0 {
+			index = len(components) + token.index
+		}
+		if index >= 0 && index < len(components) {
+			value = components[index]
+		}
+	default:
+		value = layoutRefs[token.ref](components)
+	}
+
+	if token.modifier != nil {
+		value = token.modifier(value)
+	}
+	return value
+}
+
+// Match is the inverse of Apply.
+// It takes the path components of a repository relative to its root directory, and returns the components of its URL.
+//
+// Only layouts where each segment is either literal or a single 'host', 'owner', 'name', 'group' or 'path' reference can be inverted.
+// Furthermore, at most one of 'group' and 'path' may be used, and the host must be referenced.
+// If the layout cannot be inverted, or segments does not match it, returns ok = false.
+func (l Layout) Match(segments []string) (components []string, ok bool) {
+	if l.IsZero() {
+		return segments, len(segments) > 0
+	}
+
+	// find the variable-length segment (if any)
+	variable := -1
+	for i, segment := range l.segments {
+		if len(segment) != 1 || (segment[0].ref != "" && segment[0].ref != "host" && segment[0].ref != "owner" && segment[0].ref != "name" && segment[0].ref != "group" && segment[0].ref != "path") {
+			return nil, false
+		}
+		if segment[0].ref != "group" && segment[0].ref != "path" {
+			continue
+		}
+		if variable != -1 {
+			return nil, false
+		}
+		variable = i
+	}
+
+	// assign the segments to the tokens
+	values := make(map[string][]string, len(l.segments))
+	assign := func(token layoutToken, value []string) bool {
+		if token.ref == "" {
+			return len(value) == 1 && value[0] == token.literal
+		}
+		if old, ok := values[token.ref]; ok && strings.Join(old, "/") != strings.Join(value, "/") {
+			return false
+		}
+		values[token.ref] = value
+		return true
+	}
+
+	if variable == -1 {
+		if len(segments) != len(l.segments) {
+			return nil, false
+		}
+		for i, segment := range l.segments {
+			if !assign(segment[0], segments[i:i+1]) {
+				return nil, false
+			}
+		}
+	} else {
+		suffix := len(l.segments) - variable - 1
+		if len(segments) < len(l.segments)-1 {
+			return nil, false
+		}
+		for i, segment := range l.segments[:variable] {
+			if !assign(segment[0], segments[i:i+1]) {
+				return nil, false
+			}
+		}
+		for i, segment := range l.segments[variable+1:] {
+			j := len(segments) - suffix + i
+			if !assign(segment[0], segments[j:j+1]) {
+				return nil, false
+			}
+		}
+		if !assign(l.segments[variable][0], segments[variable:len(segments)-suffix]) {
+			return nil, false
+		}
+	}
+
+	// rebuild the components
+	host, ok := values["host"]
+	if !ok {
+		return nil, false
+	}
+	components = append(components, host...)
+
+	if path, ok := values["path"]; ok {
+		return append(components, path...), true
+	}
+
+	name, ok := values["name"]
+	if !ok {
+		return nil, false
+	}
+	if group, ok := values["group"]; ok {
+		components = append(components, group...)
+	} else {
+		components = append(components, values["owner"]...)
+	}
+	return append(components, name...), true
+}
@@ -0,0 +1,132 @@
+package env_test
+
+//spellchecker:words errors reflect testing ggman internal
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.tkw01536.de/ggman/internal/env"
+)
+
+func TestParseLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		layout       string
+		wantPosition int
+		wantExplain  string
+		wantErr      bool
+	}{
+		{"empty layout", "", 0, "", false},
+		{"named references", "{host}/{owner}/{name}", 0, "", false},
+		{"modifiers and indexes", "{lower:host}/{-1}", 0, "", false},
+		{"escaped characters", `\{{name}\}`, 0, "", false},
+		{"only separators", "//", 0, "layout has no segments:\n\n    //\n    ^", true},
+		{"parent segment", "{host}/../{name}", 7, "segment \"..\" is not allowed:\n\n    {host}/../{name}\n           ^", true},
+		{"trailing escape", `{name}\`, 6, "escape character at end of layout:\n\n    {name}\\\n          ^", true},
+		{"unclosed reference", "{host}/{name", 7, "unclosed '{':\n\n    {host}/{name\n           ^", true},
+		{"zero index", "{0}", 0, "component index must not be 0 (components are counted from 1):\n\n    {0}\n    ^", true},
+		{"unknown modifier", "{title:name}", 0, "unknown modifier \"title\" (expected 'lower' or 'upper'):\n\n    {title:name}\n    ^", true},
+		{"unknown reference", "{host}/{repo}", 7, "unknown reference \"repo\" (expected a component index, 'host', 'owner', 'name', 'group' or 'path'):\n\n    {host}/{repo}\n           ^", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := env.ParseLayout(tt.layout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			var layoutErr *env.LayoutError
+			if !errors.As(err, &layoutErr) {
+				t.Fatalf("ParseLayout() error = %v, want *LayoutError", err)
+			}
+			if layoutErr.Position != tt.wantPosition {
+				t.Errorf("LayoutError.Position = %d, want %d", layoutErr.Position, tt.wantPosition)
+			}
+			if got := layoutErr.Explain(); got != tt.wantExplain {
+				t.Errorf("LayoutError.Explain() = %q, want %q", got, tt.wantExplain)
+			}
+		})
+	}
+}
+
+func TestLayout_Apply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		layout string
+		url    string
+		want   []string
+	}{
+		{"", "git@gitlab.com:group/subgroup/repo.git", []string{"gitlab.com", "group", "subgroup", "repo"}},
+		{"{host}/{path}", "git@gitlab.com:group/subgroup/repo.git", []string{"gitlab.com", "group", "subgroup", "repo"}},
+		{"{host}/{group}/{name}", "git@gitlab.com:group/subgroup/repo.git", []string{"gitlab.com", "group", "subgroup", "repo"}},
+
+		{"{owner}/{name}", "git@github.com:hello/world.git", []string{"hello", "world"}},
+		{"{host}/{name}", "git@gitlab.com:group/subgroup/repo.git", []string{"gitlab.com", "repo"}},
+		{"{host}/{owner}/{name}", "git@gitlab.com:group/subgroup/repo.git", []string{"gitlab.com", "group", "repo"}},
+		{"{host}/{owner}/{name}", "https://example.com/repo.git", []string{"example.com", "repo"}},
+
+		{"src/{upper:host}/{owner}-{name}", "git@github.com:hello/world.git", []string{"src", "GITHUB.COM", "hello-world"}},
+		{"{-1}/{1}/{5}", "git@github.com:hello/world.git", []string{"world", "github.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout+" "+tt.url, func(t *testing.T) {
+			t.Parallel()
+
+			layout, err := env.ParseLayout(tt.layout)
+			if err != nil {
+				t.Fatalf("ParseLayout() error = %v", err)
+			}
+			if got := layout.Apply(env.ParseURL(tt.url)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Layout.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayout_Match(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		layout   string
+		segments []string
+		want     []string
+		wantOK   bool
+	}{
+		{"", []string{"github.com", "hello", "world"}, []string{"github.com", "hello", "world"}, true},
+		{"{host}/{path}", []string{"gitlab.com", "group", "subgroup", "repo"}, []string{"gitlab.com", "group", "subgroup", "repo"}, true},
+		{"{host}/{group}/{name}", []string{"gitlab.com", "group", "subgroup", "repo"}, []string{"gitlab.com", "group", "subgroup", "repo"}, true},
+		{"{host}/{owner}/{name}", []string{"github.com", "hello", "world"}, []string{"github.com", "hello", "world"}, true},
+		{"{host}/{name}", []string{"gitlab.com", "repo"}, []string{"gitlab.com", "repo"}, true},
+		{"src/{host}/{owner}/{name}", []string{"src", "github.com", "hello", "world"}, []string{"github.com", "hello", "world"}, true},
+
+		{"src/{host}/{owner}/{name}", []string{"dst", "github.com", "hello", "world"}, nil, false},
+		{"{host}/{owner}/{name}", []string{"github.com", "world"}, nil, false},
+		{"{owner}/{name}", []string{"hello", "world"}, nil, false},
+		{"{host}/{owner}-{name}", []string{"github.com", "hello-world"}, nil, false},
+		{"{host}/{1}", []string{"github.com", "github.com"}, nil, false},
+		{"{host}/{group}/{path}", []string{"github.com", "hello", "world"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			t.Parallel()
+
+			layout, err := env.ParseLayout(tt.layout)
+			if err != nil {
+				t.Fatalf("ParseLayout() error = %v", err)
+			}
+			got, gotOK := layout.Match(tt.segments)
+			if !reflect.DeepEqual(got, tt.want) || gotOK != tt.wantOK {
+				t.Errorf("Layout.Match() = (%v, %v), want (%v, %v)", got, gotOK, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -11,4 +11,7 @@ type Requirement struct {
 
 	// Does the environment require a CanFile?
 	NeedsCanFile bool
+
+	// Does the environment require an archive directory?
+	NeedsArchive bool
 }
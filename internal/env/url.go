@@ -1,13 +1,16 @@
 package env
 
-//spellchecker:words strconv strings ggman internal parseurl split pkglib text
+//spellchecker:words errors neturl netip strconv strings ggman internal parseurl split
 import (
+	"errors"
+	"fmt"
+	"net/netip"
+	neturl "net/url"
 	"strconv"
 	"strings"
 
 	"go.tkw01536.de/ggman/internal/parseurl"
 	"go.tkw01536.de/ggman/internal/split"
-	"go.tkw01536.de/pkglib/text"
 )
 
 //spellchecker:words mydomain recvcheck
@@ -50,7 +53,14 @@ func (url URL) String() string {
 		builder.WriteString("@")
 	}
 
-	builder.WriteString(url.HostName)
+	if strings.ContainsRune(url.HostName, ':') {
+		// IPv6 address
+		builder.WriteString("[")
+		builder.WriteString(url.HostName)
+		builder.WriteString("]")
+	} else {
+		builder.WriteString(url.HostName)
+	}
 	if url.Port != 0 {
 		builder.WriteString(":")
 		builder.WriteString(strconv.FormatUint(uint64(url.Port), 10))
@@ -124,6 +134,125 @@ func ParseURL(s string) (url URL) {
 	return
 }
 
+var (
+	errURLEmpty         = errors.New("url is empty")
+	errURLMultipleAt    = errors.New("ambiguous user: multiple '@' found")
+	errURLUnclosedIPv6  = errors.New("missing ']' after IPv6 address")
+	errURLInvalidIPv6   = errors.New("invalid IPv6 address")
+	errURLAfterIPv6     = errors.New("unexpected character after IPv6 address")
+	errURLAmbiguousPort = errors.New("ambiguous port or path: use a scheme like 'ssh://' to specify a port")
+	errURLInvalidPort   = errors.New("invalid port")
+	errURLMissingHost   = errors.New("missing hostname")
+	errURLInvalidEscape = errors.New("invalid percent-escape")
+)
+
+// ParseURLStrict is like ParseURL, but returns an error for invalid or ambiguous input.
+// For any input that ParseURL handles unambiguously and that contains no percent-escapes, query strings or fragments,
+// both functions return the same URL.
+// ParseURL leaves percent-escapes as-is, whereas ParseURLStrict decodes them.
+//
+// In addition to ParseURL, ParseURLStrict
+//
+//   - supports IPv6 hostnames in brackets, e.g. '[::1]:repo.git' or 'ssh://git@[fe80::1]:2222/repo.git';
+//   - only treats '@' and ':' before the first '/' as part of the user or hostname;
+//   - decodes percent-escapes in the user, password and path; and
+//   - removes query strings and fragments.
+//
+// It returns an error for an empty url, multiple '@'s, invalid IPv6 addresses, invalid ports, invalid percent-escapes,
+// a missing hostname in a non-file url, and for the form 'hostname:1234/path', where '1234' could be a port or a part of the path.
+func ParseURLStrict(s string) (url URL, err error) {
+	rest := windowsReplacer.Replace(s) // normalize for windows!
+	if strings.TrimSpace(rest) == "" {
+		return URL{}, errURLEmpty
+	}
+	url.Scheme, rest = parseurl.SplitScheme(rest)
+
+	// remove query strings and fragments
+	if end := strings.IndexAny(rest, "?#"); end >= 0 {
+		rest = rest[:end]
+	}
+
+	// the authority is everything up to the first '/'
+	authority := rest
+	if slash := strings.IndexRune(rest, '/'); slash >= 0 {
+		authority = rest[:slash]
+	}
+
+	// split off authentication, if any.
+	switch strings.Count(authority, "@") {
+	case 0:
+	case 1:
+		at := strings.IndexRune(rest, '@')
+		url.User, rest = rest[:at], rest[at+1:]
+		url.User, url.Password = split.AfterRune(url.User, ':')
+		if url.User, err = neturl.PathUnescape(url.User); err != nil {
+			return URL{}, fmt.Errorf("%w in user: %w", errURLInvalidEscape, err)
+		}
+		if url.Password, err = neturl.PathUnescape(url.Password); err != nil {
+			return URL{}, fmt.Errorf("%w in password: %w", errURLInvalidEscape, err)
+		}
+	default:
+		return URL{}, errURLMultipleAt
+	}
+
+	// split off the hostname, leaving the remainder after a ':' in rest
+	var hasColon bool
+	if strings.HasPrefix(rest, "[") {
+		end := strings.IndexRune(rest, ']')
+		if end < 0 {
+			return URL{}, errURLUnclosedIPv6
+		}
+		url.HostName, rest = rest[1:end], rest[end+1:]
+		if addr, err := netip.ParseAddr(url.HostName); err != nil || !addr.Is6() {
+			return URL{}, fmt.Errorf("%q: %w", url.HostName, errURLInvalidIPv6)
+		}
+
+		switch {
+		case rest == "":
+		case rest[0] == '/':
+			rest = rest[1:]
+		case rest[0] == ':':
+			hasColon, rest = true, rest[1:]
+		default:
+			return URL{}, errURLAfterIPv6
+		}
+	} else {
+		colon := strings.IndexRune(rest, ':')
+		slash := strings.IndexRune(rest, '/')
+		if colon >= 0 && (slash < 0 || colon < slash) {
+			url.HostName, rest = rest[:colon], rest[colon+1:]
+			hasColon = true
+		} else {
+			url.HostName, rest = split.AfterRune(rest, '/')
+		}
+	}
+
+	// we have the form "hostname:port/path" or "hostname:path".
+	// the former is only valid if we have a scheme.
+	if hasColon {
+		port, path := split.AfterRune(rest, '/')
+		switch {
+		case url.Scheme != "":
+			if url.Port, err = parseurl.ParsePort(port); err != nil {
+				return URL{}, fmt.Errorf("%q: %w", port, errURLInvalidPort)
+			}
+			rest = path
+		case port != "" && strings.Trim(port, "0123456789") == "":
+			return URL{}, errURLAmbiguousPort
+		}
+	}
+
+	if url.HostName == "" && url.Scheme != "" && url.Scheme != "file" {
+		return URL{}, errURLMissingHost
+	}
+
+	if url.Path, err = neturl.PathUnescape(rest); err != nil {
+		return URL{}, fmt.Errorf("%w in path: %w", errURLInvalidEscape, err)
+	}
+
+	return url, nil
+}
+
 // IsLocal checks if this URL looks like a local URL.
 // A URL is considered local if it uses the "file" scheme, or the scheme is empty and the hostname is one of ".", ".." or "".
 func (url URL) IsLocal() bool {
@@ -163,76 +292,70 @@ func (url URL) Components() []string {
 	return components
 }
 
-// Canonical returns the canonical version of this URI given a canonical specification
-// the canonical specification can contain any character, except for three special ones
-
-// ^ -- replaced by the first un-used component of the URI
-// % -- replaced by the second un-used component of the URI (commonly username)
-// ! -- consumes a component without using it
-// $ -- replaced by all remaining components in the URI joined with a '/'. Also stops all processing afterwards.
-// If $ does not exist in the cSpec, it is assumed to be at the end of the cSpec.
+// Canonical returns the canonical version of this URI given a canonical specification.
+// See [CanSpec] for a description of the syntax.
+//
+// Only the special characters '^', '%', '!' and '$' are interpreted.
+// In particular, the characters '\' and '{' are copied literally.
+// To use the extended syntax, use [URL.CanonicalExtended].
 func (url URL) Canonical(cSpec string) (canonical string) {
-	if cSpec == "$$" {
-		return url.String()
-	}
-	var builder strings.Builder
-
-	components := url.Components()                // get the components of the URI
-	prefix, suffix := split.AfterRune(cSpec, '$') // split into mod-able and static part
-
-	for i, r := range prefix {
-		// no more components left
-		// => we can immediately exit the loop
-		if len(components) == 0 {
-			builder.WriteString(prefix[i:])
-			break
-		}
-
-		switch r {
-		case '%':
-			// insufficient components.
-			if len(components) < 2 {
-				builder.WriteRune(r)
-				break /* switch */
-			}
+	spec, _ := parseCanSpec(cSpec, false) // never fails
+	return spec.Apply(url)
+}
 
-			// write the second component
-			builder.WriteString(components[1])
-			components[1] = components[0]
-			components = components[1:]
-		case '^':
-			// write the first component
-			builder.WriteString(components[0])
-			components = components[1:]
-		case '!':
-			// drop the component of the URL
-			components = components[1:]
-		default:
-			builder.WriteRune(r)
-		}
+// CanonicalExtended is like [URL.Canonical], but uses the extended CANSPEC syntax including escapes and references.
+//
+// If cSpec cannot be parsed, it falls back to [URL.Canonical].
+// To check a CANSPEC for errors, use [ParseCanSpec].
+func (url URL) CanonicalExtended(cSpec string) (canonical string) {
+	spec, err := ParseCanSpec(cSpec)
+	if err != nil {
+		return url.Canonical(cSpec)
 	}
+	return spec.Apply(url)
+}
 
-	// add all the components to replace the '$'
-	_, _ = text.Join(&builder, components, "/") // ignore cause this should never fail
-
-	// add the suffix
-	builder.WriteString(suffix)
-
-	return builder.String()
+// canonicalLine returns the canonical version of this URI given a CANSPEC from line.
+// Uses the extended syntax only if the line uses it.
+func (url URL) canonicalLine(line CanLine, cSpec string) string {
+	if line.Extended {
+		return url.CanonicalExtended(cSpec)
+	}
+	return url.Canonical(cSpec)
 }
 
-// CanonicalWith returns the canonical url given a set of lines
-// If no pattern matches, return the best-guess original url.
-func (url URL) CanonicalWith(lines CanFile) (canonical string) {
+// CanLineWith returns the first line matching this url.
+// If no line matches, returns ok = false.
+func (url URL) CanLineWith(lines CanFile) (line CanLine, ok bool) {
 	var pat PatternFilter
 	for _, line := range lines {
 		pat.Set(line.Pattern)
 		if pat.MatchesURL(url) {
-			return url.Canonical(line.Canonical)
+			return line, true
 		}
 	}
+	return CanLine{}, false
+}
 
-	return url.String()
+// CanonicalWith returns the canonical url given a set of lines
+// If no pattern matches, return the best-guess original url.
+func (url URL) CanonicalWith(lines CanFile) (canonical string) {
+	line, ok := url.CanLineWith(lines)
+	if !ok {
+		return url.String()
+	}
+	return url.canonicalLine(line, line.Canonical)
+}
+
+// CanonicalPushWith returns the canonical push url given a set of lines.
+// The push url is determined by the push CANSPEC of the first matching line.
+// If the first matching line has no push CANSPEC, or no line matches, returns ok = false.
+func (url URL) CanonicalPushWith(lines CanFile) (push string, ok bool) {
+	line, ok := url.CanLineWith(lines)
+	if !ok || line.Push == "" {
+		return "", false
+	}
+	return url.canonicalLine(line, line.Push), true
 }
 
 // ComponentsOf returns the components of the URL in s.
@@ -1,12 +1,13 @@
 package env
 
-//spellchecker:words slices strings
+//spellchecker:words path filepath slices strings
 import (
+	"path/filepath"
 	"slices"
 	"strings"
 )
 
-//spellchecker:words GGROOT ggman workdir
+//spellchecker:words GGROOT GGROOTS GGSCAN GGLAYOUT GGARCHIVE GGJUNK ggman workdir
 
 // UserVariable is a variable that is exposed to the user.
 // See GetUserVariables() for a details.
@@ -22,6 +23,31 @@ var allVariables = []UserVariable{
 		Description: "root folder all ggman repositories will be cloned to",
 		Get:         func(env *Env) string { return env.Root },
 	},
+	{
+		Key:         "GGROOTS",
+		Description: "list of all root folders repositories may be cloned to",
+		Get:         func(env *Env) string { return strings.Join(env.Roots, string(filepath.ListSeparator)) },
+	},
+	{
+		Key:         "GGSCAN",
+		Description: "list of additional folders searched for repositories",
+		Get:         func(env *Env) string { return strings.Join(env.ScanRoots, string(filepath.ListSeparator)) },
+	},
+	{
+		Key:         "GGARCHIVE",
+		Description: "folder archived repositories are moved to",
+		Get:         func(env *Env) string { return env.Vars.GGARCHIVE },
+	},
+	{
+		Key:         "GGLAYOUT",
+		Description: "layout template of repositories within their root folder",
+		Get:         func(env *Env) string { return env.Vars.GGLAYOUT },
+	},
+	{
+		Key:         "GGJUNK",
+		Description: "list of junk file names that do not prevent a directory from being empty",
+		Get:         func(env *Env) string { return env.Vars.GGJUNK },
+	},
 	{
 		Key:         "PWD",
 		Description: "current working directory",
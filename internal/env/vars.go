@@ -8,7 +8,7 @@ import (
 	"go.tkw01536.de/pkglib/reflectx"
 )
 
-//spellchecker:words ggman GGROOT GGNORM
+//spellchecker:words ggman GGROOT GGSCAN GGNORM GGLAYOUT GGARCHIVE GGJUNK
 
 // Variables represents the values of specific environment variables.
 // Unset variables are represented as the empty string.
@@ -23,10 +23,17 @@ type Variables struct {
 	HOME string
 
 	// other environment variables
-	PATH    string `env:"PATH"`
-	GGROOT  string `env:"GGROOT"`
-	CANFILE string `env:"GGMAN_CANFILE"`
-	GGNORM  string `env:"GGNORM"`
+	PATH      string `env:"PATH"`
+	GGROOT    string `env:"GGROOT"`
+	GGSCAN    string `env:"GGSCAN"`
+	CANFILE   string `env:"GGMAN_CANFILE"`
+	GGNORM    string `env:"GGNORM"`
+	GGLAYOUT  string `env:"GGLAYOUT"`
+	GGARCHIVE string `env:"GGARCHIVE"`
+	GGJUNK    string `env:"GGJUNK"`
+
+	// SHELL is the users' preferred shell, used by 'ggman exec --shell'.
+	SHELL string `env:"SHELL"`
 }
 
 // variableEnvNames holds a mapping from reflect-field-indexes in Variables to os.GetEnv() names.
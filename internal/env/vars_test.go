@@ -8,7 +8,7 @@ import (
 	"go.tkw01536.de/ggman/internal/env"
 )
 
-//spellchecker:words GGROOT GGNORM USERPROFILE GGMAN
+//spellchecker:words GGROOT GGSCAN GGNORM GGLAYOUT GGARCHIVE GGJUNK USERPROFILE GGMAN
 
 func TestReadVariables(t *testing.T) {
 	// set fake environment variables for test
@@ -16,16 +16,26 @@ func TestReadVariables(t *testing.T) {
 	t.Setenv("HOME", "/fake/home")
 	t.Setenv("USERPROFILE", "/fake/home")
 	t.Setenv("GGROOT", "/fake/ggroot")
+	t.Setenv("GGSCAN", "/fake/ggscan")
 	t.Setenv("GGMAN_CANFILE", "/fake/canfile")
 	t.Setenv("GGNORM", "something-fake")
+	t.Setenv("GGLAYOUT", "fake-layout")
+	t.Setenv("GGARCHIVE", "/fake/ggarchive")
+	t.Setenv("GGJUNK", ".fake-junk")
+	t.Setenv("SHELL", "/fake/shell")
 
 	got := env.ReadVariables()
 	want := env.Variables{
-		HOME:    "/fake/home",
-		PATH:    "/fake/path",
-		GGROOT:  "/fake/ggroot",
-		CANFILE: "/fake/canfile",
-		GGNORM:  "something-fake",
+		HOME:      "/fake/home",
+		PATH:      "/fake/path",
+		GGROOT:    "/fake/ggroot",
+		GGSCAN:    "/fake/ggscan",
+		CANFILE:   "/fake/canfile",
+		GGNORM:    "something-fake",
+		GGLAYOUT:  "fake-layout",
+		GGARCHIVE: "/fake/ggarchive",
+		GGJUNK:    ".fake-junk",
+		SHELL:     "/fake/shell",
 	}
 
 	if !reflect.DeepEqual(got, want) {
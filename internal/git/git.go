@@ -11,13 +11,15 @@
 // For this reason, implementation of the Plumbing interface are not exported.
 package git
 
-//spellchecker:words context errors path filepath sync ggman internal dirs pkglib stream
+//spellchecker:words context errors exec path filepath strings sync ggman internal dirs pkglib stream
 import (
 	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"go.tkw01536.de/ggman/internal/dirs"
@@ -130,6 +132,7 @@ type Git interface {
 	ContainsBranch(ctx context.Context, clonePath, branch string) (exists bool, err error)
 
 	// IsDirty checks if the repository at clonePath contains uncommitted changes.
+	// This includes staged changes and untracked files.
 	//
 	// If there is no repository at clonePath returns ErrNotARepository.
 	// May return other error types for other errors.
@@ -141,8 +144,100 @@ type Git interface {
 	// May return other error types for other errors.
 	IsSync(ctx context.Context, clonePath string) (synced bool, err error)
 
+	// GetUnpushedBranches gets the local branches of the repository at clonePath that are not contained in any upstream.
+	// unpushed contains the branches that have commits not contained in their upstream.
+	// localOnly contains the branches that do not have an upstream.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	GetUnpushedBranches(ctx context.Context, clonePath string) (unpushed, localOnly []string, err error)
+
+	// HasStash checks if the repository at clonePath contains any stashed changes.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	HasStash(ctx context.Context, clonePath string) (stashed bool, err error)
+
+	// Grep searches the tracked files of the repository at clonePath for lines matching options.
+	// When using a native git, the working tree is searched; otherwise the files of the HEAD commit are searched.
+	// Binary files are skipped.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	Grep(ctx context.Context, clonePath string, options GrepOptions) (matches []GrepMatch, err error)
+
+	// Log iterates over the commits of the repository at clonePath that match options.
+	// Commits reachable from HEAD are considered; when options.All is set, commits reachable from any reference are considered.
+	// For each matching commit, yield is called until it returns false.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	Log(ctx context.Context, clonePath string, options LogOptions, yield func(Commit) bool) error
+
+	// GetStats computes size and activity statistics of the repository at clonePath.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	GetStats(ctx context.Context, clonePath string) (stats Stats, err error)
+
+	// GetGitSize computes the total size of the git directory of the repository at clonePath.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	GetGitSize(ctx context.Context, clonePath string) (size int64, err error)
+
+	// GarbageCollect removes unreachable objects from, and repacks the objects of, the repository at clonePath.
+	// Output is directed to stream.Stdout and stream.Stderr.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	GarbageCollect(ctx context.Context, stream stream.IOStream, clonePath string) error
+
+	// RegisterMaintenance registers the repository at clonePath for background maintenance using 'git maintenance register'.
+	// When no native git is available, returns ErrMaintenanceUnsupported.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	RegisterMaintenance(ctx context.Context, clonePath string) error
+
+	// GetPushURLs gets the push urls of the remote with the given name of the repository at clonePath.
+	// If the remote has no separate push urls, returns an empty list.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	GetPushURLs(ctx context.Context, clonePath string, name string) (urls []string, err error)
+
+	// SetPushURLs sets the push urls of the remote with the given name of the repository at clonePath.
+	// An empty list of urls removes all push urls.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	SetPushURLs(ctx context.Context, clonePath string, name string, urls []string) error
+
+	// SetConfig sets the local git config key of the repository at clonePath to value.
+	// Key must be of the form 'section.option' or 'section.subsection.option'.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	SetConfig(ctx context.Context, clonePath string, key, value string) error
+
+	// GetConfig returns the value of the git config key of the repository at clonePath.
+	// Key must be of the form 'section.option' or 'section.subsection.option'.
+	// If the key is not set, returns the empty string.
+	//
+	// When a native git is used, all configuration scopes are considered.
+	// Otherwise only the local configuration of the repository is.
+	//
+	// If there is no repository at clonePath returns ErrNotARepository.
+	// May return other error types for other errors.
+	GetConfig(ctx context.Context, clonePath string, key string) (string, error)
+
 	// GitPath returns the path to the git executable being used, if any.
 	GitPath() string
+
+	// GitVersion returns the version reported by the git executable being used.
+	// If no git executable is used, returns an empty string.
+	GitVersion(ctx context.Context) (version string, err error)
 }
 
 // In particular, this function does not checks on the error values returned and passes them directly from the implementation to the caller.
@@ -489,6 +584,193 @@ func (impl *defaultGitWrapper) IsSync(ctx context.Context, clonePath string) (sy
 	return sync, nil
 }
 
+func (impl *defaultGitWrapper) GetUnpushedBranches(ctx context.Context, clonePath string) (unpushed, localOnly []string, err error) {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return nil, nil, ErrNotARepository
+	}
+
+	unpushed, localOnly, err = impl.git.GetUnpushedBranches(ctx, clonePath, repoObject)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%q: failed to get unpushed branches: %w", clonePath, err)
+	}
+	return unpushed, localOnly, nil
+}
+
+func (impl *defaultGitWrapper) HasStash(ctx context.Context, clonePath string) (stashed bool, err error) {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return false, ErrNotARepository
+	}
+
+	stashed, err = impl.git.HasStash(ctx, clonePath, repoObject)
+	if err != nil {
+		return false, fmt.Errorf("%q: failed to check for stash: %w", clonePath, err)
+	}
+	return stashed, nil
+}
+
+func (impl *defaultGitWrapper) Grep(ctx context.Context, clonePath string, options GrepOptions) (matches []GrepMatch, err error) {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return nil, ErrNotARepository
+	}
+
+	matches, err = impl.git.Grep(ctx, clonePath, repoObject, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search files: %w", err)
+	}
+	return matches, nil
+}
+
+func (impl *defaultGitWrapper) Log(ctx context.Context, clonePath string, options LogOptions, yield func(Commit) bool) error {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return ErrNotARepository
+	}
+
+	if err := impl.git.Log(ctx, clonePath, repoObject, options, yield); err != nil {
+		return fmt.Errorf("failed to get commits: %w", err)
+	}
+	return nil
+}
+
+func (impl *defaultGitWrapper) GetStats(ctx context.Context, clonePath string) (stats Stats, err error) {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return Stats{}, ErrNotARepository
+	}
+
+	stats, err = impl.git.GetStats(ctx, clonePath, repoObject)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to get statistics: %w", err)
+	}
+	return stats, nil
+}
+
+func (impl *defaultGitWrapper) GetGitSize(ctx context.Context, clonePath string) (size int64, err error) {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return 0, ErrNotARepository
+	}
+
+	size, err = impl.git.GetGitSize(ctx, clonePath, repoObject)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get git directory size: %w", err)
+	}
+	return size, nil
+}
+
+func (impl *defaultGitWrapper) GarbageCollect(ctx context.Context, stream stream.IOStream, clonePath string) error {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return ErrNotARepository
+	}
+
+	if err := impl.git.GarbageCollect(ctx, stream, clonePath, repoObject); err != nil {
+		return fmt.Errorf("failed to collect garbage: %w", err)
+	}
+	return nil
+}
+
+func (impl *defaultGitWrapper) RegisterMaintenance(ctx context.Context, clonePath string) error {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return ErrNotARepository
+	}
+
+	if err := impl.git.RegisterMaintenance(ctx, clonePath, repoObject); err != nil {
+		return fmt.Errorf("failed to register for maintenance: %w", err)
+	}
+	return nil
+}
+
+func (impl *defaultGitWrapper) GetPushURLs(ctx context.Context, clonePath string, name string) (urls []string, err error) {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return nil, ErrNotARepository
+	}
+
+	urls, err = impl.git.GetRemotePushURLs(ctx, clonePath, repoObject, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push urls: %w", err)
+	}
+	return urls, nil
+}
+
+func (impl *defaultGitWrapper) SetPushURLs(ctx context.Context, clonePath string, name string, urls []string) error {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return ErrNotARepository
+	}
+
+	if err := impl.git.SetRemotePushURLs(ctx, clonePath, repoObject, name, urls); err != nil {
+		return fmt.Errorf("failed to set push urls: %w", err)
+	}
+	return nil
+}
+
+func (impl *defaultGitWrapper) SetConfig(ctx context.Context, clonePath string, key, value string) error {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return ErrNotARepository
+	}
+
+	if err := impl.git.SetConfig(ctx, clonePath, repoObject, key, value); err != nil {
+		return fmt.Errorf("failed to set config: %w", err)
+	}
+	return nil
+}
+
+func (impl *defaultGitWrapper) GetConfig(ctx context.Context, clonePath string, key string) (string, error) {
+	impl.ensureInit()
+
+	// check that the given folder is actually a repository
+	repoObject, isRepo := impl.git.IsRepository(ctx, clonePath)
+	if !isRepo {
+		return "", ErrNotARepository
+	}
+
+	value, err := impl.git.GetConfig(ctx, clonePath, repoObject, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get config: %w", err)
+	}
+	return value, nil
+}
+
 func (impl *defaultGitWrapper) GitPath() string {
 	impl.ensureInit()
 
@@ -498,3 +780,16 @@ func (impl *defaultGitWrapper) GitPath() string {
 	}
 	return gitgit.gitPath
 }
+
+func (impl *defaultGitWrapper) GitVersion(ctx context.Context) (version string, err error) {
+	gitPath := impl.GitPath()
+	if gitPath == "" {
+		return "", nil
+	}
+
+	out, err := exec.CommandContext(ctx, gitPath, "--version").Output() /* #nosec G204 -- gitPath user-controlled by design */
+	if err != nil {
+		return "", fmt.Errorf("failed to get git version: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
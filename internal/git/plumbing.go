@@ -1,25 +1,40 @@
 package git
 
-//spellchecker:words context errors exec path filepath runtime slices github plumbing pkglib exit stream
+//spellchecker:words bytes context errors exec io fs maps path filepath regexp runtime slices strconv strings time github config plumbing gitconfig format index packfile object revlist storer storage filesystem pkglib exit stream
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	gitconfig "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/revlist"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
 	"go.tkw01536.de/pkglib/exit"
 	"go.tkw01536.de/pkglib/fsx"
 	"go.tkw01536.de/pkglib/stream"
 )
 
-//spellchecker:words worktree bref reflike gogit gitgit wrapf storer
+//spellchecker:words worktree bref reflike gogit gitgit wrapf storer pushurl
 
 // Plumbing is an interface that represents a working internal implementation of git.
 // Plumbing is intended to be goroutine-safe, i.e. everything except the Init() method can be called from multiple goroutines at once.
@@ -95,6 +110,39 @@ type Plumbing interface {
 	// The second parameter must be the returned value from IsRepository().
 	SetRemoteURLs(ctx context.Context, clonePath string, repoObject any, name string, urls []string) (err error)
 
+	// GetRemotePushURLs returns the push urls of the remote 'name' of the repository at clonePath.
+	// These are the urls configured using 'remote.<name>.pushurl'.
+	// The remote 'name' must exist.
+	//
+	// This function should only be called if IsRepository(clonePath) returns true.
+	// The second parameter must be the returned value from IsRepository().
+	GetRemotePushURLs(ctx context.Context, clonePath string, repoObject any, name string) (urls []string, err error)
+
+	// SetRemotePushURLs sets the push urls of the remote 'name' of the repository at clonePath.
+	// An empty list of urls removes all push urls.
+	// The remote 'name' must exist.
+	//
+	// This function should only be called if IsRepository(clonePath) returns true.
+	// The second parameter must be the returned value from IsRepository().
+	SetRemotePushURLs(ctx context.Context, clonePath string, repoObject any, name string, urls []string) (err error)
+
+	// SetConfig sets the local git config key of the repository at clonePath to value.
+	// Key must be of the form 'section.option' or 'section.subsection.option'.
+	//
+	// This function should only be called if IsRepository(clonePath) returns true.
+	// The second parameter must be the returned value from IsRepository().
+	SetConfig(ctx context.Context, clonePath string, repoObject any, key, value string) (err error)
+
+	// GetConfig returns the value of the git config key of the repository at clonePath.
+	// Key must be of the form 'section.option' or 'section.subsection.option'.
+	// If the key is not set, returns the empty string.
+	//
+	// Implementations may only consider the local configuration of the repository.
+	//
+	// This function should only be called if IsRepository(clonePath) returns true.
+	// The second parameter must be the returned value from IsRepository().
+	GetConfig(ctx context.Context, clonePath string, repoObject any, key string) (value string, err error)
+
 	// DeleteRemote deletes the remote with the given name from the repository at clonePath.
 	// The remote 'remote' must exist.
 	//
@@ -148,6 +196,7 @@ type Plumbing interface {
 	ContainsBranch(ctx context.Context, clonePath string, cache any, branch string) (contains bool, err error)
 
 	// IsDirty checks if the repository at clonePath contains uncommitted changes.
+	// This includes staged changes and untracked files.
 	//
 	// This function will only be called if IsRepository(clonePath) returns true.
 	// The second parameter passed will be the returned value from IsRepository().
@@ -158,6 +207,196 @@ type Plumbing interface {
 	// This function will only be called if IsRepository(clonePath) returns true.
 	// The second parameter passed will be the returned value from IsRepository().
 	IsSync(ctx context.Context, clonePath string, cache any) (dirty bool, err error)
+
+	// GetUnpushedBranches gets the local branches of the repository at clonePath that are not contained in any upstream.
+	// unpushed contains the branches that have commits not contained in their upstream.
+	// localOnly contains the branches that do not have an upstream.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	GetUnpushedBranches(ctx context.Context, clonePath string, cache any) (unpushed, localOnly []string, err error)
+
+	// HasStash checks if the repository at clonePath contains any stashed changes.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	HasStash(ctx context.Context, clonePath string, cache any) (stashed bool, err error)
+
+	// Grep searches the tracked files of the repository at clonePath for lines matching options.
+	// Binary files are skipped.
+	// If no line matches, returns no matches and a nil error.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	Grep(ctx context.Context, clonePath string, cache any, options GrepOptions) (matches []GrepMatch, err error)
+
+	// Log iterates over the commits of the repository at clonePath that match options.
+	// Commits reachable from HEAD are considered; when options.All is set, commits reachable from any reference are considered.
+	// For each matching commit, yield is called until it returns false.
+	// A repository without commits yields nothing.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	Log(ctx context.Context, clonePath string, cache any, options LogOptions, yield func(Commit) bool) error
+
+	// GetStats computes size and activity statistics of the repository at clonePath.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	GetStats(ctx context.Context, clonePath string, cache any) (stats Stats, err error)
+
+	// GetGitSize computes the total size of the git directory of the repository at clonePath.
+	// It is cheaper than GetStats when only the size is needed.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	GetGitSize(ctx context.Context, clonePath string, cache any) (size int64, err error)
+
+	// GarbageCollect removes unreachable objects from, and repacks the objects of, the repository at clonePath.
+	// Any output of the underlying process is written to stream.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	GarbageCollect(ctx context.Context, stream stream.IOStream, clonePath string, cache any) error
+
+	// RegisterMaintenance registers the repository at clonePath for background maintenance using 'git maintenance register'.
+	// Implementations without a native git return ErrMaintenanceUnsupported.
+	//
+	// This function will only be called if IsRepository(clonePath) returns true.
+	// The second parameter passed will be the returned value from IsRepository().
+	RegisterMaintenance(ctx context.Context, clonePath string, cache any) error
+}
+
+// ErrMaintenanceUnsupported is returned by Plumbing.RegisterMaintenance when background maintenance is not supported.
+var ErrMaintenanceUnsupported = errors.New("background maintenance requires a native git")
+
+// Stats holds statistics about a repository, see Plumbing.GetStats.
+type Stats struct {
+	WorktreeSize int64 // total size in bytes of files in the working tree, excluding the git directory and nested repositories
+	GitSize      int64 // total size in bytes of files in the git directory
+
+	Objects int // number of loose objects
+	Packs   int // number of pack files
+
+	Commits    int       // number of commits reachable from HEAD
+	Branches   int       // number of local branches
+	LastCommit time.Time // time the HEAD commit was committed, zero if there is none
+}
+
+// GrepOptions describes what to search for in Plumbing.Grep.
+type GrepOptions struct {
+	Pattern    string   // extended regular expression to search for
+	IgnoreCase bool     // search case-insensitively
+	Pathspecs  []string // only search files matching at least one of these pathspecs, if any
+}
+
+// GrepMatch is a single line matched by Plumbing.Grep.
+type GrepMatch struct {
+	Path string // path of the file, relative to the root of the repository and '/'-separated
+	Line int    // line number, starting at 1
+	Text string // text of the matching line
+}
+
+// LogOptions describes which commits to consider in Plumbing.Log.
+type LogOptions struct {
+	Since  time.Time // only consider commits authored at or after this time, unless zero
+	Until  time.Time // only consider commits authored before this time, unless zero
+	Author string    // only consider commits whose 'Name <email>' matches this regular expression, if non-empty
+	All    bool      // consider commits reachable from any reference, not just HEAD
+}
+
+// Commit is a single commit returned by Plumbing.Log.
+type Commit struct {
+	Hash    string    // full hash of the commit
+	Author  string    // name of the author
+	Email   string    // email address of the author
+	Date    time.Time // time the commit was authored
+	Subject string    // first line of the commit message
+}
+
+// matches checks if commit is within the time range of options and matches author.
+// Author should be the compiled author pattern of options, if any.
+func (options LogOptions) matches(commit *object.Commit, author *regexp.Regexp) bool {
+	when := commit.Author.When
+	if !options.Since.IsZero() && when.Before(options.Since) {
+		return false
+	}
+	if !options.Until.IsZero() && !when.Before(options.Until) {
+		return false
+	}
+	return author == nil || author.MatchString(commit.Author.String())
+}
+
+// Regexp compiles the pattern of these options into a regular expression.
+//
+// The pattern must be a POSIX extended regular expression.
+// This restricts it to the syntax understood by both 'git grep -E' and the regexp package,
+// rejecting e.g. Perl-style character classes such as '\d' or flags such as '(?i)'.
+func (options GrepOptions) Regexp() (*regexp.Regexp, error) {
+	if _, err := regexp.CompilePOSIX(options.Pattern); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	pattern := options.Pattern
+	if options.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// PathspecRegexps compiles the pathspecs of these options into regular expressions.
+//
+// Like in git, pathspecs are glob patterns where '*' and '?' also match '/'.
+// A pathspec furthermore matches all files within a matching directory.
+func (options GrepOptions) PathspecRegexps() ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(options.Pathspecs))
+	for _, spec := range options.Pathspecs {
+		re, err := regexp.Compile("^" + globToRegexp(spec) + "(/.*)?$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pathspec %q: %w", spec, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// globToRegexp turns the glob pattern glob into a regular expression.
+func globToRegexp(glob string) string {
+	var builder strings.Builder
+	builder.WriteString("(?s)")
+
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			builder.WriteString(".*")
+		case '?':
+			builder.WriteString(".")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			builder.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				builder.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + strings.ReplaceAll(class, "\\", "\\\\") + "]")
+			i += end + 1
+		default:
+			builder.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return builder.String()
 }
 
 // NewPlumbing returns an implementation of a plumbing that has no external dependencies.
@@ -291,22 +530,180 @@ func (gg *gitgit) Pull(ctx context.Context, stream stream.IOStream, clonePath st
 	return err
 }
 
-func (gg *gitgit) IsDirty(ctx context.Context, clonePath string, cache any) (dirty bool, err error) {
-	cmd := exec.CommandContext(ctx, gg.gitPath, "diff", "--quiet") /* #nosec G204 -- gitPath user-controlled by design */
+func (gg *gitgit) SetRemotePushURLs(ctx context.Context, clonePath string, repoObject any, name string, urls []string) error {
+	// check that the remote exists
+	r := repoObject.(*git.Repository)
+	if _, err := r.Remote(name); err != nil {
+		return fmt.Errorf("failed to find remote %q in %q: %w", name, clonePath, err)
+	}
+
+	key := "remote." + name + ".pushurl"
+
+	commands := make([][]string, 0, len(urls)+1)
+	commands = append(commands, []string{"config", "--unset-all", key})
+	for _, url := range urls {
+		commands = append(commands, []string{"config", "--add", key, url})
+	}
+
+	for i, args := range commands {
+		cmd := exec.CommandContext(ctx, gg.gitPath, args...) /* #nosec G204 -- gitPath user-controlled by design */
+		cmd.Dir = clonePath
+
+		err := cmd.Run()
+
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			// code 5: there were no push urls to unset
+			if i == 0 && exitError.ExitCode() == 5 {
+				continue
+			}
+			err = exit.FromExitError(exitError)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (gg *gitgit) SetConfig(ctx context.Context, clonePath string, repoObject any, key, value string) error {
+	if _, _, _, err := splitConfigKey(key); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, gg.gitPath, "config", key, value) /* #nosec G204 -- gitPath user-controlled by design */
 	cmd.Dir = clonePath
 
-	// run the underlying command
-	err = cmd.Run()
+	err := cmd.Run()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		err = exit.FromExitError(exitError)
+	}
+	return err
+}
+
+func (gg *gitgit) GetConfig(ctx context.Context, clonePath string, repoObject any, key string) (string, error) {
+	if _, _, _, err := splitConfigKey(key); err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, gg.gitPath, "config", "--get", key) /* #nosec G204 -- gitPath user-controlled by design */
+	cmd.Dir = clonePath
+
+	out, err := cmd.Output()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		// code 1: key is not set
+		if exitError.ExitCode() == 1 {
+			return "", nil
+		}
+		err = exit.FromExitError(exitError)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (gg *gitgit) Grep(ctx context.Context, clonePath string, cache any, options GrepOptions) ([]GrepMatch, error) {
+	// reject patterns the go-git backend would not accept either
+	if _, err := options.Regexp(); err != nil {
+		return nil, err
+	}
+
+	args := []string{"grep", "--no-color", "-n", "-z", "-I", "-E"}
+	if options.IgnoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", options.Pattern, "--")
+	args = append(args, options.Pathspecs...)
+
+	cmd := exec.CommandContext(ctx, gg.gitPath, args...) /* #nosec G204 -- gitPath user-controlled by design */
+	cmd.Dir = clonePath
+
+	out, err := cmd.Output()
 
 	var exitError *exec.ExitError
 	if errors.As(err, &exitError) {
-		// code 1: it is dirty
+		// code 1: nothing matched
 		if exitError.ExitCode() == 1 {
-			return true, nil
+			return nil, nil
+		}
+		err = exit.FromExitError(exitError)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// each match is of the form 'path\0line\0text\n'
+	var matches []GrepMatch
+	for len(out) > 0 {
+		path, rest, ok1 := bytes.Cut(out, []byte{0})
+		line, rest, ok2 := bytes.Cut(rest, []byte{0})
+		text, rest, _ := bytes.Cut(rest, []byte{'\n'})
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%q: unable to parse output of git grep", clonePath)
+		}
+		out = rest
+
+		number, err := strconv.Atoi(string(line))
+		if err != nil {
+			return nil, fmt.Errorf("%q: unable to parse output of git grep: %w", clonePath, err)
 		}
+		matches = append(matches, GrepMatch{Path: string(path), Line: number, Text: string(text)})
+	}
+	return matches, nil
+}
+
+func (gg *gitgit) GarbageCollect(ctx context.Context, stream stream.IOStream, clonePath string, cache any) error {
+	cmd := exec.CommandContext(ctx, gg.gitPath, "gc", "--quiet") /* #nosec G204 -- gitPath user-controlled by design */
+	cmd.Dir = clonePath
+	cmd.Stdin = stream.Stdin
+	cmd.Stdout = stream.Stdout
+	cmd.Stderr = stream.Stderr
+
+	// run the underlying command, but treat ExitError specially by turning it into a ExitError
+	err := cmd.Run()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		err = exit.FromExitError(exitError)
+	}
+	return err
+}
+
+func (gg *gitgit) RegisterMaintenance(ctx context.Context, clonePath string, cache any) error {
+	cmd := exec.CommandContext(ctx, gg.gitPath, "maintenance", "register") /* #nosec G204 -- gitPath user-controlled by design */
+	cmd.Dir = clonePath
+
+	// run the underlying command, but treat ExitError specially by turning it into a ExitError
+	err := cmd.Run()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		err = exit.FromExitError(exitError)
+	}
+	return err
+}
+
+func (gg *gitgit) IsDirty(ctx context.Context, clonePath string, cache any) (dirty bool, err error) {
+	// unlike 'git diff', 'git status' also reports staged changes and untracked files
+	cmd := exec.CommandContext(ctx, gg.gitPath, "--no-optional-locks", "status", "--porcelain") /* #nosec G204 -- gitPath user-controlled by design */
+	cmd.Dir = clonePath
+
+	// run the underlying command
+	out, err := cmd.Output()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
 		err = exit.FromExitError(exitError)
 	}
-	return false, err
+	if err != nil {
+		return false, err
+	}
+	return len(bytes.TrimSpace(out)) > 0, nil
 }
 
 //
@@ -372,16 +769,41 @@ func (gogit) GetRemotes(ctx context.Context, clonePath string, repoObject any) (
 		return
 	}
 
+	// get the config to distinguish fetch from push urls
+	config, err := r.Config()
+	if err != nil {
+		err = fmt.Errorf("%q: unable to get config: %w", clonePath, err)
+		return
+	}
+
 	// make a map for remotes
 	remotes = make(map[string][]string, len(gitRemotes))
 	for _, r := range gitRemotes {
 		cfg := r.Config()
-		remotes[cfg.Name] = cfg.URLs
+		remotes[cfg.Name] = fetchURLs(config, cfg)
 	}
 
 	return
 }
 
+// pushURLs returns the push urls of the remote with the given name.
+func pushURLs(cfg *config.Config, name string) []string {
+	section := cfg.Raw.Section("remote")
+	if !section.HasSubsection(name) {
+		return nil
+	}
+	return section.Subsection(name).Options.GetAll("pushurl")
+}
+
+// fetchURLs returns the fetch urls of the given remote.
+//
+// go-git appends all push urls to the urls of a remote.
+// This function removes them again.
+func fetchURLs(cfg *config.Config, remote *config.RemoteConfig) []string {
+	urls := remote.URLs
+	return urls[:len(urls)-len(pushURLs(cfg, remote.Name))]
+}
+
 // originRemoteName is the name of the canonical remote.
 const originRemoteName = "origin"
 
@@ -542,14 +964,15 @@ func (gogit) SetRemoteURLs(ctx context.Context, clonePath string, repoObject any
 
 	// get the current remotes
 	remotes := cfg.Remotes[remote.Config().Name]
+	current := fetchURLs(cfg, remotes)
 
 	// if they haven't changed, we can return immediately
-	if slices.Equal(remotes.URLs, urls) {
+	if slices.Equal(current, urls) {
 		return nil
 	}
 
 	// check that they are of the new length
-	if len(remotes.URLs) != len(urls) {
+	if len(current) != len(urls) {
 		return errLengthMustBeEqual
 	}
 
@@ -563,66 +986,194 @@ func (gogit) SetRemoteURLs(ctx context.Context, clonePath string, repoObject any
 	return
 }
 
-func (gogit) Clone(ctx context.Context, stream stream.IOStream, remoteURI, clonePath string, extraArgs ...string) error {
-	// doesn't support extra arguments
-	if len(extraArgs) > 0 {
-		return ErrArgumentsUnsupported
-	}
+func (gogit) GetRemotePushURLs(ctx context.Context, clonePath string, repoObject any, name string) (urls []string, err error) {
+	r := repoObject.(*git.Repository)
 
-	// run a plain git clone but intercept all errors
-	_, err := git.PlainClone(clonePath, false, &git.CloneOptions{URL: remoteURI, Progress: stream.Stderr})
+	cfg, err := r.Config()
 	if err != nil {
-		err = fmt.Errorf("%w: %w", exit.NewErrorWithCode(fmt.Sprintf("failed to clone repository %q", remoteURI), 1), err)
+		return nil, fmt.Errorf("%q: unable to get config: %w", clonePath, err)
+	}
+	if _, ok := cfg.Remotes[name]; !ok {
+		return nil, fmt.Errorf("failed to find remote %q in %q: %w", name, clonePath, git.ErrRemoteNotFound)
 	}
 
-	return err
+	return pushURLs(cfg, name), nil
 }
 
-func (gogit) Fetch(ctx context.Context, stream stream.IOStream, clonePath string, cache any) (err error) {
-	// get the repository
-	r := cache.(*git.Repository)
+func (gogit) SetRemotePushURLs(ctx context.Context, clonePath string, repoObject any, name string, urls []string) error {
+	r := repoObject.(*git.Repository)
 
-	// list all of the remotes
-	remotes, err := r.Remotes()
+	cfg, err := r.Storer.Config()
 	if err != nil {
-		return
+		return fmt.Errorf("%q: unable to get config: %w", clonePath, err)
+	}
+	remote, ok := cfg.Remotes[name]
+	if !ok {
+		return fmt.Errorf("failed to find remote %q in %q: %w", name, clonePath, git.ErrRemoteNotFound)
 	}
 
-	// fetch all of the remotes for this repository
-	for _, remote := range remotes {
-		// fetch and write out an 'already up-to-date'
-		err = remote.Fetch(&git.FetchOptions{Progress: stream.Stderr})
-		err = ignoreErrUpToDate(stream, err)
+	// if they haven't changed, we can return immediately
+	if slices.Equal(pushURLs(cfg, name), urls) {
+		return nil
+	}
 
-		// fail on other errors
-		if err != nil {
-			err = fmt.Errorf("failed to fetch remote %q from %q: %w", remote.Config().Name, clonePath, err)
-			return
-		}
+	// only keep the fetch urls, as go-git would otherwise store push urls as fetch urls.
+	remote.URLs = fetchURLs(cfg, remote)
+
+	subsection := cfg.Raw.Section("remote").Subsection(name)
+	if len(urls) == 0 {
+		subsection.RemoveOption("pushurl")
+	} else {
+		subsection.SetOption("pushurl", urls...)
 	}
 
-	return
+	if err := r.SetConfig(cfg); err != nil {
+		return fmt.Errorf("%q: unable to store config: %w", clonePath, err)
+	}
+	return nil
 }
 
-func (gogit) Pull(ctx context.Context, stream stream.IOStream, clonePath string, cache any) (err error) {
-	// get the repository
-	r := cache.(*git.Repository)
+var errInvalidConfigKey = errors.New("invalid config key: must be of the form 'section.option' or 'section.subsection.option'")
 
-	// get the worktree
-	w, err := r.Worktree()
-	if err != nil {
-		err = fmt.Errorf("%q: unable to find worktree: %w", clonePath, err)
-		return
+// splitConfigKey splits a git config key into section, subsection and option.
+func splitConfigKey(key string) (section, subsection, option string, err error) {
+	first := strings.Index(key, ".")
+	last := strings.LastIndex(key, ".")
+	if first <= 0 || last == len(key)-1 {
+		return "", "", "", fmt.Errorf("%q: %w", key, errInvalidConfigKey)
 	}
 
-	// do a git pull, and ignore error already up-to-date
-	err = w.Pull(&git.PullOptions{Progress: stream.Stderr})
-	err = ignoreErrUpToDate(stream, err)
-	if err != nil {
-		err = fmt.Errorf("%q: unable to pull: %w", clonePath, err)
+	section, option = key[:first], key[last+1:]
+	if first != last {
+		subsection = key[first+1 : last]
 	}
-
-	return
+	return section, subsection, option, nil
+}
+
+func (gogit) SetConfig(ctx context.Context, clonePath string, repoObject any, key, value string) error {
+	section, subsection, option, err := splitConfigKey(key)
+	if err != nil {
+		return err
+	}
+
+	r := repoObject.(*git.Repository)
+
+	cfg, err := r.Storer.Config()
+	if err != nil {
+		return fmt.Errorf("%q: unable to get config: %w", clonePath, err)
+	}
+
+	raw := cfg.Raw
+	if subsection == "" {
+		raw.Section(section).SetOption(option, value)
+	} else {
+		raw.Section(section).Subsection(subsection).SetOption(option, value)
+	}
+
+	// re-read the config from the raw values.
+	// Otherwise the typed fields would take precedence when storing the config.
+	var buffer bytes.Buffer
+	if err := gitconfig.NewEncoder(&buffer).Encode(raw); err != nil {
+		return fmt.Errorf("%q: unable to encode config: %w", clonePath, err)
+	}
+	updated, err := config.ReadConfig(&buffer)
+	if err != nil {
+		return fmt.Errorf("%q: unable to decode config: %w", clonePath, err)
+	}
+
+	// only keep the fetch urls, as go-git would otherwise store push urls as fetch urls.
+	for _, remote := range updated.Remotes {
+		remote.URLs = fetchURLs(updated, remote)
+	}
+
+	if err := r.SetConfig(updated); err != nil {
+		return fmt.Errorf("%q: unable to store config: %w", clonePath, err)
+	}
+	return nil
+}
+
+func (gogit) GetConfig(ctx context.Context, clonePath string, repoObject any, key string) (string, error) {
+	section, subsection, option, err := splitConfigKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	r := repoObject.(*git.Repository)
+
+	cfg, err := r.Storer.Config()
+	if err != nil {
+		return "", fmt.Errorf("%q: unable to get config: %w", clonePath, err)
+	}
+
+	if !cfg.Raw.HasSection(section) {
+		return "", nil
+	}
+	if subsection == "" {
+		return cfg.Raw.Section(section).Option(option), nil
+	}
+	return cfg.Raw.Section(section).Subsection(subsection).Option(option), nil
+}
+
+func (gogit) Clone(ctx context.Context, stream stream.IOStream, remoteURI, clonePath string, extraArgs ...string) error {
+	// doesn't support extra arguments
+	if len(extraArgs) > 0 {
+		return ErrArgumentsUnsupported
+	}
+
+	// run a plain git clone but intercept all errors
+	_, err := git.PlainClone(clonePath, false, &git.CloneOptions{URL: remoteURI, Progress: stream.Stderr})
+	if err != nil {
+		err = fmt.Errorf("%w: %w", exit.NewErrorWithCode(fmt.Sprintf("failed to clone repository %q", remoteURI), 1), err)
+	}
+
+	return err
+}
+
+func (gogit) Fetch(ctx context.Context, stream stream.IOStream, clonePath string, cache any) (err error) {
+	// get the repository
+	r := cache.(*git.Repository)
+
+	// list all of the remotes
+	remotes, err := r.Remotes()
+	if err != nil {
+		return
+	}
+
+	// fetch all of the remotes for this repository
+	for _, remote := range remotes {
+		// fetch and write out an 'already up-to-date'
+		err = remote.Fetch(&git.FetchOptions{Progress: stream.Stderr})
+		err = ignoreErrUpToDate(stream, err)
+
+		// fail on other errors
+		if err != nil {
+			err = fmt.Errorf("failed to fetch remote %q from %q: %w", remote.Config().Name, clonePath, err)
+			return
+		}
+	}
+
+	return
+}
+
+func (gogit) Pull(ctx context.Context, stream stream.IOStream, clonePath string, cache any) (err error) {
+	// get the repository
+	r := cache.(*git.Repository)
+
+	// get the worktree
+	w, err := r.Worktree()
+	if err != nil {
+		err = fmt.Errorf("%q: unable to find worktree: %w", clonePath, err)
+		return
+	}
+
+	// do a git pull, and ignore error already up-to-date
+	err = w.Pull(&git.PullOptions{Progress: stream.Stderr})
+	err = ignoreErrUpToDate(stream, err)
+	if err != nil {
+		err = fmt.Errorf("%q: unable to pull: %w", clonePath, err)
+	}
+
+	return
 }
 
 func ignoreErrUpToDate(stream stream.IOStream, err error) error {
@@ -726,6 +1277,593 @@ func (gg gogit) IsSync(ctx context.Context, clonePath string, cache any) (sync b
 	return true, nil
 }
 
+func (gg gogit) GetUnpushedBranches(ctx context.Context, clonePath string, cache any) (unpushed, localOnly []string, err error) {
+	r := cache.(*git.Repository)
+
+	branches, err := gg.GetBranches(ctx, clonePath, cache)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%q: unable to get branch names: %w", clonePath, err)
+	}
+
+	for _, b := range branches {
+		_, dst, err := getTrackingRefs(r, b)
+		if errors.Is(err, errNoUpstream) {
+			localOnly = append(localOnly, b)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q: unable to get tracking refs: %w", clonePath, err)
+		}
+
+		localRef, err := r.ResolveRevision(plumbing.Revision(plumbing.NewBranchReferenceName(b)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q: unable to resolve branch revision: %w", clonePath, err)
+		}
+
+		// the upstream branch might have been deleted
+		remoteRef, err := r.ResolveRevision(plumbing.Revision(dst))
+		if err != nil {
+			localOnly = append(localOnly, b)
+			continue
+		}
+		if *localRef == *remoteRef {
+			continue
+		}
+
+		// being behind the upstream is fine, nothing is lost
+		localCommit, err := r.CommitObject(*localRef)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q: unable to get commit: %w", clonePath, err)
+		}
+		remoteCommit, err := r.CommitObject(*remoteRef)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q: unable to get commit: %w", clonePath, err)
+		}
+		behind, err := localCommit.IsAncestor(remoteCommit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q: unable to compare commits: %w", clonePath, err)
+		}
+		if !behind {
+			unpushed = append(unpushed, b)
+		}
+	}
+	return unpushed, localOnly, nil
+}
+
+func (gogit) HasStash(ctx context.Context, clonePath string, cache any) (stashed bool, err error) {
+	r := cache.(*git.Repository)
+
+	_, err = r.Reference(plumbing.ReferenceName("refs/stash"), false)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%q: unable to get stash reference: %w", clonePath, err)
+	}
+	return true, nil
+}
+
+func (gogit) Grep(ctx context.Context, clonePath string, cache any, options GrepOptions) (matches []GrepMatch, err error) {
+	r := cache.(*git.Repository)
+
+	pattern, err := options.Regexp()
+	if err != nil {
+		return nil, err
+	}
+	pathspecs, err := options.PathspecRegexps()
+	if err != nil {
+		return nil, err
+	}
+
+	// find the tree to search in
+	// a repository without commits has nothing to search
+	head, err := r.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q: cannot resolve HEAD: %w", clonePath, err)
+	}
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("%q: unable to get HEAD commit: %w", clonePath, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("%q: unable to get HEAD tree: %w", clonePath, err)
+	}
+
+	files := tree.Files()
+	defer files.Close()
+
+	err = files.ForEach(func(file *object.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if len(pathspecs) > 0 && !slices.ContainsFunc(pathspecs, func(re *regexp.Regexp) bool { return re.MatchString(file.Name) }) {
+			return nil
+		}
+
+		if binary, err := file.IsBinary(); err != nil || binary {
+			return err
+		}
+
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		if contents == "" {
+			return nil
+		}
+		contents = strings.TrimSuffix(contents, "\n")
+
+		for i, line := range strings.Split(contents, "\n") {
+			if pattern.MatchString(line) {
+				matches = append(matches, GrepMatch{Path: file.Name, Line: i + 1, Text: line})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%q: unable to search files: %w", clonePath, err)
+	}
+	return matches, nil
+}
+
+func (gogit) Log(ctx context.Context, clonePath string, cache any, options LogOptions, yield func(Commit) bool) error {
+	r := cache.(*git.Repository)
+
+	var author *regexp.Regexp
+	if options.Author != "" {
+		var err error
+		author, err = regexp.Compile(options.Author)
+		if err != nil {
+			return fmt.Errorf("invalid author pattern: %w", err)
+		}
+	}
+
+	// a repository without commits has nothing to iterate
+	head, err := r.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%q: cannot resolve HEAD: %w", clonePath, err)
+	}
+
+	commits, err := r.Log(&git.LogOptions{
+		From:  head.Hash(),
+		All:   options.All,
+		Order: git.LogOrderCommitterTime,
+	})
+	if err != nil {
+		return fmt.Errorf("%q: unable to get commits: %w", clonePath, err)
+	}
+	defer commits.Close()
+
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Commits reachable from HEAD are walked newest commit first.
+		// Commits are authored before they are committed, so no later commit can be in range.
+		if !options.All && !options.Since.IsZero() && commit.Committer.When.Before(options.Since) {
+			return storer.ErrStop
+		}
+
+		if !options.matches(commit, author) {
+			return nil
+		}
+
+		subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		if !yield(Commit{
+			Hash:    commit.Hash.String(),
+			Author:  commit.Author.Name,
+			Email:   commit.Author.Email,
+			Date:    commit.Author.When,
+			Subject: strings.TrimSpace(subject),
+		}) {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%q: unable to iterate commits: %w", clonePath, err)
+	}
+	return nil
+}
+
+func (gogit) GetStats(ctx context.Context, clonePath string, cache any) (stats Stats, err error) {
+	r := cache.(*git.Repository)
+
+	storage, ok := r.Storer.(*filesystem.Storage)
+	if !ok {
+		return stats, fmt.Errorf("%q: repository is not stored on disk", clonePath)
+	}
+	gitDir := storage.Filesystem().Root()
+
+	// compute the sizes
+	if stats.WorktreeSize, err = worktreeSize(ctx, clonePath, gitDir); err != nil {
+		return stats, fmt.Errorf("%q: unable to compute working tree size: %w", clonePath, err)
+	}
+	if err := gitDirStats(ctx, gitDir, &stats); err != nil {
+		return stats, fmt.Errorf("%q: unable to compute git directory size: %w", clonePath, err)
+	}
+
+	// count the branches
+	branches, err := r.Branches()
+	if err != nil {
+		return stats, fmt.Errorf("%q: unable to get branches: %w", clonePath, err)
+	}
+	defer branches.Close()
+	if err := branches.ForEach(func(*plumbing.Reference) error {
+		stats.Branches++
+		return nil
+	}); err != nil {
+		return stats, fmt.Errorf("%q: failed iterate branch refs: %w", clonePath, err)
+	}
+
+	// a repository without commits has nothing else to count
+	head, err := r.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, fmt.Errorf("%q: cannot resolve HEAD: %w", clonePath, err)
+	}
+
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return stats, fmt.Errorf("%q: unable to get HEAD commit: %w", clonePath, err)
+	}
+	stats.LastCommit = commit.Committer.When
+
+	commits, err := r.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return stats, fmt.Errorf("%q: unable to get commits: %w", clonePath, err)
+	}
+	defer commits.Close()
+	if err := commits.ForEach(func(*object.Commit) error {
+		stats.Commits++
+		return ctx.Err()
+	}); err != nil {
+		return stats, fmt.Errorf("%q: unable to count commits: %w", clonePath, err)
+	}
+
+	return stats, nil
+}
+
+func (gogit) GetGitSize(ctx context.Context, clonePath string, cache any) (size int64, err error) {
+	r := cache.(*git.Repository)
+
+	storage, ok := r.Storer.(*filesystem.Storage)
+	if !ok {
+		return 0, fmt.Errorf("%q: repository is not stored on disk", clonePath)
+	}
+
+	var stats Stats
+	if err := gitDirStats(ctx, storage.Filesystem().Root(), &stats); err != nil {
+		return 0, fmt.Errorf("%q: unable to compute git directory size: %w", clonePath, err)
+	}
+	return stats.GitSize, nil
+}
+
+// worktreeSize computes the total size of regular files in the working tree at root.
+// The git directory, as well as any nested repositories, are skipped.
+func worktreeSize(ctx context.Context, root, gitDir string) (size int64, err error) {
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path == gitDir || d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if path != root {
+				// a nested repository is counted on its own
+				if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// gitDirStats computes the size, and number of loose objects and packs, of the git directory at gitDir.
+// The results are stored in stats.
+func gitDirStats(ctx context.Context, gitDir string, stats *Stats) error {
+	objects := filepath.Join(gitDir, "objects")
+	packs := filepath.Join(objects, "pack")
+
+	return filepath.WalkDir(gitDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stats.GitSize += info.Size()
+
+		// loose objects are stored in 'objects/xx/', packs in 'objects/pack/'
+		switch dir := filepath.Dir(path); {
+		case dir == packs && filepath.Ext(path) == ".pack":
+			stats.Packs++
+		case filepath.Dir(dir) == objects && len(filepath.Base(dir)) == 2 && isHex(filepath.Base(dir)):
+			stats.Objects++
+		}
+		return nil
+	})
+}
+
+// isHex checks if s consists of hexadecimal digits only.
+func isHex(s string) bool {
+	return strings.Trim(s, "0123456789abcdef") == ""
+}
+
+// pruneExpiry is the minimum age of unreachable objects and old packs removed by gogit.GarbageCollect.
+// It matches the default of 'gc.pruneExpire'.
+const pruneExpiry = 14 * 24 * time.Hour
+
+func (gogit) GarbageCollect(ctx context.Context, stream stream.IOStream, clonePath string, cache any) error {
+	r := cache.(*git.Repository)
+
+	storage, ok := r.Storer.(*filesystem.Storage)
+	if !ok {
+		return fmt.Errorf("%q: repository is not stored on disk", clonePath)
+	}
+
+	// go-git only considers objects reachable from references.
+	// So determine the objects to keep ourselves, to not lose anything only the index or a reflog refers to.
+	live, err := liveObjects(storage)
+	if err != nil {
+		return fmt.Errorf("%q: unable to find reachable objects: %w", clonePath, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// remove unreachable loose objects, but keep recent ones as they might still be in use
+	expiry := time.Now().Add(-pruneExpiry)
+	if err := r.Prune(git.PruneOptions{
+		OnlyObjectsOlderThan: expiry,
+		Handler: func(hash plumbing.Hash) error {
+			if _, ok := live[hash]; ok {
+				return nil
+			}
+			return r.DeleteObject(hash)
+		},
+	}); err != nil {
+		return fmt.Errorf("%q: unable to prune objects: %w", clonePath, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// a repository without any objects has nothing to repack
+	if len(live) == 0 {
+		return nil
+	}
+
+	if err := repackObjects(r, storage, live, expiry); err != nil {
+		return fmt.Errorf("%q: unable to repack objects: %w", clonePath, err)
+	}
+	return nil
+}
+
+// liveObjects returns the objects in storage that garbage collection must keep.
+// These are all objects reachable from any reference, any reflog entry, or any index entry.
+// The reflogs and index of linked worktrees are considered as well.
+func liveObjects(storage *filesystem.Storage) (map[plumbing.Hash]struct{}, error) {
+	var roots []plumbing.Hash
+
+	refs, err := storage.IterReferences()
+	if err != nil {
+		return nil, fmt.Errorf("unable to iterate references: %w", err)
+	}
+	if err := refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			roots = append(roots, ref.Hash())
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to iterate references: %w", err)
+	}
+
+	idx, err := storage.Index()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read index: %w", err)
+	}
+	for _, entry := range idx.Entries {
+		roots = append(roots, entry.Hash)
+	}
+
+	gitDir := storage.Filesystem().Root()
+	for _, dir := range []string{"logs", "worktrees"} {
+		if err := filepath.WalkDir(filepath.Join(gitDir, dir), func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+
+			rel, err := filepath.Rel(gitDir, path)
+			if err != nil {
+				return err
+			}
+			parts := strings.Split(filepath.ToSlash(rel), "/")
+
+			switch {
+			case slices.Contains(parts, "logs"):
+				roots, err = appendReflogRoots(roots, path)
+			case len(parts) == 3 && parts[0] == "worktrees" && parts[2] == "index":
+				roots, err = appendIndexRoots(roots, path)
+			case len(parts) == 3 && parts[0] == "worktrees" && parts[2] == "HEAD":
+				roots, err = appendHeadRoot(roots, path)
+			}
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// reflog entries and index entries may refer to objects that no longer exist
+	existing := make([]plumbing.Hash, 0, len(roots))
+	for _, root := range roots {
+		if !root.IsZero() && storage.HasEncodedObject(root) == nil {
+			existing = append(existing, root)
+		}
+	}
+
+	hashes, err := revlist.Objects(storage, existing, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to walk objects: %w", err)
+	}
+	live := make(map[plumbing.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		live[hash] = struct{}{}
+	}
+	return live, nil
+}
+
+// appendReflogRoots appends the old and new object of each entry in the reflog at path to roots.
+func appendReflogRoots(roots []plumbing.Hash, path string) ([]plumbing.Hash, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- path is within the git directory
+	if err != nil {
+		return roots, err
+	}
+
+	// each line is of the form 'old new name <email> timestamp timezone\tmessage'
+	for line := range strings.Lines(string(content)) {
+		fields := strings.Fields(line)
+		for _, field := range fields[:min(2, len(fields))] {
+			if plumbing.IsHash(field) {
+				roots = append(roots, plumbing.NewHash(field))
+			}
+		}
+	}
+	return roots, nil
+}
+
+// appendIndexRoots appends the objects of the entries of the index file at path to roots.
+func appendIndexRoots(roots []plumbing.Hash, path string) (_ []plumbing.Hash, err error) {
+	file, err := os.Open(path) // #nosec G304 -- path is within the git directory
+	if err != nil {
+		return roots, err
+	}
+	defer func() {
+		if eClose := file.Close(); eClose != nil && err == nil {
+			err = eClose
+		}
+	}()
+
+	var idx index.Index
+	if err := index.NewDecoder(file).Decode(&idx); err != nil {
+		return roots, fmt.Errorf("unable to decode index %q: %w", path, err)
+	}
+	for _, entry := range idx.Entries {
+		roots = append(roots, entry.Hash)
+	}
+	return roots, nil
+}
+
+// appendHeadRoot appends the object of the HEAD file at path to roots, if it is detached.
+func appendHeadRoot(roots []plumbing.Hash, path string) ([]plumbing.Hash, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- path is within the git directory
+	if err != nil {
+		return roots, err
+	}
+	if head := strings.TrimSpace(string(content)); plumbing.IsHash(head) {
+		roots = append(roots, plumbing.NewHash(head))
+	}
+	return roots, nil
+}
+
+// repackObjects packs the given objects of storage into a single new pack.
+// Afterwards, loose objects that were packed, and any other packs older than expiry, are removed.
+//
+// This is like r.RepackObjects, but packs the given objects instead of only those reachable from references.
+func repackObjects(r *git.Repository, storage *filesystem.Storage, objects map[plumbing.Hash]struct{}, expiry time.Time) (err error) {
+	packs, err := storage.ObjectPacks()
+	if err != nil {
+		return err
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+
+	// write the new pack, and make sure it is stored before removing anything
+	pack, err := writePack(storage, slices.Collect(maps.Keys(objects)), cfg.Pack.Window)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.ForEachObjectHash(func(hash plumbing.Hash) error {
+		if _, ok := objects[hash]; !ok {
+			return nil
+		}
+		return storage.DeleteLooseObject(hash)
+	}); err != nil {
+		return err
+	}
+
+	for _, old := range packs {
+		if old == pack {
+			continue
+		}
+		if err := storage.DeleteOldObjectPackAndIndex(old, expiry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePack writes the given objects into a new pack of storage and returns its hash.
+func writePack(storage *filesystem.Storage, objects []plumbing.Hash, window uint) (pack plumbing.Hash, err error) {
+	writer, err := storage.PackfileWriter()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	defer func() {
+		if eClose := writer.Close(); eClose != nil && err == nil {
+			err = eClose
+		}
+	}()
+
+	return packfile.NewEncoder(writer, storage, false).Encode(objects, window)
+}
+
+func (gogit) RegisterMaintenance(ctx context.Context, clonePath string, cache any) error {
+	return ErrMaintenanceUnsupported
+}
+
 var errNoUpstream = errors.New("failed to find upstream: no corresponding upstream to track")
 
 // getTrackingRefs returns the src and dst upstream tracking refs for the provided branch.
@@ -1,18 +1,24 @@
 package git
 
-//spellchecker:words errors path filepath reflect slices testing github config plumbing ggman internal testutil pkglib stream testlib
+//spellchecker:words context errors fmt io fs path filepath reflect slices strings testing time github config plumbing object ggman internal testutil pkglib stream testlib
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"go.tkw01536.de/ggman/internal/testutil"
 	"go.tkw01536.de/pkglib/stream"
 	"go.tkw01536.de/pkglib/testlib"
@@ -899,6 +905,73 @@ func Test_gogit_IsDirty(t *testing.T) {
 	}
 }
 
+// Test_IsDirty_backends checks that both backends agree on what uncommitted changes are.
+func Test_IsDirty_backends(t *testing.T) {
+	t.Parallel()
+
+	backends := map[string]Plumbing{"gogit": &gogit{}}
+	if gg := (&gitgit{gitPath: os.Getenv("PATH")}); gg.Init() == nil {
+		backends["gitgit"] = gg
+	}
+
+	// newRepo creates a new repository with a single commit, and then calls change on its worktree.
+	newRepo := func(change func(root string, worktree *git.Worktree) error) string {
+		clonePath, repo := testutil.NewTestRepo(t)
+		worktree, _ := testutil.CommitTestFiles(repo)
+		if err := change(clonePath, worktree); err != nil {
+			panic(err)
+		}
+		return clonePath
+	}
+
+	tests := []struct {
+		name      string
+		clonePath string
+		wantDirty bool
+	}{
+		{"clean", newRepo(func(root string, worktree *git.Worktree) error {
+			return nil
+		}), false},
+		{"untracked file", newRepo(func(root string, worktree *git.Worktree) error {
+			return os.WriteFile(filepath.Join(root, "untracked"), []byte("untracked"), 0600)
+		}), true},
+		{"staged file", newRepo(func(root string, worktree *git.Worktree) error {
+			if err := os.WriteFile(filepath.Join(root, "staged"), []byte("staged"), 0600); err != nil {
+				return err
+			}
+			_, err := worktree.Add("staged")
+			return err
+		}), true},
+		{"unstaged change", newRepo(func(root string, worktree *git.Worktree) error {
+			files, err := filepath.Glob(filepath.Join(root, testutil.FileNamePrefix+"*"))
+			if err != nil || len(files) != 1 {
+				return fmt.Errorf("expected a single committed file, got %v: %w", files, err)
+			}
+			return os.WriteFile(files[0], []byte("changed"), 0600)
+		}), true},
+	}
+	for backend, gg := range backends {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				ggRepoObject, isRepo := gg.IsRepository(t.Context(), tt.clonePath)
+				if !isRepo {
+					panic("IsRepository() failed")
+				}
+
+				gotDirty, err := gg.IsDirty(t.Context(), tt.clonePath, ggRepoObject)
+				if err != nil {
+					t.Fatalf("%s.IsDirty() error = %v", backend, err)
+				}
+				if gotDirty != tt.wantDirty {
+					t.Errorf("%s.IsDirty() = %v, want %v", backend, gotDirty, tt.wantDirty)
+				}
+			})
+		}
+	}
+}
+
 func Test_gogit_IsSync(t *testing.T) {
 	t.Parallel()
 
@@ -976,3 +1049,801 @@ func Test_gogit_IsSync(t *testing.T) {
 		})
 	}
 }
+
+func Test_gogit_GetUnpushedBranches(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	// an upstream repository (has no upstream itself)
+	upstream, upstreamRepo := testutil.NewTestRepo(t)
+	_, h1 := testutil.CommitTestFiles(upstreamRepo)
+	testutil.CommitTestFiles(upstreamRepo)
+
+	// a downstream clone that is one commit behind
+	downstreamBehind := testlib.TempDirAbs(t)
+	behindRepo, err := git.PlainClone(downstreamBehind, false, &git.CloneOptions{URL: upstream})
+	if err != nil {
+		panic(err)
+	}
+	wt, err := behindRepo.Worktree()
+	if err != nil {
+		panic(err)
+	}
+	if err := wt.Reset(&git.ResetOptions{
+		Mode:   git.HardReset,
+		Commit: h1,
+	}); err != nil {
+		panic(err)
+	}
+
+	// a downstream clone that is one commit ahead
+	downstreamAhead := testlib.TempDirAbs(t)
+	aheadRepo, err := git.PlainClone(downstreamAhead, false, &git.CloneOptions{URL: upstream})
+	if err != nil {
+		panic(err)
+	}
+	testutil.CommitTestFiles(aheadRepo)
+
+	// a downstream clone with an additional local branch
+	downstreamLocal := testlib.TempDirAbs(t)
+	localRepo, err := git.PlainClone(downstreamLocal, false, &git.CloneOptions{URL: upstream})
+	if err != nil {
+		panic(err)
+	}
+	head, err := localRepo.Head()
+	if err != nil {
+		panic(err)
+	}
+	if err := localRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), head.Hash())); err != nil {
+		panic(err)
+	}
+
+	type args struct {
+		clonePath string
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantUnpushed  []string
+		wantLocalOnly []string
+		wantErr       bool
+	}{
+		{"upstream repo has only local branches", args{clonePath: upstream}, nil, []string{"master"}, false},
+		{"cloned repo that is behind has nothing unpushed", args{clonePath: downstreamBehind}, nil, nil, false},
+		{"cloned repo that is ahead has unpushed branch", args{clonePath: downstreamAhead}, []string{"master"}, nil, false},
+		{"cloned repo with local branch", args{clonePath: downstreamLocal}, nil, []string{"feature"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ggRepoObject, isRepo := gg.IsRepository(t.Context(), tt.args.clonePath)
+			if !isRepo {
+				panic("IsRepository() failed")
+			}
+
+			gotUnpushed, gotLocalOnly, err := gg.GetUnpushedBranches(t.Context(), tt.args.clonePath, ggRepoObject)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("gogit.GetUnpushedBranches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotUnpushed, tt.wantUnpushed) {
+				t.Errorf("gogit.GetUnpushedBranches() unpushed = %v, want %v", gotUnpushed, tt.wantUnpushed)
+			}
+			if !reflect.DeepEqual(gotLocalOnly, tt.wantLocalOnly) {
+				t.Errorf("gogit.GetUnpushedBranches() localOnly = %v, want %v", gotLocalOnly, tt.wantLocalOnly)
+			}
+		})
+	}
+}
+
+func Test_gogit_HasStash(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	// a repository without a stash
+	clean, cleanRepo := testutil.NewTestRepo(t)
+	testutil.CommitTestFiles(cleanRepo)
+
+	// a repository with a stash
+	stashed, stashedRepo := testutil.NewTestRepo(t)
+	_, hash := testutil.CommitTestFiles(stashedRepo)
+	if err := stashedRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName("refs/stash"), hash)); err != nil {
+		panic(err)
+	}
+
+	type args struct {
+		clonePath string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantStashed bool
+		wantErr     bool
+	}{
+		{"repository without stash", args{clonePath: clean}, false, false},
+		{"repository with stash", args{clonePath: stashed}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ggRepoObject, isRepo := gg.IsRepository(t.Context(), tt.args.clonePath)
+			if !isRepo {
+				panic("IsRepository() failed")
+			}
+
+			gotStashed, err := gg.HasStash(t.Context(), tt.args.clonePath, ggRepoObject)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("gogit.HasStash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotStashed != tt.wantStashed {
+				t.Errorf("gogit.HasStash() = %v, want %v", gotStashed, tt.wantStashed)
+			}
+		})
+	}
+}
+
+func Test_gogit_Grep(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	// a repository without any commits
+	empty, _ := testutil.NewTestRepo(t)
+
+	// a repository with some files to search
+	clonePath, repo := testutil.NewTestRepo(t)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		panic(err)
+	}
+	for name, content := range map[string]string{
+		filepath.Join("cmd", "main.go"): "package main\n\n// Hello world\nfunc main() {}\n",
+		"README.md":                     "# hello\nsay hello to the world\n",
+		"binary.dat":                    "hello\x00world",
+		"[weird].txt":                   "hello brackets\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(clonePath, name)), os.ModePerm); err != nil {
+			panic(err)
+		}
+		if err := os.WriteFile(filepath.Join(clonePath, name), []byte(content), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+			panic(err)
+		}
+		if _, err := worktree.Add(filepath.ToSlash(name)); err != nil {
+			panic(err)
+		}
+	}
+	testutil.CommitTestFiles(repo)
+
+	// uncommitted changes are not searched
+	if err := os.WriteFile(filepath.Join(clonePath, "README.md"), []byte("hello uncommitted\n"), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name      string
+		clonePath string
+		options   GrepOptions
+		want      []GrepMatch
+		wantErr   bool
+	}{
+		{"repository without commits", empty, GrepOptions{Pattern: "hello"}, nil, false},
+		{"no matches", clonePath, GrepOptions{Pattern: "goodbye"}, nil, false},
+		{"case sensitive", clonePath, GrepOptions{Pattern: "hello"}, []GrepMatch{
+			{Path: "README.md", Line: 1, Text: "# hello"},
+			{Path: "README.md", Line: 2, Text: "say hello to the world"},
+			{Path: "[weird].txt", Line: 1, Text: "hello brackets"},
+		}, false},
+		{"case insensitive", clonePath, GrepOptions{Pattern: "hello", IgnoreCase: true}, []GrepMatch{
+			{Path: "README.md", Line: 1, Text: "# hello"},
+			{Path: "README.md", Line: 2, Text: "say hello to the world"},
+			{Path: "[weird].txt", Line: 1, Text: "hello brackets"},
+			{Path: "cmd/main.go", Line: 3, Text: "// Hello world"},
+		}, false},
+		{"extended regular expression", clonePath, GrepOptions{Pattern: "^(package|func) "}, []GrepMatch{
+			{Path: "cmd/main.go", Line: 1, Text: "package main"},
+			{Path: "cmd/main.go", Line: 4, Text: "func main() {}"},
+		}, false},
+		{"glob pathspec", clonePath, GrepOptions{Pattern: "hello", IgnoreCase: true, Pathspecs: []string{"*.go"}}, []GrepMatch{
+			{Path: "cmd/main.go", Line: 3, Text: "// Hello world"},
+		}, false},
+		{"directory pathspec", clonePath, GrepOptions{Pattern: "main", Pathspecs: []string{"cmd"}}, []GrepMatch{
+			{Path: "cmd/main.go", Line: 1, Text: "package main"},
+			{Path: "cmd/main.go", Line: 4, Text: "func main() {}"},
+		}, false},
+		{"escaped pathspec", clonePath, GrepOptions{Pattern: "hello", Pathspecs: []string{`\[weird].txt`}}, []GrepMatch{
+			{Path: "[weird].txt", Line: 1, Text: "hello brackets"},
+		}, false},
+		{"several pathspecs", clonePath, GrepOptions{Pattern: "world", Pathspecs: []string{"README.*", "cmd/*"}}, []GrepMatch{
+			{Path: "README.md", Line: 2, Text: "say hello to the world"},
+			{Path: "cmd/main.go", Line: 3, Text: "// Hello world"},
+		}, false},
+		{"invalid pattern", clonePath, GrepOptions{Pattern: "("}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ggRepoObject, isRepo := gg.IsRepository(t.Context(), tt.clonePath)
+			if !isRepo {
+				panic("IsRepository() failed")
+			}
+
+			got, err := gg.Grep(t.Context(), tt.clonePath, ggRepoObject, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("gogit.Grep() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gogit.Grep() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_Grep_backends checks that both grep backends agree on the supported pattern syntax.
+func Test_Grep_backends(t *testing.T) {
+	t.Parallel()
+
+	backends := map[string]Plumbing{"gogit": &gogit{}}
+	if gg := (&gitgit{gitPath: os.Getenv("PATH")}); gg.Init() == nil {
+		backends["gitgit"] = gg
+	}
+
+	clonePath, repo := testutil.NewTestRepo(t)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(filepath.Join(clonePath, "main.go"), []byte("package main\n\n// Version 1.23\nfunc main() {}\ntype Foo struct{}\n"), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+		panic(err)
+	}
+	if _, err := worktree.Add("main.go"); err != nil {
+		panic(err)
+	}
+	testutil.CommitTestFiles(repo)
+
+	tests := []struct {
+		name    string
+		options GrepOptions
+		want    []int
+		wantErr bool
+	}{
+		{"alternation", GrepOptions{Pattern: "^(package|func) "}, []int{1, 4}, false},
+		{"bracket expression", GrepOptions{Pattern: `[[:digit:]]+\.[[:digit:]]+`}, []int{3}, false},
+		{"interval", GrepOptions{Pattern: "o{2}"}, []int{5}, false},
+		{"anchored", GrepOptions{Pattern: `\{\}$`}, []int{4, 5}, false},
+		{"case insensitive", GrepOptions{Pattern: "VERSION", IgnoreCase: true}, []int{3}, false},
+		{"invalid pattern", GrepOptions{Pattern: "("}, nil, true},
+		{"perl character class", GrepOptions{Pattern: `\d`}, nil, true},
+		{"perl word boundary", GrepOptions{Pattern: `\bmain\b`}, nil, true},
+		{"perl whitespace class", GrepOptions{Pattern: `main\s`}, nil, true},
+		{"inline flag", GrepOptions{Pattern: "(?i)foo"}, nil, true},
+	}
+	for backend, gg := range backends {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				ggRepoObject, isRepo := gg.IsRepository(t.Context(), clonePath)
+				if !isRepo {
+					panic("IsRepository() failed")
+				}
+
+				got, err := gg.Grep(t.Context(), clonePath, ggRepoObject, tt.options)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s.Grep() error = %v, wantErr %v", backend, err, tt.wantErr)
+					return
+				}
+
+				var gotLines []int
+				for _, match := range got {
+					gotLines = append(gotLines, match.Line)
+				}
+				if !reflect.DeepEqual(gotLines, tt.want) {
+					t.Errorf("%s.Grep() lines = %v, want %v", backend, gotLines, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func Test_GetConfig_backends(t *testing.T) {
+	t.Parallel()
+
+	backends := map[string]Plumbing{"gogit": &gogit{}}
+	if gg := (&gitgit{gitPath: os.Getenv("PATH")}); gg.Init() == nil {
+		backends["gitgit"] = gg
+	}
+
+	clonePath, repo := testutil.NewTestRepo(t)
+	cfg, err := repo.Config()
+	if err != nil {
+		panic(err)
+	}
+	cfg.Raw.Section("ggman").SetOption("option", "value")
+	cfg.Raw.Section("ggman").Subsection("sub").SetOption("option", "sub value")
+	if err := repo.SetConfig(cfg); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"option", "ggman.option", "value", false},
+		{"option in subsection", "ggman.sub.option", "sub value", false},
+		{"unset option", "ggman.missing", "", false},
+		{"unset section", "ggman-missing.option", "", false},
+		{"invalid key", "ggman", "", true},
+	}
+	for backend, gg := range backends {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				ggRepoObject, isRepo := gg.IsRepository(t.Context(), clonePath)
+				if !isRepo {
+					panic("IsRepository() failed")
+				}
+
+				got, err := gg.GetConfig(t.Context(), clonePath, ggRepoObject, tt.key)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s.GetConfig() error = %v, wantErr %v", backend, err, tt.wantErr)
+					return
+				}
+				if got != tt.want {
+					t.Errorf("%s.GetConfig() = %q, want %q", backend, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func Test_gogit_Log(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	// a repository without any commits
+	empty, _ := testutil.NewTestRepo(t)
+
+	// a repository with commits on two different days
+	// and an additional commit on a different branch
+	clonePath, repo := testutil.NewTestRepo(t)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		panic(err)
+	}
+
+	day := func(d, hour int) time.Time {
+		return time.Date(2020, time.January, d, hour, 0, 0, 0, time.UTC)
+	}
+	commit := func(message, name string, when time.Time) string {
+		hash, err := worktree.Commit(message, &git.CommitOptions{
+			Author:            &object.Signature{Name: name, Email: strings.ToLower(name) + "@example.com", When: when},
+			AllowEmptyCommits: true,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return hash.String()
+	}
+
+	first := commit("first commit\n\nwith a body", "Alice", day(1, 10))
+	second := commit("second commit", "Bob", day(2, 10))
+	third := commit("third commit", "Alice", day(2, 12))
+
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		panic(err)
+	}
+	feature := commit("feature commit", "Alice", day(3, 10))
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.Master}); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name      string
+		clonePath string
+		options   LogOptions
+		want      []Commit
+		wantErr   bool
+	}{
+		{"repository without commits", empty, LogOptions{}, nil, false},
+		{"all commits on HEAD", clonePath, LogOptions{}, []Commit{
+			{Hash: third, Author: "Alice", Email: "alice@example.com", Date: day(2, 12), Subject: "third commit"},
+			{Hash: second, Author: "Bob", Email: "bob@example.com", Date: day(2, 10), Subject: "second commit"},
+			{Hash: first, Author: "Alice", Email: "alice@example.com", Date: day(1, 10), Subject: "first commit"},
+		}, false},
+		{"all branches", clonePath, LogOptions{All: true, Since: day(2, 11)}, []Commit{
+			{Hash: feature, Author: "Alice", Email: "alice@example.com", Date: day(3, 10), Subject: "feature commit"},
+			{Hash: third, Author: "Alice", Email: "alice@example.com", Date: day(2, 12), Subject: "third commit"},
+		}, false},
+		{"time range", clonePath, LogOptions{Since: day(2, 0), Until: day(2, 12)}, []Commit{
+			{Hash: second, Author: "Bob", Email: "bob@example.com", Date: day(2, 10), Subject: "second commit"},
+		}, false},
+		{"author", clonePath, LogOptions{Author: "alice@"}, []Commit{
+			{Hash: third, Author: "Alice", Email: "alice@example.com", Date: day(2, 12), Subject: "third commit"},
+			{Hash: first, Author: "Alice", Email: "alice@example.com", Date: day(1, 10), Subject: "first commit"},
+		}, false},
+		{"no matches", clonePath, LogOptions{Since: day(4, 0)}, nil, false},
+		{"invalid author", clonePath, LogOptions{Author: "("}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ggRepoObject, isRepo := gg.IsRepository(t.Context(), tt.clonePath)
+			if !isRepo {
+				panic("IsRepository() failed")
+			}
+
+			var got []Commit
+			err := gg.Log(t.Context(), tt.clonePath, ggRepoObject, tt.options, func(commit Commit) bool {
+				commit.Date = commit.Date.UTC()
+				got = append(got, commit)
+				return true
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("gogit.Log() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gogit.Log() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("stop iterating", func(t *testing.T) {
+		t.Parallel()
+
+		ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+
+		var count int
+		if err := gg.Log(t.Context(), clonePath, ggRepoObject, LogOptions{}, func(Commit) bool {
+			count++
+			return false
+		}); err != nil {
+			t.Errorf("gogit.Log() error = %v", err)
+		}
+		if count != 1 {
+			t.Errorf("gogit.Log() yielded %d commits, want 1", count)
+		}
+	})
+}
+
+func Test_gogit_GetStats(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	// a repository without any commits
+	empty, _ := testutil.NewTestRepo(t)
+
+	// a repository with two commits, an additional branch and a nested repository
+	clonePath, repo := testutil.NewTestRepo(t)
+	testutil.CommitTestFiles(repo)
+	_, head := testutil.CommitTestFiles(repo)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("other"), head)); err != nil {
+		panic(err)
+	}
+	headCommit, err := repo.CommitObject(head)
+	if err != nil {
+		panic(err)
+	}
+
+	nested, _ := testutil.NewTestRepo(t)
+	if err := os.Rename(nested, filepath.Join(clonePath, "nested")); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(filepath.Join(clonePath, "nested", "ignored.txt"), []byte("not counted"), os.ModePerm /* #nosec G306 -- fine for testing */); err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name      string
+		clonePath string
+		want      Stats
+	}{
+		{"repository without commits", empty, Stats{}},
+		{"repository with commits", clonePath, Stats{
+			WorktreeSize: 2 * int64(len(testutil.FileContents)),
+			Objects:      5, // one blob (both files are equal), two trees and two commits
+			Packs:        0,
+			Commits:      2,
+			Branches:     2,
+			LastCommit:   headCommit.Committer.When,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ggRepoObject, isRepo := gg.IsRepository(t.Context(), tt.clonePath)
+			if !isRepo {
+				panic("IsRepository() failed")
+			}
+
+			got, err := gg.GetStats(t.Context(), tt.clonePath, ggRepoObject)
+			if err != nil {
+				t.Fatalf("gogit.GetStats() error = %v", err)
+			}
+
+			if got.GitSize <= 0 {
+				t.Errorf("gogit.GetStats() GitSize = %d, want positive", got.GitSize)
+			}
+			got.GitSize = 0
+
+			if !got.LastCommit.Equal(tt.want.LastCommit) {
+				t.Errorf("gogit.GetStats() LastCommit = %v, want %v", got.LastCommit, tt.want.LastCommit)
+			}
+			got.LastCommit = tt.want.LastCommit
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gogit.GetStats() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+		if _, err := gg.GetStats(ctx, clonePath, ggRepoObject); !errors.Is(err, context.Canceled) {
+			t.Errorf("gogit.GetStats() error = %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func Test_gogit_GetGitSize(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	clonePath, repo := testutil.NewTestRepo(t)
+	testutil.CommitTestFiles(repo)
+
+	ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+	stats, err := gg.GetStats(t.Context(), clonePath, ggRepoObject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := gg.GetGitSize(t.Context(), clonePath, ggRepoObject)
+	if err != nil {
+		t.Fatalf("gogit.GetGitSize() error = %v", err)
+	}
+	if got != stats.GitSize {
+		t.Errorf("gogit.GetGitSize() = %d, want %d", got, stats.GitSize)
+	}
+}
+
+func Test_gogit_GarbageCollect(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	// storeBlob stores a new unreachable blob in repo, and sets its modification time to when.
+	storeBlob := func(clonePath string, repo *git.Repository, content string, when time.Time) plumbing.Hash {
+		obj := repo.Storer.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		w, err := obj.Writer()
+		if err != nil {
+			panic(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			panic(err)
+		}
+		if err := w.Close(); err != nil {
+			panic(err)
+		}
+		hash, err := repo.Storer.SetEncodedObject(obj)
+		if err != nil {
+			panic(err)
+		}
+
+		name := hash.String()
+		if err := os.Chtimes(filepath.Join(clonePath, ".git", "objects", name[:2], name[2:]), when, when); err != nil {
+			panic(err)
+		}
+		return hash
+	}
+
+	t.Run("repository without commits", func(t *testing.T) {
+		t.Parallel()
+
+		clonePath, _ := testutil.NewTestRepo(t)
+		ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+
+		if err := gg.GarbageCollect(t.Context(), stream.FromNil(), clonePath, ggRepoObject); err != nil {
+			t.Errorf("gogit.GarbageCollect() error = %v", err)
+		}
+	})
+
+	t.Run("repository with commits", func(t *testing.T) {
+		t.Parallel()
+
+		clonePath, repo := testutil.NewTestRepo(t)
+		testutil.CommitTestFiles(repo)
+		testutil.CommitTestFiles(repo)
+
+		old := storeBlob(clonePath, repo, "old and unreachable", time.Now().Add(-30*24*time.Hour))
+		recent := storeBlob(clonePath, repo, "recent and unreachable", time.Now())
+
+		ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+		if err := gg.GarbageCollect(t.Context(), stream.FromNil(), clonePath, ggRepoObject); err != nil {
+			t.Fatalf("gogit.GarbageCollect() error = %v", err)
+		}
+
+		// re-open the repository to not rely on any cached state
+		ggRepoObject, _ = gg.IsRepository(t.Context(), clonePath)
+		got, err := gg.GetStats(t.Context(), clonePath, ggRepoObject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Objects != 1 || got.Packs != 1 || got.Commits != 2 {
+			t.Errorf("gogit.GarbageCollect() left %d objects, %d packs and %d commits, want 1, 1 and 2", got.Objects, got.Packs, got.Commits)
+		}
+
+		r := ggRepoObject.(*git.Repository)
+		if _, err := r.BlobObject(old); !errors.Is(err, plumbing.ErrObjectNotFound) {
+			t.Errorf("old unreachable object: error = %v, want %v", err, plumbing.ErrObjectNotFound)
+		}
+		if _, err := r.BlobObject(recent); err != nil {
+			t.Errorf("recent unreachable object: error = %v", err)
+		}
+	})
+
+	t.Run("objects only referenced by the index or a reflog", func(t *testing.T) {
+		t.Parallel()
+
+		clonePath, repo := testutil.NewTestRepo(t)
+		testutil.CommitTestFiles(repo)
+		head, err := repo.Head()
+		if err != nil {
+			panic(err)
+		}
+
+		// a commit that is only referenced by the reflog
+		worktree, orphan := testutil.CommitTestFiles(repo)
+		if err := worktree.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.SoftReset}); err != nil {
+			panic(err)
+		}
+		reflog := fmt.Sprintf("%s %s A U Thor <author@example.com> 0 +0000\tcommit: orphan\n", head.Hash(), orphan)
+		if err := os.MkdirAll(filepath.Join(clonePath, ".git", "logs"), os.ModePerm); err != nil {
+			panic(err)
+		}
+		if err := os.WriteFile(filepath.Join(clonePath, ".git", "logs", "HEAD"), []byte(reflog), 0600); err != nil {
+			panic(err)
+		}
+
+		// a blob that is only referenced by the index
+		if err := os.WriteFile(filepath.Join(clonePath, "staged"), []byte("staged but not committed"), 0600); err != nil {
+			panic(err)
+		}
+		staged, err := worktree.Add("staged")
+		if err != nil {
+			panic(err)
+		}
+
+		// make all objects old enough to be removed when unreachable
+		old := time.Now().Add(-30 * 24 * time.Hour)
+		if err := filepath.WalkDir(filepath.Join(clonePath, ".git", "objects"), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			return os.Chtimes(path, old, old)
+		}); err != nil {
+			panic(err)
+		}
+
+		ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+		if err := gg.GarbageCollect(t.Context(), stream.FromNil(), clonePath, ggRepoObject); err != nil {
+			t.Fatalf("gogit.GarbageCollect() error = %v", err)
+		}
+
+		// re-open the repository to not rely on any cached state
+		ggRepoObject, _ = gg.IsRepository(t.Context(), clonePath)
+		r := ggRepoObject.(*git.Repository)
+		if _, err := r.BlobObject(staged); err != nil {
+			t.Errorf("object only referenced by the index: error = %v", err)
+		}
+		commit, err := r.CommitObject(orphan)
+		if err != nil {
+			t.Fatalf("commit only referenced by a reflog: error = %v", err)
+		}
+		if _, err := commit.Tree(); err != nil {
+			t.Errorf("tree of commit only referenced by a reflog: error = %v", err)
+		}
+	})
+}
+
+func Test_gogit_RegisterMaintenance(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	clonePath, _ := testutil.NewTestRepo(t)
+	ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+
+	if err := gg.RegisterMaintenance(t.Context(), clonePath, ggRepoObject); !errors.Is(err, ErrMaintenanceUnsupported) {
+		t.Errorf("gogit.RegisterMaintenance() error = %v, want %v", err, ErrMaintenanceUnsupported)
+	}
+}
+
+func Test_gogit_SetRemotePushURLs(t *testing.T) {
+	t.Parallel()
+
+	var gg gogit
+
+	clonePath, repo := testutil.NewTestRepo(t)
+	if _, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://example.com/fetch.git"},
+	}); err != nil {
+		panic(err)
+	}
+
+	// check reads the fetch and push urls, and compares them against the expected values.
+	check := func(wantFetch, wantPush []string) {
+		t.Helper()
+
+		ggRepoObject, isRepo := gg.IsRepository(t.Context(), clonePath)
+		if !isRepo {
+			panic("IsRepository() failed")
+		}
+
+		remotes, err := gg.GetRemotes(t.Context(), clonePath, ggRepoObject)
+		if err != nil {
+			t.Fatalf("gogit.GetRemotes() error = %v", err)
+		}
+		if !slices.Equal(remotes["origin"], wantFetch) {
+			t.Errorf("gogit.GetRemotes() = %v, want %v", remotes["origin"], wantFetch)
+		}
+
+		gotPush, err := gg.GetRemotePushURLs(t.Context(), clonePath, ggRepoObject, "origin")
+		if err != nil {
+			t.Fatalf("gogit.GetRemotePushURLs() error = %v", err)
+		}
+		if !slices.Equal(gotPush, wantPush) {
+			t.Errorf("gogit.GetRemotePushURLs() = %v, want %v", gotPush, wantPush)
+		}
+	}
+
+	// set sets the push urls of the origin remote.
+	set := func(urls ...string) {
+		t.Helper()
+
+		ggRepoObject, isRepo := gg.IsRepository(t.Context(), clonePath)
+		if !isRepo {
+			panic("IsRepository() failed")
+		}
+		if err := gg.SetRemotePushURLs(t.Context(), clonePath, ggRepoObject, "origin", urls); err != nil {
+			t.Fatalf("gogit.SetRemotePushURLs() error = %v", err)
+		}
+	}
+
+	check([]string{"https://example.com/fetch.git"}, nil)
+
+	set("git@example.com:push.git")
+	check([]string{"https://example.com/fetch.git"}, []string{"git@example.com:push.git"})
+
+	// updating the fetch url keeps the push url
+	ggRepoObject, _ := gg.IsRepository(t.Context(), clonePath)
+	if err := gg.SetRemoteURLs(t.Context(), clonePath, ggRepoObject, "origin", []string{"https://example.com/other.git"}); err != nil {
+		t.Fatalf("gogit.SetRemoteURLs() error = %v", err)
+	}
+	check([]string{"https://example.com/other.git"}, []string{"git@example.com:push.git"})
+
+	set()
+	check([]string{"https://example.com/other.git"}, nil)
+}
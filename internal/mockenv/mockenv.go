@@ -28,7 +28,7 @@ import (
 	"go.tkw01536.de/pkglib/testlib"
 )
 
-//spellchecker:words GGROOT workdir sandboxed contextcheck
+//spellchecker:words GGROOT GGSCAN GGLAYOUT workdir sandboxed contextcheck
 
 // MockEnv represents a new environment that can be used for testing ggman commands.
 //
@@ -83,6 +83,32 @@ func (mock *MockEnv) SetCanfile(canfile string) {
 	mock.vars.CANFILE = canfile
 }
 
+// SetRoots sets additional root directories for the mock environment.
+// They are added to GGROOT after the default root directory.
+func (mock *MockEnv) SetRoots(roots ...string) {
+	mock.vars.GGROOT = strings.Join(append([]string{mock.localRoot}, roots...), string(filepath.ListSeparator))
+}
+
+// SetScan sets the GGSCAN for the mock environment.
+func (mock *MockEnv) SetScan(roots ...string) {
+	mock.vars.GGSCAN = strings.Join(roots, string(filepath.ListSeparator))
+}
+
+// SetLayout sets the GGLAYOUT for the mock environment.
+func (mock *MockEnv) SetLayout(layout string) {
+	mock.vars.GGLAYOUT = layout
+}
+
+// SetJunk sets the GGJUNK for the mock environment.
+func (mock *MockEnv) SetJunk(junk ...string) {
+	mock.vars.GGJUNK = strings.Join(junk, string(filepath.ListSeparator))
+}
+
+// SetArchive sets the GGARCHIVE for the mock environment.
+func (mock *MockEnv) SetArchive(archive string) {
+	mock.vars.GGARCHIVE = archive
+}
+
 // Resolve resolves a local path within this environment.
 func (mock *MockEnv) Resolve(path ...string) string {
 	return filepath.Join(append([]string{mock.localRoot}, path...)...)
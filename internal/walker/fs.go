@@ -103,3 +103,84 @@ func (rfs realFS) Sub(path, rpath string, entry fs.DirEntry) FS {
 		FollowLinks: rfs.FollowLinks,
 	}
 }
+
+// NewExcludeFS returns a new filesystem that behaves like fs, but omits the provided paths.
+// Paths are compared against the path of the parent joined with the name of each entry.
+//
+// This can be used to e.g. sweep a directory tree as if the excluded paths had already been deleted.
+func NewExcludeFS(fs FS, exclude ...string) FS {
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, path := range exclude {
+		excluded[filepath.Clean(path)] = struct{}{}
+	}
+	return excludeFS{FS: fs, excluded: excluded}
+}
+
+// excludeFS wraps an FS to exclude specific paths.
+//
+// This struct is untested; tests are done via Sweep.
+type excludeFS struct {
+	FS
+	excluded map[string]struct{}
+}
+
+func (efs excludeFS) Read(path string) ([]fs.DirEntry, error) {
+	entries, err := efs.FS.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, entry := range entries {
+		if _, ok := efs.excluded[filepath.Join(path, entry.Name())]; ok {
+			continue
+		}
+		entries[n] = entry
+		n++
+	}
+	return entries[:n], nil
+}
+
+func (efs excludeFS) Sub(path, rpath string, entry fs.DirEntry) FS {
+	return excludeFS{FS: efs.FS.Sub(path, rpath, entry), excluded: efs.excluded}
+}
+
+// NewIgnoreFS returns a new filesystem that behaves like fs, but omits non-directory entries with the provided names.
+//
+// This can be used to e.g. sweep a directory tree treating junk files like '.DS_Store' as if they did not exist.
+func NewIgnoreFS(fs FS, names ...string) FS {
+	ignored := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		ignored[name] = struct{}{}
+	}
+	return ignoreFS{FS: fs, ignored: ignored}
+}
+
+// ignoreFS wraps an FS to ignore files with specific names.
+//
+// This struct is untested; tests are done via Sweep.
+type ignoreFS struct {
+	FS
+	ignored map[string]struct{}
+}
+
+func (ifs ignoreFS) Read(path string) ([]fs.DirEntry, error) {
+	entries, err := ifs.FS.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, entry := range entries {
+		if _, ok := ifs.ignored[entry.Name()]; ok && !entry.IsDir() {
+			continue
+		}
+		entries[n] = entry
+		n++
+	}
+	return entries[:n], nil
+}
+
+func (ifs ignoreFS) Sub(path, rpath string, entry fs.DirEntry) FS {
+	return ignoreFS{FS: ifs.FS.Sub(path, rpath, entry), ignored: ifs.ignored}
+}
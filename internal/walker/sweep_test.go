@@ -97,6 +97,35 @@ func TestSweep(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"sweep / excluding /f/f",
+			nil,
+			walker.Params{
+				Root: walker.NewExcludeFS(walker.NewRealFS(base, false), filepath.Join(base, "f", "f")),
+			},
+			[]string{
+				"e/e1",
+				"e/e2",
+				"f/e",
+				"e",
+				"f",
+				".",
+			},
+			false,
+		},
+		{
+			"sweep /f ignoring files named file",
+			nil,
+			walker.Params{
+				Root: walker.NewIgnoreFS(walker.NewRealFS(filepath.Join(base, "f"), false), "file"),
+			},
+			[]string{
+				"f/e",
+				"f/f",
+				"f",
+			},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {